@@ -0,0 +1,60 @@
+// Command apikey creates an API key and prints it. It is how the first admin
+// key of a tenant is made, since the API itself needs a key to create more.
+//
+//	go run ./cmd/apikey -name ops -scopes admin -tenant acme
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/adapters/postgres"
+	"github.com/iamtbay/is-management/internal/config"
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/internal/service"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	name := flag.String("name", "", "name of the key, e.g. the client that uses it")
+	scopes := flag.String("scopes", "admin", "comma-separated scopes: products:read, products:write, orders:write, admin")
+	tenant := flag.String("tenant", domain.DefaultTenant, "tenant the key belongs to")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Error loading .env file")
+	}
+	config, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	conn, err := postgres.NewDB(config.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+	defer conn.Close()
+
+	apiKey := &domain.APIKey{Name: *name}
+	for _, scope := range strings.Split(*scopes, ",") {
+		apiKey.Scopes = append(apiKey.Scopes, domain.Scope(strings.TrimSpace(scope)))
+	}
+
+	if !domain.ValidTenant(*tenant) {
+		log.Fatalf("Invalid tenant %q, expected lowercase letters, digits, dashes or underscores", *tenant)
+	}
+	ctx, cancel := context.WithTimeout(domain.WithTenant(context.Background(), *tenant), 10*time.Second)
+	defer cancel()
+	apiKeySvc := service.NewAPIKeyService(postgres.NewAPIKeyRepository(conn))
+	key, err := apiKeySvc.CreateKey(apiKey, ctx)
+	if err != nil {
+		log.Fatalf("Error creating api key: %v", err)
+	}
+	fmt.Printf("Created api key %s (%s) for tenant %s with scopes %s\n", apiKey.ID, apiKey.Name, apiKey.TenantID, *scopes)
+	fmt.Println(key)
+	fmt.Println("Store it now, it cannot be shown again.")
+}
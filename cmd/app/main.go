@@ -4,10 +4,18 @@ package main
 // @version 1.0
 // @description This is a sample server for managing stock and orders.
 // @host localhost:8080
-// @BasePath /
+// @BasePath /v1
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description A user access token from /auth/login, as "Bearer <token>"
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"log"
 	"log/slog"
@@ -20,19 +28,24 @@ import (
 	"github.com/iamtbay/is-management/internal/adapters/api"
 	"github.com/iamtbay/is-management/internal/adapters/postgres"
 	"github.com/iamtbay/is-management/internal/config"
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/internal/logging"
 	"github.com/iamtbay/is-management/internal/service"
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	logger := slog.New(logging.NewContextHandler(slog.NewJSONHandler(os.Stdout, nil)))
 	slog.SetDefault(logger)
 	if err := godotenv.Load(); err != nil {
 		logger.Error("Error loading .env file")
 	}
 
 	//config
-	config := config.LoadConfig()
+	config, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
 	conn, err := postgres.NewDB(config.DatabaseURL)
 	if err != nil {
@@ -43,25 +56,169 @@ func main() {
 	//REPOS
 	productRepo := postgres.NewProductRepository(conn)
 	orderRepo := postgres.NewOrderRepository(conn)
+	supplierRepo := postgres.NewSupplierRepository(conn)
+	purchaseOrderRepo := postgres.NewPurchaseOrderRepository(conn)
+	costLayerRepo := postgres.NewCostLayerRepository(conn)
+	stockHistoryRepo := postgres.NewStockHistoryRepository(conn)
+	lotRepo := postgres.NewLotRepository(conn)
+	serialRepo := postgres.NewSerialRepository(conn)
+	expectedReceiptRepo := postgres.NewExpectedReceiptRepository(conn)
+	webhookRepo := postgres.NewWebhookRepository(conn)
+	apiKeyRepo := postgres.NewAPIKeyRepository(conn)
+	userRepo := postgres.NewUserRepository(conn)
+	transactor := postgres.NewTransactor(conn)
 	logger.Info("Repositories initialized")
 	//REPOS END
 
 	//SERVICES
-	productSvc := service.NewProductService(productRepo)
-	orderSvc := service.NewOrderService(orderRepo, productRepo)
+	stockHistorySvc := service.NewStockHistoryService(stockHistoryRepo)
+	eventSvc := service.NewEventService(config.EventBufferSize)
+	costingMethod := domain.CostingMethod(config.CostingMethod)
+	if !costingMethod.Valid() {
+		log.Fatalf("Invalid COSTING_METHOD %q, expected fifo or average", config.CostingMethod)
+	}
+	valuationSvc := service.NewValuationService(costLayerRepo, costingMethod)
+	productSvc := service.NewProductService(service.ProductServiceDeps{
+		Transactor:   transactor,
+		Products:     productRepo,
+		Valuation:    valuationSvc,
+		StockHistory: stockHistorySvc,
+		Events:       eventSvc,
+	})
+	lotSvc := service.NewLotService(service.LotServiceDeps{
+		Transactor:   transactor,
+		Lots:         lotRepo,
+		Products:     productRepo,
+		StockHistory: stockHistorySvc,
+		Events:       eventSvc,
+	})
+	serialSvc := service.NewSerialService(service.SerialServiceDeps{
+		Transactor:   transactor,
+		Serials:      serialRepo,
+		Products:     productRepo,
+		Valuation:    valuationSvc,
+		StockHistory: stockHistorySvc,
+		Events:       eventSvc,
+	})
+	atpSvc := service.NewATPService(productRepo, expectedReceiptRepo)
+	orderSvc := service.NewOrderService(service.OrderServiceDeps{
+		Transactor:   transactor,
+		Orders:       orderRepo,
+		Products:     productRepo,
+		Valuation:    valuationSvc,
+		StockHistory: stockHistorySvc,
+		Lots:         lotSvc,
+		Serials:      serialSvc,
+		Events:       eventSvc,
+	})
+	supplierSvc := service.NewSupplierService(supplierRepo, productRepo)
+	purchaseOrderSvc := service.NewPurchaseOrderService(service.PurchaseOrderServiceDeps{
+		Transactor:     transactor,
+		PurchaseOrders: purchaseOrderRepo,
+		Suppliers:      supplierRepo,
+		Products:       productRepo,
+		Valuation:      valuationSvc,
+		StockHistory:   stockHistorySvc,
+		Lots:           lotSvc,
+		Serials:        serialSvc,
+		ATP:            atpSvc,
+		Events:         eventSvc,
+	})
+	replenishmentSvc := service.NewReplenishmentService(service.ReplenishmentServiceDeps{
+		Transactor:     transactor,
+		Products:       productRepo,
+		Orders:         orderRepo,
+		Suppliers:      supplierRepo,
+		PurchaseOrders: purchaseOrderRepo,
+	})
+	forecastSvc := service.NewForecastService(productRepo, orderRepo)
+	reportSvc := service.NewReportService(productRepo, orderRepo, stockHistoryRepo)
+	stockCheckSvc := service.NewStockCheckService(service.StockCheckServiceDeps{
+		Transactor:          transactor,
+		Products:            productRepo,
+		Orders:              orderRepo,
+		StockHistoryRecords: stockHistoryRepo,
+		StockHistory:        stockHistorySvc,
+		Valuation:           valuationSvc,
+	})
+	webhookSvc := service.NewWebhookService(webhookRepo, &http.Client{Timeout: 10 * time.Second})
+	eventSvc.AddListener(webhookSvc.Enqueue)
+	apiKeySvc := service.NewAPIKeyService(apiKeyRepo)
+	jwtSecret := []byte(config.JWTSecret)
+	if len(jwtSecret) == 0 {
+		jwtSecret = make([]byte, 32)
+		if _, err := rand.Read(jwtSecret); err != nil {
+			log.Fatal("Error generating JWT secret")
+		}
+		logger.Warn("JWT_SECRET is not set, user tokens will not survive a restart")
+	}
+	userSvc := service.NewUserService(userRepo, jwtSecret, config.AccessTokenTTL, config.RefreshTokenTTL)
 	logger.Info("Services initialized")
 	//SERVICES END
 
-	handler := api.NewHTTPHandler(productSvc, orderSvc)
+	handler := api.NewHTTPHandler(api.Services{
+		Products:       productSvc,
+		Orders:         orderSvc,
+		Suppliers:      supplierSvc,
+		PurchaseOrders: purchaseOrderSvc,
+		Replenishment:  replenishmentSvc,
+		Valuation:      valuationSvc,
+		StockHistory:   stockHistorySvc,
+		Forecast:       forecastSvc,
+		Reports:        reportSvc,
+		StockCheck:     stockCheckSvc,
+		Lots:           lotSvc,
+		Serials:        serialSvc,
+		ATP:            atpSvc,
+		Events:         eventSvc,
+		Webhooks:       webhookSvc,
+		APIKeys:        apiKeySvc,
+		Users:          userSvc,
+	}, config.RequireIfMatch)
 	logger.Info("Handler initialized")
 
-	mux := api.NewRouter(handler)
+	rateLimit, err := service.ParseRateLimit(config.RateLimit)
+	if err != nil {
+		log.Fatalf("Invalid RATE_LIMIT: %v", err)
+	}
+	routeRateLimits, err := service.ParseRouteRateLimits(config.RateLimitRoutes)
+	if err != nil {
+		log.Fatalf("Invalid RATE_LIMIT_ROUTES: %v", err)
+	}
+	ipRateLimit, err := service.ParseRateLimit(config.RateLimitIP)
+	if err != nil {
+		log.Fatalf("Invalid RATE_LIMIT_IP: %v", err)
+	}
+	mux := api.NewRouter(handler, api.AuthOptions{
+		Enabled:       config.AuthEnabled,
+		PublicHealth:  config.PublicHealth,
+		PublicSwagger: config.PublicSwagger,
+	}, api.RateLimitOptions{
+		Default:    rateLimit,
+		Routes:     routeRateLimits,
+		PerIP:      ipRateLimit,
+		TrustProxy: config.TrustProxy,
+	})
+	if !config.AuthEnabled {
+		logger.Warn("API key authentication is disabled")
+	}
 	logger.Info("Router initialized")
 
 	server := &http.Server{
 		Addr:    ":" + config.Port,
 		Handler: mux,
 	}
+	// event streams never finish on their own, so end them on shutdown
+	server.RegisterOnShutdown(eventSvc.Close)
+
+	//BACKGROUND JOBS
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	if config.SnapshotInterval > 0 {
+		go stockHistorySvc.RunSnapshots(config.SnapshotInterval, jobsCtx)
+	}
+	if config.WebhookInterval > 0 {
+		go webhookSvc.RunDeliveries(config.WebhookInterval, jobsCtx)
+	}
 
 	//SERVER
 	go func() {
@@ -73,6 +230,7 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
+	stopJobs()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := server.Shutdown(ctx); err != nil {
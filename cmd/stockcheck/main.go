@@ -0,0 +1,88 @@
+// Command stockcheck compares every product's stock with the stock explained
+// by its recorded history and the orders table. It only reports by default;
+// with -apply it books each difference as a reconciliation movement.
+// It exits with status 1 when differences were found and not applied.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/adapters/postgres"
+	"github.com/iamtbay/is-management/internal/config"
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/internal/service"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	apply := flag.Bool("apply", false, "book differences as reconciliation movements instead of only reporting them")
+	tenant := flag.String("tenant", domain.DefaultTenant, "tenant whose products are checked")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Error loading .env file")
+	}
+	config, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	conn, err := postgres.NewDB(config.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+	defer conn.Close()
+
+	productRepo := postgres.NewProductRepository(conn)
+	orderRepo := postgres.NewOrderRepository(conn)
+	stockHistoryRepo := postgres.NewStockHistoryRepository(conn)
+	stockHistorySvc := service.NewStockHistoryService(stockHistoryRepo)
+	costingMethod := domain.CostingMethod(config.CostingMethod)
+	if !costingMethod.Valid() {
+		log.Fatalf("Invalid COSTING_METHOD %q, expected fifo or average", config.CostingMethod)
+	}
+	valuationSvc := service.NewValuationService(postgres.NewCostLayerRepository(conn), costingMethod)
+	stockCheckSvc := service.NewStockCheckService(service.StockCheckServiceDeps{
+		Transactor:          postgres.NewTransactor(conn),
+		Products:            productRepo,
+		Orders:              orderRepo,
+		StockHistoryRecords: stockHistoryRepo,
+		StockHistory:        stockHistorySvc,
+		Valuation:           valuationSvc,
+	})
+
+	ctx, cancel := context.WithTimeout(domain.WithTenant(context.Background(), *tenant), time.Minute)
+	defer cancel()
+	report, err := stockCheckSvc.Check(*apply, ctx)
+	if err != nil {
+		log.Fatalf("Error checking stock: %v", err)
+	}
+
+	fmt.Printf("Checked %d products, %d discrepancies\n", report.Products, len(report.Discrepancies))
+	if len(report.Discrepancies) == 0 {
+		return 0
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PRODUCT ID\tNAME\tEXPECTED\tACTUAL\tDIFFERENCE")
+	for _, d := range report.Discrepancies {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%+d\n", d.ProductID, d.ProductName, d.Expected, d.Actual, d.Difference)
+	}
+	tw.Flush()
+
+	if !report.Applied {
+		fmt.Println("Dry run, nothing written. Run with -apply to book the differences.")
+		return 1
+	}
+	fmt.Println("Differences booked as reconciliation movements.")
+	return 0
+}
@@ -0,0 +1,84 @@
+package api
+
+import "net/http"
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Creates an API key with the given scopes. Only a hash is stored, so the key is returned once, here; send it as X-API-Key or an Authorization bearer token.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param apiKey body CreateAPIKeyRequest true "API key"
+// @Success 201 {object} APIKeyResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api-keys [post]
+func (h *HTTPHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req CreateAPIKeyRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := validateAPIKey(&req); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	ctx := r.Context()
+	apiKey := req.toDomain()
+	key, err := h.apiKeyService.CreateKey(apiKey, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response := newAPIKeyResponse(apiKey)
+	response.Key = key
+	h.writeJSON(w, http.StatusCreated, response)
+}
+
+// FindAllAPIKeys godoc
+// @Summary Find all API keys
+// @Description Lists the API keys, revoked ones included, with their last use
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Success 200 {object} []APIKeyResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api-keys [get]
+func (h *HTTPHandler) FindAllAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	apiKeys, err := h.apiKeyService.FindAll(ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newAPIKeyResponses(apiKeys))
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revokes an API key; requests with it are rejected from now on
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api-keys/{id} [delete]
+func (h *HTTPHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.apiKeyService.Revoke(id, ctx); err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
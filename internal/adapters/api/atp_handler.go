@@ -0,0 +1,118 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+// CreateExpectedReceipt godoc
+// @Summary Register an expected receipt
+// @Description Announces inbound stock of a product for a date, optionally for a purchase order whose receipts then close it
+// @Tags atp
+// @Accept json
+// @Produce json
+// @Param expected_receipt body CreateExpectedReceiptRequest true "Expected receipt"
+// @Success 201 {object} ExpectedReceiptResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /expected-receipts [post]
+func (h *HTTPHandler) CreateExpectedReceipt(w http.ResponseWriter, r *http.Request) {
+	var req CreateExpectedReceiptRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := validateExpectedReceipt(&req); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	ctx := r.Context()
+	expectedReceipt := req.toDomain()
+	if err := h.atpService.CreateExpectedReceipt(expectedReceipt, ctx); err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, newExpectedReceiptResponse(expectedReceipt))
+}
+
+// FindExpectedReceipts godoc
+// @Summary Find open expected receipts
+// @Description Lists expected receipts that have not fully arrived yet, earliest first
+// @Tags atp
+// @Accept json
+// @Produce json
+// @Param product_id query string false "Only this product"
+// @Success 200 {object} []ExpectedReceiptResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /expected-receipts [get]
+func (h *HTTPHandler) FindExpectedReceipts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	expectedReceipts, err := h.atpService.FindExpectedReceipts(r.URL.Query().Get("product_id"), ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newExpectedReceiptResponses(expectedReceipts))
+}
+
+// CloseExpectedReceipt godoc
+// @Summary Close an expected receipt
+// @Description Stops waiting for the rest of an expected receipt, e.g. one without a purchase order or whose goods will not come, so it no longer counts towards available to promise
+// @Tags atp
+// @Accept json
+// @Produce json
+// @Param id path string true "Expected receipt ID"
+// @Success 200 {object} ExpectedReceiptResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /expected-receipts/{id}/close [post]
+func (h *HTTPHandler) CloseExpectedReceipt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	expectedReceipt, err := h.atpService.CloseExpectedReceipt(id, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newExpectedReceiptResponse(expectedReceipt))
+}
+
+// AvailableToPromise godoc
+// @Summary Find when a quantity can be promised
+// @Description Combines the available stock with the open expected receipts that are not overdue and returns the earliest date the quantity can be fulfilled
+// @Tags atp
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param quantity query int true "Quantity to promise"
+// @Success 200 {object} AvailableToPromiseResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{id}/atp [get]
+func (h *HTTPHandler) AvailableToPromise(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	quantity, err := h.readIntQuery(r, "quantity", 0)
+	if err != nil || quantity < 1 {
+		h.writeError(w, http.StatusBadRequest, "quantity must be a number greater than 0")
+		return
+	}
+	atp, err := h.atpService.AvailableToPromise(id, quantity, time.Now().UTC(), ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newAvailableToPromiseResponse(atp))
+}
@@ -0,0 +1,1036 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+// Request and response bodies of the HTTP API. Handlers decode requests into
+// these types and map them to the domain, so clients cannot set server-owned
+// fields such as IDs or prices and domain changes do not leak into the API.
+
+// REQUESTS
+
+type CreateProductRequest struct {
+	Name            string                 `json:"name"`
+	Price           float64                `json:"price"`
+	Stock           int                    `json:"stock"`
+	UnitCost        float64                `json:"unit_cost"`
+	ReorderPoint    int                    `json:"reorder_point"`
+	ReorderQuantity int                    `json:"reorder_quantity"`
+	LotTracked      bool                   `json:"lot_tracked"`
+	Serialized      bool                   `json:"serialized"`
+	InventoryPolicy domain.InventoryPolicy `json:"inventory_policy,omitempty" enums:"tracked,untracked,allow_negative"`
+}
+
+func (req *CreateProductRequest) toDomain() *domain.Product {
+	return &domain.Product{
+		Name:            req.Name,
+		Price:           req.Price,
+		Stock:           req.Stock,
+		ReorderPoint:    req.ReorderPoint,
+		ReorderQuantity: req.ReorderQuantity,
+		LotTracked:      req.LotTracked,
+		Serialized:      req.Serialized,
+		InventoryPolicy: req.InventoryPolicy,
+	}
+}
+
+type StockMoveRequest struct {
+	From     domain.StockBucket `json:"from" enums:"available,reserved,damaged,quarantined"`
+	To       domain.StockBucket `json:"to" enums:"available,reserved,damaged,quarantined"`
+	Quantity int                `json:"quantity"`
+}
+
+func (req *StockMoveRequest) toDomain() domain.StockMove {
+	return domain.StockMove{From: req.From, To: req.To, Quantity: req.Quantity}
+}
+
+// CreateOrderRequest takes either a quantity in base units or a unit with a
+// unit quantity.
+type CreateOrderRequest struct {
+	ProductID    string               `json:"product_id"`
+	Quantity     int                  `json:"quantity,omitempty"`
+	Unit         domain.UnitOfMeasure `json:"unit,omitempty" enums:"each,pack,case,pallet"`
+	UnitQuantity float64              `json:"unit_quantity,omitempty"`
+	Customer     string               `json:"customer,omitempty"`
+	Serials      []string             `json:"serials,omitempty"`
+}
+
+func (req *CreateOrderRequest) toDomain() *domain.Order {
+	return &domain.Order{
+		ProductID:    req.ProductID,
+		Quantity:     req.Quantity,
+		Unit:         req.Unit,
+		UnitQuantity: req.UnitQuantity,
+		Customer:     req.Customer,
+		Serials:      req.Serials,
+	}
+}
+
+type CreateSupplierRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+func (req *CreateSupplierRequest) toDomain() *domain.Supplier {
+	return &domain.Supplier{Name: req.Name, Email: req.Email, Phone: req.Phone}
+}
+
+type LinkSupplierProductRequest struct {
+	ProductID    string  `json:"product_id"`
+	UnitCost     float64 `json:"unit_cost"`
+	LeadTimeDays int     `json:"lead_time_days"`
+}
+
+func (req *LinkSupplierProductRequest) toDomain(supplierID string) *domain.SupplierProduct {
+	return &domain.SupplierProduct{
+		SupplierID:   supplierID,
+		ProductID:    req.ProductID,
+		UnitCost:     req.UnitCost,
+		LeadTimeDays: req.LeadTimeDays,
+	}
+}
+
+type CreatePurchaseOrderRequest struct {
+	SupplierID string                     `json:"supplier_id"`
+	Lines      []PurchaseOrderLineRequest `json:"lines"`
+}
+
+// PurchaseOrderLineRequest defaults UnitCost to the supplier's cost when it is 0.
+type PurchaseOrderLineRequest struct {
+	ProductID string  `json:"product_id"`
+	Quantity  int     `json:"quantity"`
+	UnitCost  float64 `json:"unit_cost,omitempty"`
+}
+
+func (req *CreatePurchaseOrderRequest) toDomain() *domain.PurchaseOrder {
+	purchaseOrder := &domain.PurchaseOrder{SupplierID: req.SupplierID}
+	for _, line := range req.Lines {
+		purchaseOrder.Lines = append(purchaseOrder.Lines, domain.PurchaseOrderLine{
+			ProductID: line.ProductID,
+			Quantity:  line.Quantity,
+			UnitCost:  line.UnitCost,
+		})
+	}
+	return purchaseOrder
+}
+
+type ReceiptRequest struct {
+	Lines []ReceiptLineRequest `json:"lines"`
+}
+
+// ReceiptLineRequest names the purchase order line by line_id or product_id.
+type ReceiptLineRequest struct {
+	LineID         string               `json:"line_id,omitempty"`
+	ProductID      string               `json:"product_id,omitempty"`
+	Quantity       int                  `json:"quantity,omitempty"`
+	Unit           domain.UnitOfMeasure `json:"unit,omitempty" enums:"each,pack,case,pallet"`
+	UnitQuantity   float64              `json:"unit_quantity,omitempty"`
+	LotNumber      string               `json:"lot_number,omitempty"`
+	ManufacturedAt *time.Time           `json:"manufactured_at,omitempty"`
+	ExpiresAt      *time.Time           `json:"expires_at,omitempty"`
+	Serials        []string             `json:"serials,omitempty"`
+}
+
+func (req *ReceiptRequest) toDomain() *domain.Receipt {
+	receipt := &domain.Receipt{}
+	for _, line := range req.Lines {
+		receipt.Lines = append(receipt.Lines, domain.ReceiptLine{
+			PurchaseOrderLineID: line.LineID,
+			ProductID:           line.ProductID,
+			Quantity:            line.Quantity,
+			Unit:                line.Unit,
+			UnitQuantity:        line.UnitQuantity,
+			LotNumber:           line.LotNumber,
+			ManufacturedAt:      line.ManufacturedAt,
+			ExpiresAt:           line.ExpiresAt,
+			Serials:             line.Serials,
+		})
+	}
+	return receipt
+}
+
+type CreateExpectedReceiptRequest struct {
+	ProductID       string    `json:"product_id"`
+	PurchaseOrderID string    `json:"purchase_order_id,omitempty"`
+	Quantity        int       `json:"quantity"`
+	ExpectedAt      time.Time `json:"expected_at"`
+}
+
+func (req *CreateExpectedReceiptRequest) toDomain() *domain.ExpectedReceipt {
+	return &domain.ExpectedReceipt{
+		ProductID:       req.ProductID,
+		PurchaseOrderID: req.PurchaseOrderID,
+		Quantity:        req.Quantity,
+		ExpectedAt:      req.ExpectedAt,
+	}
+}
+
+// RESPONSES
+
+type ProductResponse struct {
+	ID              string                 `json:"id"`
+	Name            string                 `json:"name"`
+	Price           float64                `json:"price"`
+	Stock           int                    `json:"stock"`
+	Reserved        int                    `json:"reserved"`
+	Damaged         int                    `json:"damaged"`
+	Quarantined     int                    `json:"quarantined"`
+	ReorderPoint    int                    `json:"reorder_point"`
+	ReorderQuantity int                    `json:"reorder_quantity"`
+	LotTracked      bool                   `json:"lot_tracked"`
+	Serialized      bool                   `json:"serialized"`
+	InventoryPolicy domain.InventoryPolicy `json:"inventory_policy" enums:"tracked,untracked,allow_negative"`
+	// Version is the product's ETag without quotes.
+	Version int `json:"version"`
+}
+
+func newProductResponse(product *domain.Product) *ProductResponse {
+	return &ProductResponse{
+		ID:              product.ID,
+		Name:            product.Name,
+		Price:           product.Price,
+		Stock:           product.Stock,
+		Reserved:        product.Reserved,
+		Damaged:         product.Damaged,
+		Quarantined:     product.Quarantined,
+		ReorderPoint:    product.ReorderPoint,
+		ReorderQuantity: product.ReorderQuantity,
+		LotTracked:      product.LotTracked,
+		Serialized:      product.Serialized,
+		InventoryPolicy: product.InventoryPolicy,
+		Version:         product.Version,
+	}
+}
+
+func newProductResponses(products []domain.Product) []ProductResponse {
+	responses := make([]ProductResponse, 0, len(products))
+	for i := range products {
+		responses = append(responses, *newProductResponse(&products[i]))
+	}
+	return responses
+}
+
+type OrderResponse struct {
+	ID           string                  `json:"id"`
+	ProductID    string                  `json:"product_id"`
+	Quantity     int                     `json:"quantity"`
+	Unit         domain.UnitOfMeasure    `json:"unit,omitempty"`
+	UnitQuantity float64                 `json:"unit_quantity,omitempty"`
+	TotalPrice   float64                 `json:"total_price"`
+	Customer     string                  `json:"customer"`
+	CreatedAt    time.Time               `json:"created_at"`
+	Allocations  []LotAllocationResponse `json:"allocations,omitempty"`
+	Serials      []string                `json:"serials,omitempty"`
+}
+
+type LotAllocationResponse struct {
+	LotID     string     `json:"lot_id"`
+	LotNumber string     `json:"lot_number"`
+	Quantity  int        `json:"quantity"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func newOrderResponse(order *domain.Order) *OrderResponse {
+	response := &OrderResponse{
+		ID:           order.ID,
+		ProductID:    order.ProductID,
+		Quantity:     order.Quantity,
+		Unit:         order.Unit,
+		UnitQuantity: order.UnitQuantity,
+		TotalPrice:   order.TotalPrice,
+		Customer:     order.Customer,
+		CreatedAt:    order.CreatedAt,
+		Serials:      order.Serials,
+	}
+	for _, allocation := range order.Allocations {
+		response.Allocations = append(response.Allocations, LotAllocationResponse{
+			LotID:     allocation.LotID,
+			LotNumber: allocation.LotNumber,
+			Quantity:  allocation.Quantity,
+			ExpiresAt: allocation.ExpiresAt,
+		})
+	}
+	return response
+}
+
+func newOrderResponses(orders []domain.Order) []OrderResponse {
+	responses := make([]OrderResponse, 0, len(orders))
+	for i := range orders {
+		responses = append(responses, *newOrderResponse(&orders[i]))
+	}
+	return responses
+}
+
+type SupplierResponse struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+func newSupplierResponse(supplier *domain.Supplier) *SupplierResponse {
+	return &SupplierResponse{ID: supplier.ID, Name: supplier.Name, Email: supplier.Email, Phone: supplier.Phone}
+}
+
+func newSupplierResponses(suppliers []domain.Supplier) []SupplierResponse {
+	responses := make([]SupplierResponse, 0, len(suppliers))
+	for i := range suppliers {
+		responses = append(responses, *newSupplierResponse(&suppliers[i]))
+	}
+	return responses
+}
+
+type SupplierProductResponse struct {
+	SupplierID   string  `json:"supplier_id"`
+	ProductID    string  `json:"product_id"`
+	UnitCost     float64 `json:"unit_cost"`
+	LeadTimeDays int     `json:"lead_time_days"`
+}
+
+func newSupplierProductResponse(link *domain.SupplierProduct) *SupplierProductResponse {
+	return &SupplierProductResponse{
+		SupplierID:   link.SupplierID,
+		ProductID:    link.ProductID,
+		UnitCost:     link.UnitCost,
+		LeadTimeDays: link.LeadTimeDays,
+	}
+}
+
+func newSupplierProductResponses(links []domain.SupplierProduct) []SupplierProductResponse {
+	responses := make([]SupplierProductResponse, 0, len(links))
+	for i := range links {
+		responses = append(responses, *newSupplierProductResponse(&links[i]))
+	}
+	return responses
+}
+
+type PurchaseOrderResponse struct {
+	ID         string                      `json:"id"`
+	SupplierID string                      `json:"supplier_id"`
+	Status     domain.PurchaseOrderStatus  `json:"status" enums:"draft,sent,partially_received,closed"`
+	Lines      []PurchaseOrderLineResponse `json:"lines"`
+	CreatedAt  time.Time                   `json:"created_at"`
+}
+
+type PurchaseOrderLineResponse struct {
+	ID               string  `json:"id"`
+	ProductID        string  `json:"product_id"`
+	Quantity         int     `json:"quantity"`
+	ReceivedQuantity int     `json:"received_quantity"`
+	UnitCost         float64 `json:"unit_cost"`
+}
+
+func newPurchaseOrderResponse(purchaseOrder *domain.PurchaseOrder) *PurchaseOrderResponse {
+	response := &PurchaseOrderResponse{
+		ID:         purchaseOrder.ID,
+		SupplierID: purchaseOrder.SupplierID,
+		Status:     purchaseOrder.Status,
+		Lines:      make([]PurchaseOrderLineResponse, 0, len(purchaseOrder.Lines)),
+		CreatedAt:  purchaseOrder.CreatedAt,
+	}
+	for _, line := range purchaseOrder.Lines {
+		response.Lines = append(response.Lines, PurchaseOrderLineResponse{
+			ID:               line.ID,
+			ProductID:        line.ProductID,
+			Quantity:         line.Quantity,
+			ReceivedQuantity: line.ReceivedQuantity,
+			UnitCost:         line.UnitCost,
+		})
+	}
+	return response
+}
+
+func newPurchaseOrderResponses(purchaseOrders []domain.PurchaseOrder) []PurchaseOrderResponse {
+	responses := make([]PurchaseOrderResponse, 0, len(purchaseOrders))
+	for i := range purchaseOrders {
+		responses = append(responses, *newPurchaseOrderResponse(&purchaseOrders[i]))
+	}
+	return responses
+}
+
+type ExpectedReceiptResponse struct {
+	ID              string     `json:"id"`
+	ProductID       string     `json:"product_id"`
+	PurchaseOrderID string     `json:"purchase_order_id,omitempty"`
+	Quantity        int        `json:"quantity"`
+	Received        int        `json:"received"`
+	ExpectedAt      time.Time  `json:"expected_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ClosedAt        *time.Time `json:"closed_at,omitempty"`
+}
+
+func newExpectedReceiptResponse(expectedReceipt *domain.ExpectedReceipt) *ExpectedReceiptResponse {
+	return &ExpectedReceiptResponse{
+		ID:              expectedReceipt.ID,
+		ProductID:       expectedReceipt.ProductID,
+		PurchaseOrderID: expectedReceipt.PurchaseOrderID,
+		Quantity:        expectedReceipt.Quantity,
+		Received:        expectedReceipt.Received,
+		ExpectedAt:      expectedReceipt.ExpectedAt,
+		CreatedAt:       expectedReceipt.CreatedAt,
+		ClosedAt:        expectedReceipt.ClosedAt,
+	}
+}
+
+func newExpectedReceiptResponses(expectedReceipts []domain.ExpectedReceipt) []ExpectedReceiptResponse {
+	responses := make([]ExpectedReceiptResponse, 0, len(expectedReceipts))
+	for i := range expectedReceipts {
+		responses = append(responses, *newExpectedReceiptResponse(&expectedReceipts[i]))
+	}
+	return responses
+}
+
+type ProductUnitResponse struct {
+	ProductID string               `json:"product_id"`
+	Unit      domain.UnitOfMeasure `json:"unit" enums:"each,pack,case,pallet"`
+	Factor    int                  `json:"factor"`
+}
+
+func newProductUnitResponse(unit *domain.ProductUnit) *ProductUnitResponse {
+	return &ProductUnitResponse{ProductID: unit.ProductID, Unit: unit.Unit, Factor: unit.Factor}
+}
+
+func newProductUnitResponses(units []domain.ProductUnit) []ProductUnitResponse {
+	responses := make([]ProductUnitResponse, 0, len(units))
+	for i := range units {
+		responses = append(responses, *newProductUnitResponse(&units[i]))
+	}
+	return responses
+}
+
+type LotResponse struct {
+	ID             string     `json:"id"`
+	ProductID      string     `json:"product_id"`
+	LotNumber      string     `json:"lot_number"`
+	ManufacturedAt *time.Time `json:"manufactured_at"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	Quantity       int        `json:"quantity"`
+	Remaining      int        `json:"remaining"`
+	ReceivedAt     time.Time  `json:"received_at"`
+	Quarantined    bool       `json:"quarantined"`
+}
+
+func newLotResponse(lot *domain.Lot) *LotResponse {
+	return &LotResponse{
+		ID:             lot.ID,
+		ProductID:      lot.ProductID,
+		LotNumber:      lot.LotNumber,
+		ManufacturedAt: lot.ManufacturedAt,
+		ExpiresAt:      lot.ExpiresAt,
+		Quantity:       lot.Quantity,
+		Remaining:      lot.Remaining,
+		ReceivedAt:     lot.ReceivedAt,
+		Quarantined:    lot.Quarantined,
+	}
+}
+
+func newLotResponses(lots []domain.Lot) []LotResponse {
+	responses := make([]LotResponse, 0, len(lots))
+	for i := range lots {
+		responses = append(responses, *newLotResponse(&lots[i]))
+	}
+	return responses
+}
+
+type LotTraceResponse struct {
+	LotNumber string                  `json:"lot_number"`
+	Lots      []LotResponse           `json:"lots"`
+	Orders    []LotTraceOrderResponse `json:"orders"`
+	Shipped   int                     `json:"shipped"`
+	OnHand    int                     `json:"on_hand"`
+}
+
+type LotTraceOrderResponse struct {
+	OrderID   string    `json:"order_id"`
+	ProductID string    `json:"product_id"`
+	LotID     string    `json:"lot_id"`
+	Customer  string    `json:"customer"`
+	Quantity  int       `json:"quantity"`
+	OrderedAt time.Time `json:"ordered_at"`
+}
+
+func newLotTraceResponse(trace *domain.LotTrace) *LotTraceResponse {
+	response := &LotTraceResponse{
+		LotNumber: trace.LotNumber,
+		Lots:      newLotResponses(trace.Lots),
+		Orders:    make([]LotTraceOrderResponse, 0, len(trace.Orders)),
+		Shipped:   trace.Shipped,
+		OnHand:    trace.OnHand,
+	}
+	for _, order := range trace.Orders {
+		response.Orders = append(response.Orders, LotTraceOrderResponse{
+			OrderID:   order.OrderID,
+			ProductID: order.ProductID,
+			LotID:     order.LotID,
+			Customer:  order.Customer,
+			Quantity:  order.Quantity,
+			OrderedAt: order.OrderedAt,
+		})
+	}
+	return response
+}
+
+type SerialResponse struct {
+	SerialNumber string              `json:"serial_number"`
+	ProductID    string              `json:"product_id"`
+	Status       domain.SerialStatus `json:"status" enums:"in_stock,sold"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
+
+func newSerialResponse(serial *domain.Serial) *SerialResponse {
+	return &SerialResponse{SerialNumber: serial.SerialNumber, ProductID: serial.ProductID, Status: serial.Status, UpdatedAt: serial.UpdatedAt}
+}
+
+type SerialHistoryResponse struct {
+	SerialResponse
+	Events []SerialEventResponse `json:"events"`
+}
+
+type SerialEventResponse struct {
+	ID           string                 `json:"id"`
+	SerialNumber string                 `json:"serial_number"`
+	ProductID    string                 `json:"product_id"`
+	Event        domain.SerialEventType `json:"event" enums:"received,sold,returned"`
+	ReferenceID  string                 `json:"reference_id"`
+	CreatedAt    time.Time              `json:"created_at"`
+}
+
+func newSerialHistoryResponse(history *domain.SerialHistory) *SerialHistoryResponse {
+	response := &SerialHistoryResponse{
+		SerialResponse: *newSerialResponse(&history.Serial),
+		Events:         make([]SerialEventResponse, 0, len(history.Events)),
+	}
+	for _, event := range history.Events {
+		response.Events = append(response.Events, SerialEventResponse{
+			ID:           event.ID,
+			SerialNumber: event.SerialNumber,
+			ProductID:    event.ProductID,
+			Event:        event.Event,
+			ReferenceID:  event.ReferenceID,
+			CreatedAt:    event.CreatedAt,
+		})
+	}
+	return response
+}
+
+type AvailableToPromiseResponse struct {
+	ProductID  string `json:"product_id"`
+	Quantity   int    `json:"quantity"`
+	OnHand     int    `json:"on_hand"`
+	Reserved   int    `json:"reserved"`
+	Overdue    int    `json:"overdue"`
+	Promisable bool   `json:"promisable"`
+	// PromiseDate is null when stock and expected receipts cannot cover the quantity.
+	PromiseDate *time.Time         `json:"promise_date"`
+	Shortfall   int                `json:"shortfall"`
+	Schedule    []ATPPointResponse `json:"schedule"`
+}
+
+type ATPPointResponse struct {
+	Date      time.Time `json:"date"`
+	Available int       `json:"available"`
+}
+
+func newAvailableToPromiseResponse(atp *domain.AvailableToPromise) *AvailableToPromiseResponse {
+	response := &AvailableToPromiseResponse{
+		ProductID:   atp.ProductID,
+		Quantity:    atp.Quantity,
+		OnHand:      atp.OnHand,
+		Reserved:    atp.Reserved,
+		Overdue:     atp.Overdue,
+		Promisable:  atp.Promisable,
+		PromiseDate: atp.PromiseDate,
+		Shortfall:   atp.Shortfall,
+		Schedule:    make([]ATPPointResponse, 0, len(atp.Schedule)),
+	}
+	for _, point := range atp.Schedule {
+		response.Schedule = append(response.Schedule, ATPPointResponse{Date: point.Date, Available: point.Available})
+	}
+	return response
+}
+
+type StockLevelResponse struct {
+	ProductID string    `json:"product_id"`
+	Stock     int       `json:"stock"`
+	AsOf      time.Time `json:"as_of"`
+}
+
+func newStockLevelResponse(level *domain.StockLevel) *StockLevelResponse {
+	return &StockLevelResponse{ProductID: level.ProductID, Stock: level.Stock, AsOf: level.AsOf}
+}
+
+func newStockLevelResponses(levels []domain.StockLevel) []StockLevelResponse {
+	responses := make([]StockLevelResponse, 0, len(levels))
+	for i := range levels {
+		responses = append(responses, *newStockLevelResponse(&levels[i]))
+	}
+	return responses
+}
+
+type StockCheckResponse struct {
+	CheckedAt     time.Time                  `json:"checked_at"`
+	Products      int                        `json:"products"`
+	Applied       bool                       `json:"applied"`
+	Discrepancies []StockDiscrepancyResponse `json:"discrepancies"`
+}
+
+type StockDiscrepancyResponse struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Expected    int    `json:"expected"`
+	Actual      int    `json:"actual"`
+	Difference  int    `json:"difference"`
+}
+
+func newStockCheckResponse(report *domain.StockCheckReport) *StockCheckResponse {
+	response := &StockCheckResponse{
+		CheckedAt:     report.CheckedAt,
+		Products:      report.Products,
+		Applied:       report.Applied,
+		Discrepancies: make([]StockDiscrepancyResponse, 0, len(report.Discrepancies)),
+	}
+	for _, d := range report.Discrepancies {
+		response.Discrepancies = append(response.Discrepancies, StockDiscrepancyResponse{
+			ProductID:   d.ProductID,
+			ProductName: d.ProductName,
+			Expected:    d.Expected,
+			Actual:      d.Actual,
+			Difference:  d.Difference,
+		})
+	}
+	return response
+}
+
+type ForecastResponse struct {
+	ProductID          string                  `json:"product_id"`
+	Method             domain.ForecastMethod   `json:"method" enums:"moving_average,exponential_smoothing"`
+	WeeklySeasonality  bool                    `json:"weekly_seasonality"`
+	Stock              int                     `json:"stock"`
+	AverageDailyDemand float64                 `json:"average_daily_demand"`
+	TotalDemand        float64                 `json:"total_demand"`
+	StockoutDate       string                  `json:"stockout_date,omitempty"`
+	Points             []ForecastPointResponse `json:"points"`
+}
+
+type ForecastPointResponse struct {
+	Date     string  `json:"date"`
+	Quantity float64 `json:"quantity"`
+}
+
+func newForecastResponse(forecast *domain.Forecast) *ForecastResponse {
+	response := &ForecastResponse{
+		ProductID:          forecast.ProductID,
+		Method:             forecast.Method,
+		WeeklySeasonality:  forecast.WeeklySeasonality,
+		Stock:              forecast.Stock,
+		AverageDailyDemand: forecast.AverageDailyDemand,
+		TotalDemand:        forecast.TotalDemand,
+		StockoutDate:       forecast.StockoutDate,
+		Points:             make([]ForecastPointResponse, 0, len(forecast.Points)),
+	}
+	for _, point := range forecast.Points {
+		response.Points = append(response.Points, ForecastPointResponse{Date: point.Date, Quantity: point.Quantity})
+	}
+	return response
+}
+
+type ReplenishmentSuggestionResponse struct {
+	ProductID         string  `json:"product_id"`
+	ProductName       string  `json:"product_name"`
+	SupplierID        string  `json:"supplier_id"`
+	Stock             int     `json:"stock"`
+	Inbound           int     `json:"inbound"`
+	ReorderPoint      int     `json:"reorder_point"`
+	DailySales        float64 `json:"daily_sales"`
+	LeadTimeDays      int     `json:"lead_time_days"`
+	UnitCost          float64 `json:"unit_cost"`
+	SuggestedQuantity int     `json:"suggested_quantity"`
+}
+
+func newReplenishmentSuggestionResponses(suggestions []domain.ReplenishmentSuggestion) []ReplenishmentSuggestionResponse {
+	responses := make([]ReplenishmentSuggestionResponse, 0, len(suggestions))
+	for _, s := range suggestions {
+		responses = append(responses, ReplenishmentSuggestionResponse{
+			ProductID:         s.ProductID,
+			ProductName:       s.ProductName,
+			SupplierID:        s.SupplierID,
+			Stock:             s.Stock,
+			Inbound:           s.Inbound,
+			ReorderPoint:      s.ReorderPoint,
+			DailySales:        s.DailySales,
+			LeadTimeDays:      s.LeadTimeDays,
+			UnitCost:          s.UnitCost,
+			SuggestedQuantity: s.SuggestedQuantity,
+		})
+	}
+	return responses
+}
+
+type ValuationResponse struct {
+	Method     domain.CostingMethod       `json:"method" enums:"fifo,average"`
+	AsOf       time.Time                  `json:"as_of"`
+	Products   []ProductValuationResponse `json:"products"`
+	TotalValue float64                    `json:"total_value"`
+}
+
+type ProductValuationResponse struct {
+	ProductID   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Quantity    int     `json:"quantity"`
+	UnitCost    float64 `json:"unit_cost"`
+	TotalValue  float64 `json:"total_value"`
+}
+
+func newValuationResponse(report *domain.ValuationReport) *ValuationResponse {
+	response := &ValuationResponse{
+		Method:     report.Method,
+		AsOf:       report.AsOf,
+		Products:   make([]ProductValuationResponse, 0, len(report.Products)),
+		TotalValue: report.TotalValue,
+	}
+	for _, p := range report.Products {
+		response.Products = append(response.Products, ProductValuationResponse{
+			ProductID:   p.ProductID,
+			ProductName: p.ProductName,
+			Quantity:    p.Quantity,
+			UnitCost:    p.UnitCost,
+			TotalValue:  p.TotalValue,
+		})
+	}
+	return response
+}
+
+type ClassificationResponse struct {
+	From         time.Time                       `json:"from"`
+	To           time.Time                       `json:"to"`
+	Period       domain.DemandPeriod             `json:"period" enums:"day,week"`
+	TotalRevenue float64                         `json:"total_revenue"`
+	Products     []ProductClassificationResponse `json:"products"`
+}
+
+type ProductClassificationResponse struct {
+	ProductID              string  `json:"product_id"`
+	ProductName            string  `json:"product_name"`
+	Revenue                float64 `json:"revenue"`
+	RevenueShare           float64 `json:"revenue_share"`
+	CumulativeShare        float64 `json:"cumulative_share"`
+	ABC                    string  `json:"abc"`
+	DemandMean             float64 `json:"demand_mean"`
+	DemandStdDev           float64 `json:"demand_std_dev"`
+	CoefficientOfVariation float64 `json:"coefficient_of_variation"`
+	XYZ                    string  `json:"xyz"`
+	Class                  string  `json:"class"`
+}
+
+func newClassificationResponse(report *domain.ClassificationReport) *ClassificationResponse {
+	response := &ClassificationResponse{
+		From:         report.From,
+		To:           report.To,
+		Period:       report.Period,
+		TotalRevenue: report.TotalRevenue,
+		Products:     make([]ProductClassificationResponse, 0, len(report.Products)),
+	}
+	for _, p := range report.Products {
+		response.Products = append(response.Products, ProductClassificationResponse{
+			ProductID:              p.ProductID,
+			ProductName:            p.ProductName,
+			Revenue:                p.Revenue,
+			RevenueShare:           p.RevenueShare,
+			CumulativeShare:        p.CumulativeShare,
+			ABC:                    p.ABC,
+			DemandMean:             p.DemandMean,
+			DemandStdDev:           p.DemandStdDev,
+			CoefficientOfVariation: p.CoefficientOfVariation,
+			XYZ:                    p.XYZ,
+			Class:                  p.Class,
+		})
+	}
+	return response
+}
+
+type InventoryKPIResponse struct {
+	From          time.Time               `json:"from"`
+	To            time.Time               `json:"to"`
+	DeadStockDays int                     `json:"dead_stock_days"`
+	Overall       KPIResponse             `json:"overall"`
+	Products      []ProductKPIResponse    `json:"products"`
+	DeadStock     []DeadStockItemResponse `json:"dead_stock"`
+}
+
+type KPIResponse struct {
+	UnitsSold     int     `json:"units_sold"`
+	Revenue       float64 `json:"revenue"`
+	OpeningStock  int     `json:"opening_stock"`
+	ClosingStock  int     `json:"closing_stock"`
+	UnitsReceived int     `json:"units_received"`
+	AverageStock  float64 `json:"average_stock"`
+	Turnover      float64 `json:"turnover"`
+	// DaysOfSupply is null when nothing was sold.
+	DaysOfSupply    *float64 `json:"days_of_supply"`
+	SellThroughRate float64  `json:"sell_through_rate"`
+}
+
+type ProductKPIResponse struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	KPIResponse
+}
+
+type DeadStockItemResponse struct {
+	ProductID   string     `json:"product_id"`
+	ProductName string     `json:"product_name"`
+	Stock       int        `json:"stock"`
+	LastSoldAt  *time.Time `json:"last_sold_at"`
+}
+
+func newKPIResponse(kpi domain.InventoryKPI) KPIResponse {
+	return KPIResponse{
+		UnitsSold:       kpi.UnitsSold,
+		Revenue:         kpi.Revenue,
+		OpeningStock:    kpi.OpeningStock,
+		ClosingStock:    kpi.ClosingStock,
+		UnitsReceived:   kpi.UnitsReceived,
+		AverageStock:    kpi.AverageStock,
+		Turnover:        kpi.Turnover,
+		DaysOfSupply:    kpi.DaysOfSupply,
+		SellThroughRate: kpi.SellThroughRate,
+	}
+}
+
+func newInventoryKPIResponse(report *domain.InventoryKPIReport) *InventoryKPIResponse {
+	response := &InventoryKPIResponse{
+		From:          report.From,
+		To:            report.To,
+		DeadStockDays: report.DeadStockDays,
+		Overall:       newKPIResponse(report.Overall),
+		Products:      make([]ProductKPIResponse, 0, len(report.Products)),
+		DeadStock:     make([]DeadStockItemResponse, 0, len(report.DeadStock)),
+	}
+	for _, p := range report.Products {
+		response.Products = append(response.Products, ProductKPIResponse{
+			ProductID:   p.ProductID,
+			ProductName: p.ProductName,
+			KPIResponse: newKPIResponse(p.InventoryKPI),
+		})
+	}
+	for _, d := range report.DeadStock {
+		response.DeadStock = append(response.DeadStock, DeadStockItemResponse{
+			ProductID:   d.ProductID,
+			ProductName: d.ProductName,
+			Stock:       d.Stock,
+			LastSoldAt:  d.LastSoldAt,
+		})
+	}
+	return response
+}
+
+type NegativeStockResponse struct {
+	AsOf      time.Time                   `json:"as_of"`
+	Products  []NegativeStockItemResponse `json:"products"`
+	Shortfall int                         `json:"shortfall"`
+}
+
+type NegativeStockItemResponse struct {
+	ProductID       string                 `json:"product_id"`
+	ProductName     string                 `json:"product_name"`
+	Stock           int                    `json:"stock"`
+	InventoryPolicy domain.InventoryPolicy `json:"inventory_policy" enums:"tracked,untracked,allow_negative"`
+}
+
+func newNegativeStockResponse(report *domain.NegativeStockReport) *NegativeStockResponse {
+	response := &NegativeStockResponse{
+		AsOf:      report.AsOf,
+		Products:  make([]NegativeStockItemResponse, 0, len(report.Products)),
+		Shortfall: report.Shortfall,
+	}
+	for _, p := range report.Products {
+		response.Products = append(response.Products, NegativeStockItemResponse{
+			ProductID:       p.ProductID,
+			ProductName:     p.ProductName,
+			Stock:           p.Stock,
+			InventoryPolicy: p.InventoryPolicy,
+		})
+	}
+	return response
+}
+
+type CreateWebhookRequest struct {
+	URL        string             `json:"url"`
+	EventTypes []domain.EventType `json:"event_types" enums:"stock.changed,order.created"`
+	// Secret signs the deliveries; one is generated when it is empty.
+	Secret string `json:"secret,omitempty"`
+}
+
+func (req *CreateWebhookRequest) toDomain() *domain.Webhook {
+	return &domain.Webhook{URL: req.URL, EventTypes: req.EventTypes, Secret: req.Secret}
+}
+
+type WebhookResponse struct {
+	ID         string             `json:"id"`
+	URL        string             `json:"url"`
+	EventTypes []domain.EventType `json:"event_types"`
+	// Secret is only returned when the webhook is created.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newWebhookResponse(webhook *domain.Webhook) *WebhookResponse {
+	return &WebhookResponse{ID: webhook.ID, URL: webhook.URL, EventTypes: webhook.EventTypes, CreatedAt: webhook.CreatedAt}
+}
+
+func newWebhookResponses(webhooks []domain.Webhook) []WebhookResponse {
+	responses := make([]WebhookResponse, 0, len(webhooks))
+	for i := range webhooks {
+		responses = append(responses, *newWebhookResponse(&webhooks[i]))
+	}
+	return responses
+}
+
+type WebhookDeliveryResponse struct {
+	ID             string                       `json:"id"`
+	WebhookID      string                       `json:"webhook_id"`
+	EventType      domain.EventType             `json:"event_type"`
+	Payload        json.RawMessage              `json:"payload" swaggertype:"object"`
+	Status         domain.WebhookDeliveryStatus `json:"status" enums:"pending,delivered,dead"`
+	Attempts       int                          `json:"attempts"`
+	NextAttemptAt  time.Time                    `json:"next_attempt_at"`
+	LastError      string                       `json:"last_error,omitempty"`
+	ResponseStatus int                          `json:"response_status,omitempty"`
+	CreatedAt      time.Time                    `json:"created_at"`
+	DeliveredAt    *time.Time                   `json:"delivered_at,omitempty"`
+}
+
+func newWebhookDeliveryResponse(delivery *domain.WebhookDelivery) *WebhookDeliveryResponse {
+	return &WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastError:      delivery.LastError,
+		ResponseStatus: delivery.ResponseStatus,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+	}
+}
+
+func newWebhookDeliveryResponses(deliveries []domain.WebhookDelivery) []WebhookDeliveryResponse {
+	responses := make([]WebhookDeliveryResponse, 0, len(deliveries))
+	for i := range deliveries {
+		responses = append(responses, *newWebhookDeliveryResponse(&deliveries[i]))
+	}
+	return responses
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string         `json:"name"`
+	Scopes []domain.Scope `json:"scopes" enums:"products:read,products:write,orders:write,admin"`
+}
+
+func (req *CreateAPIKeyRequest) toDomain() *domain.APIKey {
+	return &domain.APIKey{Name: req.Name, Scopes: req.Scopes}
+}
+
+type APIKeyResponse struct {
+	ID       string         `json:"id"`
+	TenantID string         `json:"tenant_id"`
+	Name     string         `json:"name"`
+	Prefix   string         `json:"prefix"`
+	Scopes   []domain.Scope `json:"scopes"`
+	// Key is only returned when the key is created.
+	Key        string     `json:"key,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func newAPIKeyResponse(apiKey *domain.APIKey) *APIKeyResponse {
+	return &APIKeyResponse{
+		ID:         apiKey.ID,
+		TenantID:   apiKey.TenantID,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scopes:     apiKey.Scopes,
+		CreatedAt:  apiKey.CreatedAt,
+		LastUsedAt: apiKey.LastUsedAt,
+		RevokedAt:  apiKey.RevokedAt,
+	}
+}
+
+func newAPIKeyResponses(apiKeys []domain.APIKey) []APIKeyResponse {
+	responses := make([]APIKeyResponse, 0, len(apiKeys))
+	for i := range apiKeys {
+		responses = append(responses, *newAPIKeyResponse(&apiKeys[i]))
+	}
+	return responses
+}
+
+type CreateUserRequest struct {
+	Email    string      `json:"email"`
+	Name     string      `json:"name"`
+	Password string      `json:"password"`
+	Role     domain.Role `json:"role" enums:"viewer,clerk,manager,admin"`
+}
+
+func (req *CreateUserRequest) toDomain() *domain.User {
+	return &domain.User{Email: req.Email, Name: req.Name, Role: req.Role}
+}
+
+type UpdateUserRoleRequest struct {
+	Role domain.Role `json:"role" enums:"viewer,clerk,manager,admin"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type UserResponse struct {
+	ID        string      `json:"id"`
+	TenantID  string      `json:"tenant_id"`
+	Email     string      `json:"email"`
+	Name      string      `json:"name"`
+	Role      domain.Role `json:"role"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+func newUserResponse(user *domain.User) *UserResponse {
+	return &UserResponse{ID: user.ID, TenantID: user.TenantID, Email: user.Email, Name: user.Name, Role: user.Role, CreatedAt: user.CreatedAt}
+}
+
+func newUserResponses(users []domain.User) []UserResponse {
+	responses := make([]UserResponse, 0, len(users))
+	for i := range users {
+		responses = append(responses, *newUserResponse(&users[i]))
+	}
+	return responses
+}
+
+type TokenResponse struct {
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	TokenType        string    `json:"token_type" example:"Bearer"`
+}
+
+func newTokenResponse(pair *domain.TokenPair) *TokenResponse {
+	return &TokenResponse{
+		AccessToken:      pair.AccessToken,
+		AccessExpiresAt:  pair.AccessExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+		TokenType:        "Bearer",
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+// productETag is the strong entity tag of a product version.
+func productETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseETag returns the version in a strong product entity tag.
+func parseETag(tag string) (int, bool) {
+	tag = strings.TrimSpace(tag)
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
+
+// readIfMatch returns the product version an update must apply to, or 0 for
+// any version when If-Match is * or, unless it is required, missing. It
+// answers 428 when a required header is missing and 412 when the header can
+// never match.
+func (h *HTTPHandler) readIfMatch(w http.ResponseWriter, r *http.Request) (int, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	switch header {
+	case "":
+		if h.requireIfMatch {
+			h.writeError(w, http.StatusPreconditionRequired, "If-Match header is required")
+			return 0, false
+		}
+		return 0, true
+	case "*":
+		return 0, true
+	}
+	version, ok := parseETag(header)
+	if !ok {
+		h.writeError(w, http.StatusPreconditionFailed, "If-Match must be a product ETag")
+		return 0, false
+	}
+	return version, true
+}
+
+// notModified reports whether If-None-Match matches the product's ETag.
+func notModified(r *http.Request, product *domain.Product) bool {
+	header := r.Header.Get("If-None-Match")
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if version, ok := parseETag(tag); ok && version == product.Version {
+			return true
+		}
+	}
+	return false
+}
+
+// writeProduct answers with the product and its ETag.
+func (h *HTTPHandler) writeProduct(w http.ResponseWriter, status int, product *domain.Product) {
+	w.Header().Set("ETag", productETag(product.Version))
+	h.writeJSON(w, status, newProductResponse(product))
+}
+
+// writeProductError answers 412 for version conflicts and 500 otherwise.
+func (h *HTTPHandler) writeProductError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrVersionConflict) {
+		h.writeError(w, http.StatusPreconditionFailed, err.Error())
+		return
+	}
+	h.writeError(w, http.StatusInternalServerError, err.Error())
+}
@@ -0,0 +1,314 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/internal/service"
+	"github.com/iamtbay/is-management/pkg/helpers"
+)
+
+type mockTransactor struct{}
+
+func (m *mockTransactor) WithinTx(fn func(ctx context.Context) error, ctx context.Context) error {
+	return fn(ctx)
+}
+
+func (m *mockTransactor) WithinSnapshot(fn func(ctx context.Context) error, ctx context.Context) error {
+	return fn(ctx)
+}
+
+// mockProductRepo keeps products by ID and bumps their version on every
+// change, like the database does.
+type mockProductRepo struct {
+	fakeProducts map[string]*domain.Product
+	fakeUnits    []domain.ProductUnit
+}
+
+func (m *mockProductRepo) Save(product *domain.Product, ctx context.Context) error {
+	product.TenantID = domain.TenantFromContext(ctx)
+	product.Version = 1
+	stored := *product
+	if m.fakeProducts == nil {
+		m.fakeProducts = make(map[string]*domain.Product)
+	}
+	m.fakeProducts[product.ID] = &stored
+	return nil
+}
+
+func (m *mockProductRepo) FindAll(ctx context.Context) ([]domain.Product, error) {
+	var products []domain.Product
+	for _, product := range m.fakeProducts {
+		products = append(products, *product)
+	}
+	return products, nil
+}
+
+func (m *mockProductRepo) FindByID(id string, ctx context.Context) (*domain.Product, error) {
+	product, ok := m.fakeProducts[id]
+	if !ok {
+		return nil, errors.New("product not found")
+	}
+	found := *product
+	return &found, nil
+}
+
+// change applies fn to the product when it is still at version.
+func (m *mockProductRepo) change(id string, version int, fn func(product *domain.Product) error) (*domain.Product, error) {
+	product, ok := m.fakeProducts[id]
+	if !ok {
+		return nil, errors.New("product not found")
+	}
+	if version != 0 && version != product.Version {
+		return nil, domain.ErrVersionConflict
+	}
+	if err := fn(product); err != nil {
+		return nil, err
+	}
+	product.Version++
+	changed := *product
+	return &changed, nil
+}
+
+func (m *mockProductRepo) UpdateStock(id string, stockQuantity int, version int, ctx context.Context) (*domain.Product, error) {
+	return m.change(id, version, func(product *domain.Product) error {
+		product.Stock -= stockQuantity
+		return nil
+	})
+}
+
+func (m *mockProductRepo) IncreaseStock(id string, stockQuantity int, ctx context.Context) (*domain.Product, error) {
+	return m.change(id, 0, func(product *domain.Product) error {
+		product.Stock += stockQuantity
+		return nil
+	})
+}
+
+func (m *mockProductRepo) UpdateReorderSettings(id string, reorderPoint int, reorderQuantity int, version int, ctx context.Context) (*domain.Product, error) {
+	return m.change(id, version, func(product *domain.Product) error {
+		product.ReorderPoint = reorderPoint
+		product.ReorderQuantity = reorderQuantity
+		return nil
+	})
+}
+
+func (m *mockProductRepo) MoveStock(id string, from domain.StockBucket, to domain.StockBucket, quantity int, version int, ctx context.Context) (*domain.Product, error) {
+	return m.change(id, version, func(product *domain.Product) error {
+		buckets := map[domain.StockBucket]*int{
+			domain.BucketAvailable:   &product.Stock,
+			domain.BucketReserved:    &product.Reserved,
+			domain.BucketDamaged:     &product.Damaged,
+			domain.BucketQuarantined: &product.Quarantined,
+		}
+		if *buckets[from] < quantity {
+			return errors.New("not enough stock in " + string(from))
+		}
+		*buckets[from] -= quantity
+		*buckets[to] += quantity
+		return nil
+	})
+}
+
+func (m *mockProductRepo) SaveUnit(unit *domain.ProductUnit, version int, ctx context.Context) (*domain.Product, error) {
+	return m.change(unit.ProductID, version, func(product *domain.Product) error {
+		m.fakeUnits = append(m.fakeUnits, *unit)
+		return nil
+	})
+}
+
+func (m *mockProductRepo) FindUnits(productID string, ctx context.Context) ([]domain.ProductUnit, error) {
+	return m.fakeUnits, nil
+}
+
+type mockStockHistoryRepo struct {
+	movements []domain.StockMovement
+}
+
+func (m *mockStockHistoryRepo) SaveMovement(movement *domain.StockMovement, ctx context.Context) error {
+	m.movements = append(m.movements, *movement)
+	return nil
+}
+
+func (m *mockStockHistoryRepo) StockAt(productID string, asOf time.Time, ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (m *mockStockHistoryRepo) StockAtAll(asOf time.Time, ctx context.Context) ([]domain.StockLevel, error) {
+	return nil, nil
+}
+
+func (m *mockStockHistoryRepo) StockBeforeAll(before time.Time, ctx context.Context) ([]domain.StockLevel, error) {
+	return nil, nil
+}
+
+func (m *mockStockHistoryRepo) SaveSnapshot(takenAt time.Time, ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (m *mockStockHistoryRepo) SaveSnapshotAll(takenAt time.Time, ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (m *mockStockHistoryRepo) MovementTotals(reason domain.StockMovementReason, from time.Time, to time.Time, ctx context.Context) (map[string]int, error) {
+	return nil, nil
+}
+
+func (m *mockStockHistoryRepo) TotalsExcluding(reason domain.StockMovementReason, ctx context.Context) (map[string]int, error) {
+	return nil, nil
+}
+
+type mockCostLayerRepo struct{}
+
+func (m *mockCostLayerRepo) SaveLayer(layer *domain.CostLayer, ctx context.Context) error {
+	return nil
+}
+
+func (m *mockCostLayerRepo) FindOpenLayers(productID string, ctx context.Context) ([]domain.CostLayer, error) {
+	return nil, nil
+}
+
+func (m *mockCostLayerRepo) Consume(consumptions []domain.CostConsumption, ctx context.Context) error {
+	return nil
+}
+
+func (m *mockCostLayerRepo) FindConsumptions(orderID string, productID string, ctx context.Context) ([]domain.CostConsumption, error) {
+	return nil, nil
+}
+
+func (m *mockCostLayerRepo) ProductTotals(productID string, ctx context.Context) (*domain.CostTotals, error) {
+	return &domain.CostTotals{ProductID: productID}, nil
+}
+
+func (m *mockCostLayerRepo) Totals(asOf time.Time, ctx context.Context) ([]domain.CostTotals, error) {
+	return nil, nil
+}
+
+// newProductRouter serves the API without authentication over in-memory
+// products.
+func newProductRouter(requireIfMatch bool) (http.Handler, *mockProductRepo) {
+	products := &mockProductRepo{}
+	productService := service.NewProductService(service.ProductServiceDeps{
+		Transactor:   &mockTransactor{},
+		Products:     products,
+		Valuation:    service.NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+		StockHistory: service.NewStockHistoryService(&mockStockHistoryRepo{}),
+		Events:       service.NewEventService(10),
+	})
+	handler := NewHTTPHandler(Services{Products: productService}, requireIfMatch)
+	return NewRouter(handler, AuthOptions{}, RateLimitOptions{}), products
+}
+
+// saveProduct stores a product at version 1 and returns its ID.
+func saveProduct(t *testing.T, products *mockProductRepo) string {
+	t.Helper()
+	product := &domain.Product{ID: helpers.GenerateUUID(), Name: "Widget", Price: 10, Stock: 10, InventoryPolicy: domain.PolicyTracked}
+	if err := products.Save(product, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	return product.ID
+}
+
+// TESTS
+func TestCreateProduct_SetsETag(t *testing.T) {
+	router, _ := newProductRouter(false)
+	rec := serve(router, httptest.NewRequest(http.MethodPost, "/v1/products", strings.NewReader(`{"name":"Widget","price":10,"stock":5}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %v (%v)", rec.Code, rec.Body.String())
+	}
+	if etag := rec.Header().Get("ETag"); etag != `"1"` {
+		t.Errorf(`expected ETag "1", got %v`, etag)
+	}
+}
+
+func TestFindProductByID_IfNoneMatch(t *testing.T) {
+	router, products := newProductRouter(false)
+	id := saveProduct(t, products)
+
+	tests := []struct {
+		ifNoneMatch string
+		status      int
+	}{
+		{ifNoneMatch: "", status: http.StatusOK},
+		{ifNoneMatch: `"1"`, status: http.StatusNotModified},
+		{ifNoneMatch: `W/"1"`, status: http.StatusNotModified},
+		{ifNoneMatch: `"3", "1"`, status: http.StatusNotModified},
+		{ifNoneMatch: "*", status: http.StatusNotModified},
+		{ifNoneMatch: `"2"`, status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/v1/products/"+id, nil)
+		if tt.ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", tt.ifNoneMatch)
+		}
+		rec := serve(router, r)
+		if rec.Code != tt.status {
+			t.Errorf("expected status %v for If-None-Match %v, got %v", tt.status, tt.ifNoneMatch, rec.Code)
+		}
+		if etag := rec.Header().Get("ETag"); etag != `"1"` {
+			t.Errorf(`expected ETag "1" for If-None-Match %v, got %v`, tt.ifNoneMatch, etag)
+		}
+		if tt.status == http.StatusNotModified && rec.Body.Len() != 0 {
+			t.Errorf("expected no body for 304, got %v", rec.Body.String())
+		}
+	}
+}
+
+func TestProductUpdates_IfMatch(t *testing.T) {
+	updates := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{name: "stock", method: http.MethodPatch, path: "", body: `{"quantity":1}`},
+		{name: "reorder settings", method: http.MethodPut, path: "/reorder-settings", body: `{"reorder_point":5,"reorder_quantity":10}`},
+		{name: "stock move", method: http.MethodPost, path: "/stock-moves", body: `{"from":"available","to":"damaged","quantity":1}`},
+		{name: "unit", method: http.MethodPut, path: "/units/case", body: `{"factor":24}`},
+	}
+	tests := []struct {
+		name           string
+		requireIfMatch bool
+		ifMatch        string
+		status         int
+		etag           string
+	}{
+		{name: "current version", ifMatch: `"1"`, status: http.StatusOK, etag: `"2"`},
+		{name: "stale version", ifMatch: `"2"`, status: http.StatusPreconditionFailed},
+		{name: "malformed", ifMatch: "1", status: http.StatusPreconditionFailed},
+		{name: "any version", ifMatch: "*", requireIfMatch: true, status: http.StatusOK, etag: `"2"`},
+		{name: "missing", status: http.StatusOK, etag: `"2"`},
+		{name: "missing but required", requireIfMatch: true, status: http.StatusPreconditionRequired},
+	}
+	for _, update := range updates {
+		for _, tt := range tests {
+			t.Run(update.name+"/"+tt.name, func(t *testing.T) {
+				router, products := newProductRouter(tt.requireIfMatch)
+				id := saveProduct(t, products)
+				r := httptest.NewRequest(update.method, "/v1/products/"+id+update.path, strings.NewReader(update.body))
+				if tt.ifMatch != "" {
+					r.Header.Set("If-Match", tt.ifMatch)
+				}
+				rec := serve(router, r)
+				if rec.Code != tt.status {
+					t.Fatalf("expected status %v, got %v (%v)", tt.status, rec.Code, rec.Body.String())
+				}
+				if etag := rec.Header().Get("ETag"); etag != tt.etag {
+					t.Errorf("expected ETag %q, got %q", tt.etag, etag)
+				}
+				wantVersion := 1
+				if tt.status == http.StatusOK {
+					wantVersion = 2
+				}
+				if product, _ := products.FindByID(id, context.Background()); product.Version != wantVersion {
+					t.Errorf("expected version %v, got %v", wantVersion, product.Version)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+// heartbeatInterval keeps idle event streams, and the proxies in front of
+// them, from timing out.
+const heartbeatInterval = 15 * time.Second
+
+// StreamEvents godoc
+// @Summary Stream stock and order changes
+// @Description Server-Sent Events stream of stock.changed and order.created events. Reconnecting clients send Last-Event-ID to resume from the recent events kept in memory; a reset event means some were lost and the client should reload its state. Comment lines are sent as heartbeats.
+// @Tags events
+// @Produce text/event-stream
+// @Param product_id query []string false "Only events of these products" collectionFormat(multi)
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Success 200 {string} string "Event stream"
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /events/stream [get]
+func (h *HTTPHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	productIDs := r.URL.Query()["product_id"]
+	var v validator
+	for _, productID := range productIDs {
+		v.requiredUUID("product_id", productID)
+	}
+	if !v.valid() {
+		h.writeValidationErrors(w, v.errors)
+		return
+	}
+	// an unparsable Last-Event-ID starts a fresh stream
+	lastEventID, _ := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+
+	sub := h.eventService.Subscribe(domain.TenantFromContext(r.Context()), productIDs, lastEventID)
+	defer h.eventService.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if sub.Reset {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range sub.Replay {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			// closed on shutdown or when the client fell too far behind;
+			// the client reconnects and resumes with Last-Event-ID
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event domain.Event) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
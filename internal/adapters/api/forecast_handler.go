@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+// readForecastParams reads the forecast settings from the query string.
+func (h *HTTPHandler) readForecastParams(r *http.Request) (domain.ForecastParams, error) {
+	params := domain.ForecastParams{
+		Method:            domain.ForecastMethod(r.URL.Query().Get("method")),
+		WeeklySeasonality: r.URL.Query().Get("seasonality") == "weekly",
+	}
+	if params.Method == "" {
+		params.Method = domain.ForecastMovingAverage
+	}
+	var err error
+	if params.Days, err = h.readIntQuery(r, "days", 30); err != nil {
+		return params, err
+	}
+	if params.HistoryDays, err = h.readIntQuery(r, "history_days", 90); err != nil {
+		return params, err
+	}
+	if params.Window, err = h.readIntQuery(r, "window", 7); err != nil {
+		return params, err
+	}
+	if params.Alpha, err = h.readFloatQuery(r, "alpha", 0.3); err != nil {
+		return params, err
+	}
+	return params, nil
+}
+
+// ProductForecast godoc
+// @Summary Forecast a product's demand
+// @Description Forecasts daily demand from order history with a moving average or exponential smoothing, and projects the stockout date
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param days query int false "Days to forecast" default(30)
+// @Param method query string false "moving_average or exponential_smoothing" default(moving_average)
+// @Param history_days query int false "Days of order history to learn from" default(90)
+// @Param window query int false "Moving average window in days" default(7)
+// @Param alpha query number false "Exponential smoothing factor" default(0.3)
+// @Param seasonality query string false "Set to weekly to apply weekly seasonality"
+// @Success 200 {object} ForecastResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{id}/forecast [get]
+func (h *HTTPHandler) ProductForecast(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	params, err := h.readForecastParams(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "days, history_days, window and alpha must be numbers")
+		return
+	}
+	forecast, err := h.forecastService.Forecast(id, params, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newForecastResponse(forecast))
+}
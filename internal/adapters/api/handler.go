@@ -3,20 +3,73 @@ package api
 import (
 	"net/http"
 
-	"github.com/iamtbay/is-management/internal/domain"
 	"github.com/iamtbay/is-management/internal/service"
 )
 
 type HTTPHandler struct {
-	productService *service.ProductService
-	orderService   *service.OrderService
+	productService       *service.ProductService
+	orderService         *service.OrderService
+	supplierService      *service.SupplierService
+	purchaseOrderService *service.PurchaseOrderService
+	replenishmentService *service.ReplenishmentService
+	valuationService     *service.ValuationService
+	stockHistoryService  *service.StockHistoryService
+	forecastService      *service.ForecastService
+	reportService        *service.ReportService
+	stockCheckService    *service.StockCheckService
+	lotService           *service.LotService
+	serialService        *service.SerialService
+	atpService           *service.ATPService
+	eventService         *service.EventService
+	webhookService       *service.WebhookService
+	apiKeyService        *service.APIKeyService
+	userService          *service.UserService
+	// requireIfMatch rejects product updates without If-Match.
+	requireIfMatch bool
+}
+
+// Services are the services the handlers call.
+type Services struct {
+	Products       *service.ProductService
+	Orders         *service.OrderService
+	Suppliers      *service.SupplierService
+	PurchaseOrders *service.PurchaseOrderService
+	Replenishment  *service.ReplenishmentService
+	Valuation      *service.ValuationService
+	StockHistory   *service.StockHistoryService
+	Forecast       *service.ForecastService
+	Reports        *service.ReportService
+	StockCheck     *service.StockCheckService
+	Lots           *service.LotService
+	Serials        *service.SerialService
+	ATP            *service.ATPService
+	Events         *service.EventService
+	Webhooks       *service.WebhookService
+	APIKeys        *service.APIKeyService
+	Users          *service.UserService
 }
 
 // create handler
-func NewHTTPHandler(productService *service.ProductService, orderService *service.OrderService) *HTTPHandler {
+func NewHTTPHandler(services Services, requireIfMatch bool) *HTTPHandler {
 	return &HTTPHandler{
-		productService: productService,
-		orderService:   orderService,
+		productService:       services.Products,
+		orderService:         services.Orders,
+		supplierService:      services.Suppliers,
+		purchaseOrderService: services.PurchaseOrders,
+		replenishmentService: services.Replenishment,
+		valuationService:     services.Valuation,
+		stockHistoryService:  services.StockHistory,
+		forecastService:      services.Forecast,
+		reportService:        services.Reports,
+		stockCheckService:    services.StockCheck,
+		lotService:           services.Lots,
+		serialService:        services.Serials,
+		atpService:           services.ATP,
+		eventService:         services.Events,
+		webhookService:       services.Webhooks,
+		apiKeyService:        services.APIKeys,
+		userService:          services.Users,
+		requireIfMatch:       requireIfMatch,
 	}
 }
 
@@ -26,52 +79,62 @@ func NewHTTPHandler(productService *service.ProductService, orderService *servic
 // @Tags orders
 // @Accept json
 // @Produce json
-// @Param order body domain.Order true "Order Info"
-// @Success 201 {object} domain.Order
+// @Param order body CreateOrderRequest true "Order Info"
+// @Success 201 {object} OrderResponse
 // @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /orders [post]
 func (h *HTTPHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
-	var order domain.Order
-	if err := h.readJSON(w, r, &order); err != nil {
-		h.writeError(w, http.StatusBadRequest, "Invalid JSON format or body too large")
+	var req CreateOrderRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := validateOrder(&req); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
 		return
 	}
 	ctx := r.Context()
-	err := h.orderService.CreateOrder(&order, ctx)
+	order := req.toDomain()
+	err := h.orderService.CreateOrder(order, ctx)
 	if err != nil {
 		h.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	h.writeJSON(w, http.StatusCreated, &order)
+	h.writeJSON(w, http.StatusCreated, newOrderResponse(order))
 }
 
 // CreateProduct godoc
 // @Summary Create a new product
-// @Description Adds a new product to the inventory
+// @Description Adds a new product to the inventory; unit_cost values its initial stock
 // @Tags products
 // @Accept json
 // @Produce json
-// @Param product body domain.Product true "Product Info"
-// @Success 201 {object} domain.Product
+// @Param product body CreateProductRequest true "Product Info"
+// @Success 201 {object} ProductResponse
 // @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /products [post]
 func (h *HTTPHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
-	var product domain.Product
-	if err := h.readJSON(w, r, &product); err != nil {
-		h.writeError(w, http.StatusBadRequest, "Invalid JSON format or body too large")
+	var req CreateProductRequest
+	if !h.decodeJSON(w, r, &req) {
 		return
 	}
-	if product.Stock < 1 {
-		h.writeError(w, http.StatusBadRequest, "stock must be greater than 0")
+	if errs := validateProduct(&req); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
 		return
 	}
 	ctx := r.Context()
-	err := h.productService.CreateProduct(&product, ctx)
+	product := req.toDomain()
+	err := h.productService.CreateProduct(product, req.UnitCost, ctx)
 	if err != nil {
 		h.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	h.writeJSON(w, http.StatusCreated, &product)
+	h.writeProduct(w, http.StatusCreated, product)
 }
 
 // FindProductByID godoc
@@ -81,14 +144,19 @@ func (h *HTTPHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
-// @Success 200 {object} domain.Product
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {object} ProductResponse
+// @Header 200 {string} ETag "Product version"
+// @Success 304 "Not modified"
 // @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /products/{id} [get]
 func (h *HTTPHandler) FindProductByID(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	id := r.PathValue("id")
-	if id == "" {
-		h.writeError(w, http.StatusBadRequest, "ID is empty")
+	id, ok := h.readPathID(w, r)
+	if !ok {
 		return
 	}
 
@@ -97,7 +165,12 @@ func (h *HTTPHandler) FindProductByID(w http.ResponseWriter, r *http.Request) {
 		h.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	h.writeJSON(w, http.StatusOK, &product)
+	if notModified(r, product) {
+		w.Header().Set("ETag", productETag(product.Version))
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.writeProduct(w, http.StatusOK, product)
 }
 
 // UpdateStock godoc
@@ -108,8 +181,15 @@ func (h *HTTPHandler) FindProductByID(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path string true "Product ID"
 // @Param stock body UpdateStockRequest true "Stock quantity"
-// @Success 200 {object} domain.Product
+// @Param If-Match header string false "ETag of the product version being changed"
+// @Success 200 {object} ProductResponse
+// @Header 200 {string} ETag "New product version"
 // @Failure 400 {object} string
+// @Failure 412 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Failure 428 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /products/{id} [patch]
 type UpdateStockRequest struct {
 	Quantity int `json:"quantity"`
@@ -118,21 +198,120 @@ type UpdateStockRequest struct {
 func (h *HTTPHandler) UpdateStock(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var stock UpdateStockRequest
-	if err := h.readJSON(w, r, &stock); err != nil {
-		h.writeError(w, http.StatusBadRequest, "Invalid JSON format or body too large")
+	if !h.decodeJSON(w, r, &stock) {
 		return
 	}
-	id := r.PathValue("id")
-	if id == "" {
-		h.writeError(w, http.StatusBadRequest, "ID is empty")
+	if errs := validateUpdateStock(&stock); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
 		return
 	}
-	product, err := h.productService.UpdateStock(id, stock.Quantity, ctx)
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	version, ok := h.readIfMatch(w, r)
+	if !ok {
+		return
+	}
+	product, err := h.productService.UpdateStock(id, stock.Quantity, version, ctx)
 	if err != nil {
-		h.writeError(w, http.StatusInternalServerError, err.Error())
+		h.writeProductError(w, err)
+		return
+	}
+	h.writeProduct(w, http.StatusOK, product)
+}
+
+// UpdateReorderSettings godoc
+// @Summary Update a product's reorder settings
+// @Description Sets the reorder point and minimum reorder quantity used by replenishment
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param settings body UpdateReorderSettingsRequest true "Reorder settings"
+// @Param If-Match header string false "ETag of the product version being changed"
+// @Success 200 {object} ProductResponse
+// @Header 200 {string} ETag "New product version"
+// @Failure 400 {object} string
+// @Failure 412 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Failure 428 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{id}/reorder-settings [put]
+type UpdateReorderSettingsRequest struct {
+	ReorderPoint    int `json:"reorder_point"`
+	ReorderQuantity int `json:"reorder_quantity"`
+}
+
+func (h *HTTPHandler) UpdateReorderSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var settings UpdateReorderSettingsRequest
+	if !h.decodeJSON(w, r, &settings) {
+		return
+	}
+	if errs := validateReorderSettings(&settings); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	version, ok := h.readIfMatch(w, r)
+	if !ok {
+		return
+	}
+	product, err := h.productService.UpdateReorderSettings(id, settings.ReorderPoint, settings.ReorderQuantity, version, ctx)
+	if err != nil {
+		h.writeProductError(w, err)
+		return
+	}
+	h.writeProduct(w, http.StatusOK, product)
+}
+
+// MoveStock godoc
+// @Summary Move stock between buckets
+// @Description Moves units of a product between the available, reserved, damaged and quarantined buckets, e.g. to quarantine stock that failed QC or release it after inspection. Lot-tracked and serialized products cannot be moved to damaged or quarantined
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param move body StockMoveRequest true "Stock move"
+// @Param If-Match header string false "ETag of the product version being changed"
+// @Success 200 {object} ProductResponse
+// @Header 200 {string} ETag "New product version"
+// @Failure 400 {object} string
+// @Failure 412 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Failure 428 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{id}/stock-moves [post]
+func (h *HTTPHandler) MoveStock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var move StockMoveRequest
+	if !h.decodeJSON(w, r, &move) {
+		return
+	}
+	if errs := validateStockMove(&move); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	version, ok := h.readIfMatch(w, r)
+	if !ok {
+		return
+	}
+	product, err := h.productService.MoveStock(id, move.toDomain(), version, ctx)
+	if err != nil {
+		h.writeProductError(w, err)
 		return
 	}
-	h.writeJSON(w, http.StatusOK, &product)
+	h.writeProduct(w, http.StatusOK, product)
 }
 
 // FindAllProducts godoc
@@ -141,8 +320,10 @@ func (h *HTTPHandler) UpdateStock(w http.ResponseWriter, r *http.Request) {
 // @Tags products
 // @Accept json
 // @Produce json
-// @Success 200 {object} []domain.Product
+// @Success 200 {object} []ProductResponse
 // @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /products [get]
 func (h *HTTPHandler) FindAllProducts(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -151,7 +332,7 @@ func (h *HTTPHandler) FindAllProducts(w http.ResponseWriter, r *http.Request) {
 		h.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	h.writeJSON(w, http.StatusOK, &products)
+	h.writeJSON(w, http.StatusOK, newProductResponses(products))
 }
 
 // FindAllOrders godoc
@@ -160,8 +341,10 @@ func (h *HTTPHandler) FindAllProducts(w http.ResponseWriter, r *http.Request) {
 // @Tags orders
 // @Accept json
 // @Produce json
-// @Success 200 {object} []domain.Order
+// @Success 200 {object} []OrderResponse
 // @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /orders [get]
 func (h *HTTPHandler) FindAllOrders(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -170,7 +353,7 @@ func (h *HTTPHandler) FindAllOrders(w http.ResponseWriter, r *http.Request) {
 		h.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	h.writeJSON(w, http.StatusOK, &orders)
+	h.writeJSON(w, http.StatusOK, newOrderResponses(orders))
 }
 
 // FindOrderByID godoc
@@ -180,14 +363,16 @@ func (h *HTTPHandler) FindAllOrders(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Order ID"
-// @Success 200 {object} domain.Order
+// @Success 200 {object} OrderResponse
 // @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /orders/{id} [get]
 func (h *HTTPHandler) FindOrderByID(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	id := r.PathValue("id")
-	if id == "" {
-		h.writeError(w, http.StatusBadRequest, "ID is empty")
+	id, ok := h.readPathID(w, r)
+	if !ok {
 		return
 	}
 
@@ -196,5 +381,5 @@ func (h *HTTPHandler) FindOrderByID(w http.ResponseWriter, r *http.Request) {
 		h.writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	h.writeJSON(w, http.StatusOK, &order)
+	h.writeJSON(w, http.StatusOK, newOrderResponse(order))
 }
@@ -1,8 +1,11 @@
 package api
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 func (h *HTTPHandler) writeJSON(w http.ResponseWriter, status int, data interface{}) error {
@@ -11,6 +14,13 @@ func (h *HTTPHandler) writeJSON(w http.ResponseWriter, status int, data interfac
 	return json.NewEncoder(w).Encode(data)
 }
 
+func (h *HTTPHandler) writeCSV(w http.ResponseWriter, status int, filename string, records [][]string) error {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(status)
+	return csv.NewWriter(w).WriteAll(records)
+}
+
 func (h *HTTPHandler) writeError(w http.ResponseWriter, status int, message string) {
 	type envelope struct {
 		Error string `json:"error"`
@@ -22,8 +32,57 @@ func (h *HTTPHandler) readJSON(w http.ResponseWriter, r *http.Request, data inte
 	maxBytes :=1048576
 	r.Body=http.MaxBytesReader(w,r.Body,int64(maxBytes))
 	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
 	if err:=dec.Decode(data);err!=nil{
 		return err
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// readIntQuery returns the integer query parameter key, or fallback when it is not set.
+func (h *HTTPHandler) readIntQuery(r *http.Request, key string, fallback int) (int, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// readFloatQuery returns the float query parameter key, or fallback when it is not set.
+func (h *HTTPHandler) readFloatQuery(r *http.Request, key string, fallback float64) (float64, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// readTimeQuery returns the time query parameter key, or fallback when it is not set.
+// Both RFC 3339 timestamps and plain dates are accepted; a plain date means the
+// end of that day in UTC.
+func (h *HTTPHandler) readTimeQuery(r *http.Request, key string, fallback time.Time) (time.Time, error) {
+	return parseTimeQuery(r.URL.Query().Get(key), fallback, true)
+}
+
+// readFromTimeQuery is readTimeQuery for the start of a range: a plain date
+// means the start of that day in UTC.
+func (h *HTTPHandler) readFromTimeQuery(r *http.Request, key string, fallback time.Time) (time.Time, error) {
+	return parseTimeQuery(r.URL.Query().Get(key), fallback, false)
+}
+
+func parseTimeQuery(value string, fallback time.Time, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		return day.AddDate(0, 0, 1).Add(-time.Microsecond), nil
+	}
+	return day, nil
+}
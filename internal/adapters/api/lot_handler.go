@@ -0,0 +1,122 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+// FindProductLots godoc
+// @Summary Find a product's lots
+// @Description Lists the lots of a lot-tracked product with their expiry dates and remaining quantities, first expiry first
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} []LotResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{id}/lots [get]
+func (h *HTTPHandler) FindProductLots(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	if _, err := h.productService.FindProductByID(id, ctx); err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	lots, err := h.lotService.FindByProduct(id, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newLotResponses(lots))
+}
+
+// TraceLot godoc
+// @Summary Trace a lot
+// @Description Lists every order, customer and quantity that consumed the lot number, with the quantity still on hand
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Param lot path string true "Lot number"
+// @Param product_id query string false "Only the lot of this product"
+// @Success 200 {object} LotTraceResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /lots/{lot}/trace [get]
+func (h *HTTPHandler) TraceLot(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	lotNumber := r.PathValue("lot")
+	if lotNumber == "" {
+		h.writeError(w, http.StatusBadRequest, "lot number is empty")
+		return
+	}
+	trace, err := h.lotService.Trace(lotNumber, r.URL.Query().Get("product_id"), ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newLotTraceResponse(trace))
+}
+
+// QuarantineLot godoc
+// @Summary Quarantine a lot
+// @Description Blocks the lot number from order allocation, e.g. during a recall, and moves its remaining units to the quarantined stock bucket
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Param lot path string true "Lot number"
+// @Param product_id query string false "Only the lot of this product"
+// @Success 200 {object} []LotResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /lots/{lot}/quarantine [post]
+func (h *HTTPHandler) QuarantineLot(w http.ResponseWriter, r *http.Request) {
+	h.setLotQuarantine(w, r, true)
+}
+
+// ReleaseLot godoc
+// @Summary Release a quarantined lot
+// @Description Makes a quarantined lot number available for order allocation again and moves its remaining units back to available stock
+// @Tags lots
+// @Accept json
+// @Produce json
+// @Param lot path string true "Lot number"
+// @Param product_id query string false "Only the lot of this product"
+// @Success 200 {object} []LotResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /lots/{lot}/release [post]
+func (h *HTTPHandler) ReleaseLot(w http.ResponseWriter, r *http.Request) {
+	h.setLotQuarantine(w, r, false)
+}
+
+func (h *HTTPHandler) setLotQuarantine(w http.ResponseWriter, r *http.Request, quarantined bool) {
+	ctx := r.Context()
+	lotNumber := r.PathValue("lot")
+	if lotNumber == "" {
+		h.writeError(w, http.StatusBadRequest, "lot number is empty")
+		return
+	}
+	productID := r.URL.Query().Get("product_id")
+	var lots []domain.Lot
+	var err error
+	if quarantined {
+		lots, err = h.lotService.Quarantine(lotNumber, productID, ctx)
+	} else {
+		lots, err = h.lotService.Release(lotNumber, productID, ctx)
+	}
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newLotResponses(lots))
+}
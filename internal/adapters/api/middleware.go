@@ -1,19 +1,345 @@
 package api
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"log/slog"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/internal/logging"
+	"github.com/iamtbay/is-management/internal/service"
 )
 
-func LoggerMiddleware(next http.Handler) http.Handler {
+// RequestIDHeader carries the ID of a request, which is taken from the client
+// when it sends a usable one and generated otherwise.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware puts the request ID into the request context, where the
+// logging handler picks it up, and echoes it in the response.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID accepts up to 128 printable ASCII characters, so client IDs
+// cannot break log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// responseRecorder captures the status and size of a response. Unwrap keeps
+// http.ResponseController working, which event streams flush through.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	n, err := rr.ResponseWriter.Write(b)
+	rr.bytes += n
+	return n, err
+}
+
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+// LoggerMiddleware logs every request once it is answered, with the request
+// ID of its context and the client IP the rate limits see.
+func LoggerMiddleware(trustProxy bool, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		next.ServeHTTP(w, r)
-		slog.Info("Request handled",
+		recorder := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		slog.InfoContext(r.Context(), "Request handled",
 			"method", r.Method,
 			"path", r.URL.Path,
+			"status", recorder.status,
+			"bytes", recorder.bytes,
+			"client", clientIP(r, trustProxy),
+			"user_agent", r.UserAgent(),
 			"duration", time.Since(start),
 		)
 	})
 }
+
+// clientIP returns the IP of the client. Behind a trusted proxy it is the
+// last one in X-Forwarded-For, which the proxy added, and otherwise the IP of
+// the connection the request came in on.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if forwarded := r.Header.Values("X-Forwarded-For"); trustProxy && len(forwarded) > 0 {
+		last := forwarded[len(forwarded)-1]
+		if i := strings.LastIndex(last, ","); i >= 0 {
+			last = last[i+1:]
+		}
+		if ip := strings.TrimSpace(last); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimit answers 429 once a client used up its requests for the route, or
+// for the API when the route has no limit of its own. Clients are told apart
+// by the API key or user they authenticated as and otherwise by IP, and the
+// RateLimit-* headers tell them how many requests they have left.
+func (h *HTTPHandler) rateLimit(options RateLimitOptions, next http.Handler) http.Handler {
+	limiter := service.NewRateLimiter()
+	// the patterns are matched the way the API routes are
+	routes := http.NewServeMux()
+	for pattern := range options.Routes {
+		routes.HandleFunc(pattern, http.NotFound)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, bucket := options.Default, "default"
+		if _, pattern := routes.Handler(r); pattern != "" {
+			limit, bucket = options.Routes[pattern], pattern
+		}
+		if !limit.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		decision := limiter.Allow(bucket+" "+rateLimitClient(r, options.TrustProxy), limit, time.Now())
+		header := w.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		header.Set("RateLimit-Reset", ceilSeconds(decision.Reset))
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s", limit.Requests, ceilSeconds(limit.Per)))
+		if !decision.Allowed {
+			h.writeRateLimited(w, decision)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitIP answers 429 once an IP used up its requests under limit. It runs in
+// front of authenticate, so a flood of requests from one IP is turned away
+// before its credentials are looked up in the database. The limit is meant to
+// be well above the per-client ones, which still apply after it.
+func (h *HTTPHandler) limitIP(limit service.RateLimit, trustProxy bool, next http.Handler) http.Handler {
+	if !limit.Enabled() {
+		return next
+	}
+	limiter := service.NewRateLimiter()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		decision := limiter.Allow(clientIP(r, trustProxy), limit, time.Now())
+		if !decision.Allowed {
+			h.writeRateLimited(w, decision)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeRateLimited answers 429 with the time until the next request is allowed.
+func (h *HTTPHandler) writeRateLimited(w http.ResponseWriter, decision service.RateDecision) {
+	retryAfter := ceilSeconds(decision.RetryAfter)
+	w.Header().Set("Retry-After", retryAfter)
+	h.writeError(w, http.StatusTooManyRequests, "rate limit exceeded, retry in "+retryAfter+" seconds")
+}
+
+// rateLimitClient identifies the client by its authenticated API key or user,
+// or by IP. Credentials that did not authenticate count as the IP, so made up
+// keys cannot buy fresh limits.
+func rateLimitClient(r *http.Request, trustProxy bool) string {
+	if apiKey, ok := APIKeyFromContext(r.Context()); ok {
+		return "key:" + apiKey.ID
+	}
+	if user, ok := UserFromContext(r.Context()); ok {
+		return "user:" + user.ID
+	}
+	return "ip:" + clientIP(r, trustProxy)
+}
+
+// ceilSeconds formats d in whole seconds, rounded up.
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// TenantHeader names the tenant of a request when it is not authenticated.
+// Authenticated requests belong to the tenant of their credentials and may
+// only repeat it here.
+const TenantHeader = "X-Tenant-ID"
+
+// resolveTenant scopes the request to the tenant in TenantHeader, or the
+// default tenant when there is none.
+func (h *HTTPHandler) resolveTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID := r.Header.Get(TenantHeader)
+		if tenantID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !domain.ValidTenant(tenantID) {
+			h.writeError(w, http.StatusBadRequest, TenantHeader+" must be lowercase letters, digits, dashes or underscores")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(domain.WithTenant(r.Context(), tenantID)))
+	})
+}
+
+type (
+	apiKeyContextKey    struct{}
+	userContextKey      struct{}
+	authErrorContextKey struct{}
+)
+
+// authError is why the credentials of a request did not authenticate, and
+// the error that makes it a 401 rather than a 500.
+type authError struct {
+	err             error
+	unauthenticated error
+}
+
+// APIKeyFromContext returns the key the request was authenticated with.
+func APIKeyFromContext(ctx context.Context) (*domain.APIKey, bool) {
+	apiKey, ok := ctx.Value(apiKeyContextKey{}).(*domain.APIKey)
+	return apiKey, ok
+}
+
+// UserFromContext returns the user acting in the request. Only the ID,
+// tenant, email and role are set, as they come from the access token.
+func UserFromContext(ctx context.Context) (*domain.User, bool) {
+	user, ok := ctx.Value(userContextKey{}).(*domain.User)
+	return user, ok
+}
+
+// authenticate puts the API key or user of the request into its context, so
+// rate limiting and authorize know who is calling. API keys are read from
+// X-API-Key or an Authorization bearer token; other bearer tokens are user
+// access tokens. Credentials that do not authenticate are only rejected by
+// authorize, as routes without authentication ignore them.
+func (h *HTTPHandler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey, token := readCredentials(r)
+		switch {
+		case apiKey != "":
+			found, err := h.apiKeyService.Authenticate(apiKey, time.Now().UTC(), ctx)
+			if err != nil {
+				ctx = context.WithValue(ctx, authErrorContextKey{}, authError{err: err, unauthenticated: domain.ErrInvalidAPIKey})
+			} else {
+				ctx = context.WithValue(ctx, apiKeyContextKey{}, found)
+			}
+		case token != "":
+			user, err := h.userService.Authenticate(token, time.Now().UTC())
+			if err != nil {
+				ctx = context.WithValue(ctx, authErrorContextKey{}, authError{err: err, unauthenticated: domain.ErrInvalidToken})
+			} else {
+				ctx = context.WithValue(ctx, userContextKey{}, user)
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authorize lets requests through whose API key or user grants scope, or any
+// authenticated request when scope is empty. It relies on authenticate having
+// run before. The request is scoped to the tenant of the key or user.
+func (h *HTTPHandler) authorize(scope domain.Scope, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		var allowed bool
+		var tenantID string
+		if failed, ok := ctx.Value(authErrorContextKey{}).(authError); ok {
+			h.writeAuthError(w, failed.err, failed.unauthenticated)
+			return
+		}
+		if apiKey, ok := APIKeyFromContext(ctx); ok {
+			allowed = scope == "" || apiKey.Allows(scope)
+			tenantID = apiKey.TenantID
+		} else if user, ok := UserFromContext(ctx); ok {
+			allowed = scope == "" || user.Role.Allows(scope)
+			tenantID = user.TenantID
+		} else {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			h.writeError(w, http.StatusUnauthorized, "api key or access token required")
+			return
+		}
+		if header := r.Header.Get(TenantHeader); header != "" && header != tenantID {
+			h.writeError(w, http.StatusForbidden, "the credentials belong to another tenant")
+			return
+		}
+		if !allowed {
+			h.writeError(w, http.StatusForbidden, "missing permission "+string(scope))
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(domain.WithTenant(ctx, tenantID)))
+	})
+}
+
+// writeAuthError answers 401 for the unauthenticated error and 500 otherwise.
+func (h *HTTPHandler) writeAuthError(w http.ResponseWriter, err error, unauthenticated error) {
+	if errors.Is(err, unauthenticated) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		h.writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	h.writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+// readCredentials returns the API key or the access token of the request.
+func readCredentials(r *http.Request) (apiKey string, token string) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key, ""
+	}
+	scheme, value, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", ""
+	}
+	value = strings.TrimSpace(value)
+	if service.IsAPIKey(value) {
+		return value, ""
+	}
+	return "", value
+}
@@ -0,0 +1,594 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/internal/logging"
+	"github.com/iamtbay/is-management/internal/service"
+)
+
+type mockAPIKeyRepo struct {
+	fakeKeys []domain.APIKey
+	lookups  int
+}
+
+func (m *mockAPIKeyRepo) Save(apiKey *domain.APIKey, ctx context.Context) error {
+	apiKey.TenantID = domain.TenantFromContext(ctx)
+	m.fakeKeys = append(m.fakeKeys, *apiKey)
+	return nil
+}
+
+func (m *mockAPIKeyRepo) FindAll(ctx context.Context) ([]domain.APIKey, error) {
+	var keys []domain.APIKey
+	for _, apiKey := range m.fakeKeys {
+		if apiKey.TenantID == domain.TenantFromContext(ctx) {
+			keys = append(keys, apiKey)
+		}
+	}
+	return keys, nil
+}
+
+func (m *mockAPIKeyRepo) FindByHash(hash string, ctx context.Context) (*domain.APIKey, error) {
+	m.lookups++
+	for i := range m.fakeKeys {
+		if m.fakeKeys[i].Hash == hash {
+			apiKey := m.fakeKeys[i]
+			return &apiKey, nil
+		}
+	}
+	return nil, domain.ErrInvalidAPIKey
+}
+
+func (m *mockAPIKeyRepo) Revoke(id string, revokedAt time.Time, ctx context.Context) error {
+	for i := range m.fakeKeys {
+		if m.fakeKeys[i].ID == id {
+			m.fakeKeys[i].RevokedAt = &revokedAt
+		}
+	}
+	return nil
+}
+
+func (m *mockAPIKeyRepo) UpdateLastUsed(id string, lastUsedAt time.Time, ctx context.Context) error {
+	return nil
+}
+
+type mockUserRepo struct {
+	fakeUsers []domain.User
+}
+
+func (m *mockUserRepo) Save(user *domain.User, ctx context.Context) error {
+	user.TenantID = domain.TenantFromContext(ctx)
+	m.fakeUsers = append(m.fakeUsers, *user)
+	return nil
+}
+
+func (m *mockUserRepo) FindAll(ctx context.Context) ([]domain.User, error) {
+	return m.fakeUsers, nil
+}
+
+func (m *mockUserRepo) FindByID(id string, ctx context.Context) (*domain.User, error) {
+	for i := range m.fakeUsers {
+		if m.fakeUsers[i].ID == id && m.fakeUsers[i].TenantID == domain.TenantFromContext(ctx) {
+			user := m.fakeUsers[i]
+			return &user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (m *mockUserRepo) FindByEmail(email string, ctx context.Context) (*domain.User, error) {
+	for i := range m.fakeUsers {
+		if m.fakeUsers[i].Email == email {
+			user := m.fakeUsers[i]
+			return &user, nil
+		}
+	}
+	return nil, domain.ErrInvalidCredentials
+}
+
+func (m *mockUserRepo) UpdateRole(id string, role domain.Role, ctx context.Context) error {
+	for i := range m.fakeUsers {
+		if m.fakeUsers[i].ID == id {
+			m.fakeUsers[i].Role = role
+			return nil
+		}
+	}
+	return errors.New("user not found")
+}
+
+// newAccessToken creates a user of the tenant with the role and returns an
+// access token for them.
+func newAccessToken(t *testing.T, users *service.UserService, tenantID string, email string, role domain.Role) string {
+	t.Helper()
+	ctx := domain.WithTenant(context.Background(), tenantID)
+	if err := users.CreateUser(&domain.User{Email: email, Name: "Test", Role: role}, "correct horse", ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	pair, err := users.Login(email, "correct horse", time.Now().UTC(), ctx)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	return pair.AccessToken
+}
+
+// newAPIKey creates a key of the tenant with the scopes and returns it.
+func newAPIKey(t *testing.T, keys *service.APIKeyService, tenantID string, scopes ...domain.Scope) string {
+	t.Helper()
+	key, err := keys.CreateKey(&domain.APIKey{Name: "test", Scopes: scopes}, domain.WithTenant(context.Background(), tenantID))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	return key
+}
+
+// serve answers the request with handler.
+func serve(handler http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+	return rec
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("OK"))
+})
+
+// TESTS
+func TestRateLimit_AnswersTooManyRequests(t *testing.T) {
+	handler := NewHTTPHandler(Services{}, false)
+	limited := handler.rateLimit(RateLimitOptions{Default: service.RateLimit{Requests: 2, Per: time.Hour}}, okHandler)
+
+	for _, remaining := range []string{"1", "0"} {
+		rec := serve(limited, httptest.NewRequest(http.MethodGet, "/products", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %v", rec.Code)
+		}
+		header := rec.Header()
+		if header.Get("RateLimit-Limit") != "2" || header.Get("RateLimit-Remaining") != remaining || header.Get("RateLimit-Policy") != "2;w=3600" {
+			t.Errorf("expected limit 2 with %v remaining, got %v", remaining, header)
+		}
+		if header.Get("RateLimit-Reset") == "" || header.Get("Retry-After") != "" {
+			t.Errorf("expected a reset and no Retry-After, got %v", header)
+		}
+	}
+
+	rec := serve(limited, httptest.NewRequest(http.MethodGet, "/products", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status 429, got %v", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "1800" || rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("expected Retry-After 1800 and nothing remaining, got %v", rec.Header())
+	}
+	if !strings.Contains(rec.Body.String(), "rate limit exceeded, retry in 1800 seconds") {
+		t.Errorf("expected a rate limit error, got %v", rec.Body.String())
+	}
+
+	other := httptest.NewRequest(http.MethodGet, "/products", nil)
+	other.RemoteAddr = "192.0.2.2:1234"
+	if rec := serve(limited, other); rec.Code != http.StatusOK {
+		t.Errorf("expected another client to have its own bucket, got %v", rec.Code)
+	}
+}
+
+func TestRateLimit_RouteLimits(t *testing.T) {
+	handler := NewHTTPHandler(Services{}, false)
+	limited := handler.rateLimit(RateLimitOptions{Routes: map[string]service.RateLimit{"POST /orders": {Requests: 1, Per: time.Minute}}}, okHandler)
+
+	if rec := serve(limited, httptest.NewRequest(http.MethodPost, "/orders", nil)); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %v", rec.Code)
+	}
+	if rec := serve(limited, httptest.NewRequest(http.MethodPost, "/orders", nil)); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the route limit to apply, got %v", rec.Code)
+	}
+	rec := serve(limited, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("expected other routes to be unlimited, got %v with %v", rec.Code, rec.Header())
+	}
+}
+
+func TestRateLimitClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		prepare    func(r *http.Request) *http.Request
+		want       string
+	}{
+		{name: "ip", want: "ip:192.0.2.1"},
+		{
+			name:       "trusted proxy",
+			trustProxy: true,
+			prepare: func(r *http.Request) *http.Request {
+				r.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+				return r
+			},
+			want: "ip:198.51.100.7",
+		},
+		{
+			name: "untrusted proxy",
+			prepare: func(r *http.Request) *http.Request {
+				r.Header.Set("X-Forwarded-For", "198.51.100.7")
+				return r
+			},
+			want: "ip:192.0.2.1",
+		},
+		{
+			name: "unauthenticated key",
+			prepare: func(r *http.Request) *http.Request {
+				r.Header.Set("X-API-Key", "ism_made-up")
+				return r
+			},
+			want: "ip:192.0.2.1",
+		},
+		{
+			name: "api key",
+			prepare: func(r *http.Request) *http.Request {
+				return r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, &domain.APIKey{ID: "key-1"}))
+			},
+			want: "key:key-1",
+		},
+		{
+			name: "user",
+			prepare: func(r *http.Request) *http.Request {
+				return r.WithContext(context.WithValue(r.Context(), userContextKey{}, &domain.User{ID: "user-1"}))
+			},
+			want: "user:user-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/products", nil)
+			if tt.prepare != nil {
+				r = tt.prepare(r)
+			}
+			if got := rateLimitClient(r, tt.trustProxy); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRouter_RateLimitsByAuthenticatedClient(t *testing.T) {
+	apiKeys := service.NewAPIKeyService(&mockAPIKeyRepo{})
+	handler := NewHTTPHandler(Services{APIKeys: apiKeys}, false)
+	router := NewRouter(handler, AuthOptions{Enabled: true}, RateLimitOptions{Default: service.RateLimit{Requests: 1, Per: time.Minute}})
+	acme := newAPIKey(t, apiKeys, "acme", domain.ScopeAdmin)
+	globex := newAPIKey(t, apiKeys, "globex", domain.ScopeAdmin)
+
+	request := func(key string) int {
+		r := httptest.NewRequest(http.MethodGet, "/v1/api-keys", nil)
+		r.Header.Set("X-API-Key", key)
+		return serve(router, r).Code
+	}
+	if code := request(acme); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %v", code)
+	}
+	if code := request(globex); code != http.StatusOK {
+		t.Errorf("expected another key from the same IP to have its own bucket, got %v", code)
+	}
+	if code := request(acme); code != http.StatusTooManyRequests {
+		t.Errorf("expected the key to be limited, got %v", code)
+	}
+
+	if code := request("ism_made-up-1"); code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %v", code)
+	}
+	if code := request("ism_made-up-2"); code != http.StatusTooManyRequests {
+		t.Errorf("expected made up keys to share the IP's bucket, got %v", code)
+	}
+}
+
+func TestRouter_LimitsIPBeforeAuthenticating(t *testing.T) {
+	mockKRepo := &mockAPIKeyRepo{}
+	handler := NewHTTPHandler(Services{APIKeys: service.NewAPIKeyService(mockKRepo)}, false)
+	router := NewRouter(handler, AuthOptions{Enabled: true}, RateLimitOptions{PerIP: service.RateLimit{Requests: 1, Per: time.Minute}})
+
+	request := func(key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/v1/api-keys", nil)
+		r.Header.Set("X-API-Key", key)
+		return serve(router, r)
+	}
+	if rec := request("ism_made-up-1"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %v", rec.Code)
+	}
+	rec := request("ism_made-up-2")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("expected status 429 with Retry-After 60, got %v with %v", rec.Code, rec.Header())
+	}
+	if mockKRepo.lookups != 1 {
+		t.Errorf("expected the limited request not to look up its key, got %v lookups", mockKRepo.lookups)
+	}
+}
+
+func TestAuthorize_APIKeys(t *testing.T) {
+	apiKeys := service.NewAPIKeyService(&mockAPIKeyRepo{})
+	router := NewRouter(NewHTTPHandler(Services{APIKeys: apiKeys}, false), AuthOptions{Enabled: true}, RateLimitOptions{})
+	admin := newAPIKey(t, apiKeys, "acme", domain.ScopeAdmin)
+	reader := newAPIKey(t, apiKeys, "acme", domain.ScopeProductsRead)
+	revoked := newAPIKey(t, apiKeys, "acme", domain.ScopeAdmin)
+	keys, _ := apiKeys.FindAll(domain.WithTenant(context.Background(), "acme"))
+	if err := apiKeys.Revoke(keys[2].ID, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		headers map[string]string
+		status  int
+		message string
+	}{
+		{name: "no credentials", method: http.MethodGet, target: "/v1/api-keys", status: http.StatusUnauthorized, message: "api key or access token required"},
+		{name: "invalid key", method: http.MethodGet, target: "/v1/api-keys", headers: map[string]string{"X-API-Key": "ism_made-up"}, status: http.StatusUnauthorized},
+		{name: "revoked key", method: http.MethodGet, target: "/v1/api-keys", headers: map[string]string{"X-API-Key": revoked}, status: http.StatusUnauthorized},
+		{name: "missing scope", method: http.MethodPost, target: "/v1/products", headers: map[string]string{"X-API-Key": reader}, status: http.StatusForbidden, message: "missing permission products:write"},
+		{name: "other tenant", method: http.MethodGet, target: "/v1/api-keys", headers: map[string]string{"X-API-Key": admin, TenantHeader: "globex"}, status: http.StatusForbidden, message: "the credentials belong to another tenant"},
+		{name: "same tenant", method: http.MethodGet, target: "/v1/api-keys", headers: map[string]string{"X-API-Key": admin, TenantHeader: "acme"}, status: http.StatusOK},
+		{name: "bearer key", method: http.MethodGet, target: "/v1/api-keys", headers: map[string]string{"Authorization": "Bearer " + admin}, status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, nil)
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+			rec := serve(router, r)
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %v, got %v (%v)", tt.status, rec.Code, rec.Body.String())
+			}
+			if tt.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("expected a WWW-Authenticate challenge, got %v", rec.Header())
+			}
+			if !strings.Contains(rec.Body.String(), tt.message) {
+				t.Errorf("expected %q, got %v", tt.message, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthorize_ScopesRequestToKeyTenant(t *testing.T) {
+	apiKeys := service.NewAPIKeyService(&mockAPIKeyRepo{})
+	handler := NewHTTPHandler(Services{APIKeys: apiKeys}, false)
+	acme := newAPIKey(t, apiKeys, "acme", domain.ScopeProductsRead)
+
+	var tenantID string
+	var apiKey *domain.APIKey
+	authorized := handler.authenticate(handler.authorize(domain.ScopeProductsRead, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID = domain.TenantFromContext(r.Context())
+		apiKey, _ = APIKeyFromContext(r.Context())
+	})))
+	r := httptest.NewRequest(http.MethodGet, "/products", nil)
+	r.Header.Set("X-API-Key", acme)
+	if rec := serve(authorized, r); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %v", rec.Code)
+	}
+	if tenantID != "acme" || apiKey == nil || apiKey.TenantID != "acme" {
+		t.Errorf("expected the request to act as acme's key, got tenant %v and key %+v", tenantID, apiKey)
+	}
+}
+
+func TestRouter_HealthAndSwaggerAuthentication(t *testing.T) {
+	apiKeys := service.NewAPIKeyService(&mockAPIKeyRepo{})
+	key := newAPIKey(t, apiKeys, "acme", domain.ScopeProductsRead)
+
+	tests := []struct {
+		name   string
+		auth   AuthOptions
+		target string
+		key    string
+		status int
+	}{
+		{name: "private health", auth: AuthOptions{Enabled: true}, target: "/health", status: http.StatusUnauthorized},
+		{name: "private health with key", auth: AuthOptions{Enabled: true}, target: "/health", key: key, status: http.StatusOK},
+		{name: "public health", auth: AuthOptions{Enabled: true, PublicHealth: true}, target: "/health", status: http.StatusOK},
+		{name: "private swagger", auth: AuthOptions{Enabled: true}, target: "/swagger/index.html", status: http.StatusUnauthorized},
+		{name: "private swagger with key", auth: AuthOptions{Enabled: true}, target: "/swagger/index.html", key: key, status: http.StatusOK},
+		{name: "public swagger", auth: AuthOptions{Enabled: true, PublicSwagger: true}, target: "/swagger/index.html", status: http.StatusOK},
+		{name: "auth disabled", target: "/health", status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter(NewHTTPHandler(Services{APIKeys: apiKeys}, false), tt.auth, RateLimitOptions{})
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.key != "" {
+				r.Header.Set("X-API-Key", tt.key)
+			}
+			if rec := serve(router, r); rec.Code != tt.status {
+				t.Errorf("expected status %v, got %v", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestAuthorize_UserRoles(t *testing.T) {
+	users := service.NewUserService(&mockUserRepo{}, []byte("test-secret"), 15*time.Minute, 24*time.Hour)
+	router := NewRouter(NewHTTPHandler(Services{Users: users}, false), AuthOptions{Enabled: true}, RateLimitOptions{})
+	viewer := newAccessToken(t, users, "acme", "viewer@example.com", domain.RoleViewer)
+	admin := newAccessToken(t, users, "acme", "admin@example.com", domain.RoleAdmin)
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		token   string
+		tenant  string
+		status  int
+		message string
+	}{
+		{name: "invalid token", method: http.MethodGet, target: "/v1/auth/me", token: "not-a-token", status: http.StatusUnauthorized},
+		{name: "missing role", method: http.MethodPost, target: "/v1/products", token: viewer, status: http.StatusForbidden, message: "missing permission products:write"},
+		{name: "missing admin", method: http.MethodGet, target: "/v1/users", token: viewer, status: http.StatusForbidden, message: "missing permission admin"},
+		{name: "other tenant", method: http.MethodGet, target: "/v1/users", token: admin, tenant: "globex", status: http.StatusForbidden, message: "the credentials belong to another tenant"},
+		{name: "admin", method: http.MethodGet, target: "/v1/users", token: admin, status: http.StatusOK},
+		{name: "current user", method: http.MethodGet, target: "/v1/auth/me", token: viewer, status: http.StatusOK, message: `"email":"viewer@example.com"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, nil)
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+			if tt.tenant != "" {
+				r.Header.Set(TenantHeader, tt.tenant)
+			}
+			rec := serve(router, r)
+			if rec.Code != tt.status {
+				t.Fatalf("expected status %v, got %v (%v)", tt.status, rec.Code, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.message) {
+				t.Errorf("expected %q, got %v", tt.message, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthorize_PutsUserIntoContext(t *testing.T) {
+	users := service.NewUserService(&mockUserRepo{}, []byte("test-secret"), 15*time.Minute, 24*time.Hour)
+	handler := NewHTTPHandler(Services{Users: users}, false)
+	token := newAccessToken(t, users, "acme", "clerk@example.com", domain.RoleClerk)
+
+	var tenantID string
+	var user *domain.User
+	authorized := handler.authenticate(handler.authorize(domain.ScopeOrdersWrite, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID = domain.TenantFromContext(r.Context())
+		user, _ = UserFromContext(r.Context())
+	})))
+	r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	if rec := serve(authorized, r); rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %v", rec.Code)
+	}
+	if user == nil || user.ID == "" || user.Email != "clerk@example.com" || user.Role != domain.RoleClerk || user.TenantID != "acme" {
+		t.Errorf("expected the clerk in the context, got %+v", user)
+	}
+	if tenantID != "acme" {
+		t.Errorf("expected the request to be scoped to acme, got %v", tenantID)
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		honoured bool
+	}{
+		{name: "usable", incoming: "req-42", honoured: true},
+		{name: "longest usable", incoming: strings.Repeat("a", 128), honoured: true},
+		{name: "missing"},
+		{name: "oversized", incoming: strings.Repeat("a", 129)},
+		{name: "spaces", incoming: "req 42"},
+		{name: "non-ASCII", incoming: "req-ü"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = logging.RequestIDFromContext(r.Context())
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/v1/products", nil)
+			if tt.incoming != "" {
+				r.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			rec := serve(handler, r)
+
+			echoed := rec.Header().Get(RequestIDHeader)
+			if echoed != seen {
+				t.Errorf("expected the echoed ID %v to be the context ID %v", echoed, seen)
+			}
+			if tt.honoured && echoed != tt.incoming {
+				t.Errorf("expected request ID %v, got %v", tt.incoming, echoed)
+			}
+			if !tt.honoured && (echoed == tt.incoming || !validRequestID(echoed)) {
+				t.Errorf("expected a generated request ID, got %q", echoed)
+			}
+		})
+	}
+}
+
+func TestLoggerMiddleware_ClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		want       string
+	}{
+		{name: "trusted proxy", trustProxy: true, want: "client=198.51.100.7"},
+		{name: "untrusted proxy", want: "client=192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			defaultLogger := slog.Default()
+			slog.SetDefault(slog.New(slog.NewTextHandler(&out, nil)))
+			defer slog.SetDefault(defaultLogger)
+
+			r := httptest.NewRequest(http.MethodGet, "/health", nil)
+			r.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+			serve(LoggerMiddleware(tt.trustProxy, okHandler), r)
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf("expected the access log to contain %v, got %v", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestResponseRecorder(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+		bytes   int
+	}{
+		{
+			name:    "implicit status",
+			handler: func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("hello")) },
+			status:  http.StatusOK,
+			bytes:   5,
+		},
+		{
+			name: "explicit status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("hello"))
+				w.Write([]byte(" world"))
+			},
+			status: http.StatusCreated,
+			bytes:  11,
+		},
+		{
+			name: "first status wins",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				w.WriteHeader(http.StatusOK)
+			},
+			status: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &responseRecorder{ResponseWriter: httptest.NewRecorder()}
+			tt.handler(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+			if recorder.status != tt.status {
+				t.Errorf("expected status %v, got %v", tt.status, recorder.status)
+			}
+			if recorder.bytes != tt.bytes {
+				t.Errorf("expected %v bytes, got %v", tt.bytes, recorder.bytes)
+			}
+		})
+	}
+}
+
+func TestResponseRecorder_Unwrap(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := http.NewResponseController(&responseRecorder{ResponseWriter: rec}).Flush(); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !rec.Flushed {
+		t.Errorf("expected the underlying writer to be flushed")
+	}
+}
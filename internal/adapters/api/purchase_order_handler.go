@@ -0,0 +1,178 @@
+package api
+
+import (
+	"net/http"
+)
+
+// CreatePurchaseOrder godoc
+// @Summary Create a new purchase order
+// @Description Creates a draft purchase order for a supplier
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param purchaseOrder body CreatePurchaseOrderRequest true "Purchase order info"
+// @Success 201 {object} PurchaseOrderResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /purchase-orders [post]
+func (h *HTTPHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var req CreatePurchaseOrderRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := validatePurchaseOrder(&req); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	ctx := r.Context()
+	purchaseOrder := req.toDomain()
+	err := h.purchaseOrderService.CreatePurchaseOrder(purchaseOrder, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, newPurchaseOrderResponse(purchaseOrder))
+}
+
+// FindAllPurchaseOrders godoc
+// @Summary Find all purchase orders
+// @Description Finds all purchase orders
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Success 200 {object} []PurchaseOrderResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /purchase-orders [get]
+func (h *HTTPHandler) FindAllPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	purchaseOrders, err := h.purchaseOrderService.FindAll(ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newPurchaseOrderResponses(purchaseOrders))
+}
+
+// FindPurchaseOrderByID godoc
+// @Summary Find a purchase order by ID
+// @Description Finds a purchase order by ID
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Purchase order ID"
+// @Success 200 {object} PurchaseOrderResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /purchase-orders/{id} [get]
+func (h *HTTPHandler) FindPurchaseOrderByID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+
+	purchaseOrder, err := h.purchaseOrderService.FindByID(id, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newPurchaseOrderResponse(purchaseOrder))
+}
+
+// SendPurchaseOrder godoc
+// @Summary Send a purchase order
+// @Description Marks a draft purchase order as sent to the supplier
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Purchase order ID"
+// @Success 200 {object} PurchaseOrderResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /purchase-orders/{id}/send [post]
+func (h *HTTPHandler) SendPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+
+	purchaseOrder, err := h.purchaseOrderService.SendPurchaseOrder(id, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newPurchaseOrderResponse(purchaseOrder))
+}
+
+// ClosePurchaseOrder godoc
+// @Summary Close a purchase order
+// @Description Closes a purchase order, cancelling quantities that were not received
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Purchase order ID"
+// @Success 200 {object} PurchaseOrderResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /purchase-orders/{id}/close [post]
+func (h *HTTPHandler) ClosePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+
+	purchaseOrder, err := h.purchaseOrderService.ClosePurchaseOrder(id, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newPurchaseOrderResponse(purchaseOrder))
+}
+
+// ReceivePurchaseOrder godoc
+// @Summary Receive goods for a purchase order
+// @Description Books a full or partial delivery and increases product stock
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Purchase order ID"
+// @Param receipt body ReceiptRequest true "Received lines"
+// @Success 201 {object} PurchaseOrderResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /purchase-orders/{id}/receipts [post]
+func (h *HTTPHandler) ReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var req ReceiptRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := validateReceipt(&req); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	purchaseOrder, err := h.purchaseOrderService.ReceivePurchaseOrder(id, req.toDomain(), ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, newPurchaseOrderResponse(purchaseOrder))
+}
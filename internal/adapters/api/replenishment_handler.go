@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+// readReplenishmentParams reads the planning window and coverage from the query string.
+func (h *HTTPHandler) readReplenishmentParams(r *http.Request) (domain.ReplenishmentParams, error) {
+	var params domain.ReplenishmentParams
+	var err error
+	if params.WindowDays, err = h.readIntQuery(r, "window_days", 30); err != nil {
+		return params, err
+	}
+	if params.CoverageDays, err = h.readIntQuery(r, "coverage_days", 14); err != nil {
+		return params, err
+	}
+	return params, nil
+}
+
+// FindReplenishmentSuggestions godoc
+// @Summary Suggest purchase quantities
+// @Description Proposes what to reorder per product and supplier from stock, open purchase orders, reorder points and recent sales
+// @Tags replenishment
+// @Accept json
+// @Produce json
+// @Param window_days query int false "Days of order history used for sales velocity" default(30)
+// @Param coverage_days query int false "Days of sales to cover beyond the lead time" default(14)
+// @Success 200 {object} []ReplenishmentSuggestionResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /replenishment/suggestions [get]
+func (h *HTTPHandler) FindReplenishmentSuggestions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params, err := h.readReplenishmentParams(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "window_days and coverage_days must be integers")
+		return
+	}
+	suggestions, err := h.replenishmentService.Suggest(params, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newReplenishmentSuggestionResponses(suggestions))
+}
+
+// CreateReplenishmentPurchaseOrders godoc
+// @Summary Create draft purchase orders from suggestions
+// @Description Converts the current replenishment suggestions into one draft purchase order per supplier
+// @Tags replenishment
+// @Accept json
+// @Produce json
+// @Param window_days query int false "Days of order history used for sales velocity" default(30)
+// @Param coverage_days query int false "Days of sales to cover beyond the lead time" default(14)
+// @Success 201 {object} []PurchaseOrderResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /replenishment/purchase-orders [post]
+func (h *HTTPHandler) CreateReplenishmentPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params, err := h.readReplenishmentParams(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "window_days and coverage_days must be integers")
+		return
+	}
+	purchaseOrders, err := h.replenishmentService.CreateDraftPurchaseOrders(params, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, newPurchaseOrderResponses(purchaseOrders))
+}
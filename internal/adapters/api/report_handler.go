@@ -0,0 +1,191 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+// Valuation godoc
+// @Summary Inventory valuation
+// @Description Values stock on hand per product from its cost layers, using the deployment's costing method (FIFO or weighted average)
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param as_of query string false "RFC 3339 timestamp or date (end of day); defaults to now"
+// @Success 200 {object} ValuationResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /reports/valuation [get]
+func (h *HTTPHandler) Valuation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	asOf, err := h.readTimeQuery(r, "as_of", time.Now().UTC())
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "as_of must be an RFC 3339 timestamp or a date")
+		return
+	}
+	report, err := h.valuationService.Valuation(asOf, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newValuationResponse(report))
+}
+
+// ABCXYZClassification godoc
+// @Summary ABC / XYZ classification
+// @Description Classifies products A/B/C by share of order revenue and X/Y/Z by demand variability (coefficient of variation)
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Param from query string false "RFC 3339 timestamp or date (start of day); defaults to 90 days before to"
+// @Param to query string false "RFC 3339 timestamp or date (end of day); defaults to now"
+// @Param a query number false "Cumulative revenue share closing class A" default(0.8)
+// @Param b query number false "Cumulative revenue share closing class B" default(0.95)
+// @Param x query number false "Highest coefficient of variation in class X" default(0.5)
+// @Param y query number false "Highest coefficient of variation in class Y" default(1.0)
+// @Param period query string false "day or week" default(week)
+// @Param format query string false "json or csv" default(json)
+// @Success 200 {object} ClassificationResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /reports/abc-xyz [get]
+func (h *HTTPHandler) ABCXYZClassification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	params, err := h.readClassificationParams(r)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		h.writeError(w, http.StatusBadRequest, "format must be json or csv")
+		return
+	}
+	report, err := h.reportService.Classify(params, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if format != "csv" {
+		h.writeJSON(w, http.StatusOK, newClassificationResponse(report))
+		return
+	}
+
+	records := [][]string{{"product_id", "product_name", "revenue", "revenue_share", "cumulative_share", "abc", "demand_mean", "demand_std_dev", "coefficient_of_variation", "xyz", "class"}}
+	for _, p := range report.Products {
+		records = append(records, []string{
+			p.ProductID,
+			p.ProductName,
+			formatFloat(p.Revenue),
+			formatFloat(p.RevenueShare),
+			formatFloat(p.CumulativeShare),
+			p.ABC,
+			formatFloat(p.DemandMean),
+			formatFloat(p.DemandStdDev),
+			formatFloat(p.CoefficientOfVariation),
+			p.XYZ,
+			p.Class,
+		})
+	}
+	h.writeCSV(w, http.StatusOK, "abc-xyz.csv", records)
+}
+
+func (h *HTTPHandler) readClassificationParams(r *http.Request) (domain.ClassificationParams, error) {
+	params := domain.ClassificationParams{Period: domain.DemandPeriod(r.URL.Query().Get("period"))}
+	if params.Period == "" {
+		params.Period = domain.PeriodWeek
+	}
+	var err error
+	if params.To, err = h.readTimeQuery(r, "to", time.Now().UTC()); err != nil {
+		return params, errors.New("to must be an RFC 3339 timestamp or a date")
+	}
+	if params.From, err = h.readFromTimeQuery(r, "from", params.To.AddDate(0, 0, -90)); err != nil {
+		return params, errors.New("from must be an RFC 3339 timestamp or a date")
+	}
+	thresholds := []struct {
+		key      string
+		fallback float64
+		target   *float64
+	}{
+		{"a", 0.8, &params.AThreshold},
+		{"b", 0.95, &params.BThreshold},
+		{"x", 0.5, &params.XThreshold},
+		{"y", 1.0, &params.YThreshold},
+	}
+	for _, t := range thresholds {
+		if *t.target, err = h.readFloatQuery(r, t.key, t.fallback); err != nil {
+			return params, errors.New("thresholds a, b, x and y must be numbers")
+		}
+	}
+	return params, nil
+}
+
+// InventoryKPIs godoc
+// @Summary Inventory KPIs
+// @Description Computes stock turnover, days of supply and sell-through rate per product and overall, and lists dead stock
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param from query string false "RFC 3339 timestamp or date (start of day); defaults to 30 days before to"
+// @Param to query string false "RFC 3339 timestamp or date (end of day); defaults to now"
+// @Param dead_stock_days query int false "Days without sales after which stocked products count as dead stock" default(90)
+// @Success 200 {object} InventoryKPIResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /reports/inventory-kpis [get]
+func (h *HTTPHandler) InventoryKPIs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var params domain.KPIParams
+	var err error
+	if params.To, err = h.readTimeQuery(r, "to", time.Now().UTC()); err != nil {
+		h.writeError(w, http.StatusBadRequest, "to must be an RFC 3339 timestamp or a date")
+		return
+	}
+	if params.From, err = h.readFromTimeQuery(r, "from", params.To.AddDate(0, 0, -30)); err != nil {
+		h.writeError(w, http.StatusBadRequest, "from must be an RFC 3339 timestamp or a date")
+		return
+	}
+	if params.DeadStockDays, err = h.readIntQuery(r, "dead_stock_days", 90); err != nil {
+		h.writeError(w, http.StatusBadRequest, "dead_stock_days must be an integer")
+		return
+	}
+	report, err := h.reportService.InventoryKPIs(params, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newInventoryKPIResponse(report))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// NegativeStock godoc
+// @Summary Products below zero stock
+// @Description Lists products oversold below zero under the allow_negative inventory policy
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Success 200 {object} NegativeStockResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /reports/negative-stock [get]
+func (h *HTTPHandler) NegativeStock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	report, err := h.reportService.NegativeStock(ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newNegativeStockResponse(report))
+}
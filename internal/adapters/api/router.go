@@ -3,28 +3,152 @@ package api
 import (
 	"net/http"
 
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/internal/service"
+
 	_ "github.com/iamtbay/is-management/docs"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func NewRouter(handler *HTTPHandler) http.Handler {
+// AuthOptions controls authentication with API keys and user access tokens.
+// With Enabled unset every request is let through.
+type AuthOptions struct {
+	Enabled bool
+	// PublicHealth and PublicSwagger serve /health and the Swagger UI without a key.
+	PublicHealth  bool
+	PublicSwagger bool
+}
+
+// RateLimitOptions limits the API requests of each client. Routes without a
+// limit of their own share the Default one, and a zero limit lets everything
+// through.
+type RateLimitOptions struct {
+	Default service.RateLimit
+	// Routes are limits by route pattern, like "POST /orders".
+	Routes map[string]service.RateLimit
+	// PerIP limits every request of an IP before its credentials are checked.
+	PerIP service.RateLimit
+	// TrustProxy takes the client IP for rate limits and the access log from
+	// X-Forwarded-For.
+	TrustProxy bool
+}
+
+// NewRouter serves the API under /v1, next to the unversioned Swagger UI and
+// health check. Every API route but login and refresh needs an API key or a
+// user whose scopes or role grant the route's permission: reads need
+// products:read, orders need orders:write, webhooks, API keys, users and admin
+// routes need admin, and every other change needs products:write. API
+// requests are rate limited per API key or user once their credentials check
+// out, and per IP otherwise. Every request is also limited per IP before its
+// credentials are checked.
+func NewRouter(handler *HTTPHandler, auth AuthOptions, limits RateLimitOptions) http.Handler {
+	scoped := func(scope domain.Scope, next http.HandlerFunc) http.Handler {
+		if !auth.Enabled {
+			return next
+		}
+		return handler.authorize(scope, next)
+	}
+	read, write, orders, admin := domain.ScopeProductsRead, domain.ScopeProductsWrite, domain.ScopeOrdersWrite, domain.ScopeAdmin
+
 	mux := http.NewServeMux()
-	//SWAGGER
-	mux.HandleFunc("GET /swagger/", httpSwagger.WrapHandler)
 	//PRODUCT ROUTES
-	mux.HandleFunc("GET /products", handler.FindAllProducts)
-	mux.HandleFunc("POST /products", handler.CreateProduct)
-	mux.HandleFunc("GET /products/{id}", handler.FindProductByID)
-	mux.HandleFunc("PATCH /products/{id}", handler.UpdateStock)
+	mux.Handle("GET /products", scoped(read, handler.FindAllProducts))
+	mux.Handle("POST /products", scoped(write, handler.CreateProduct))
+	mux.Handle("GET /products/{id}", scoped(read, handler.FindProductByID))
+	mux.Handle("PATCH /products/{id}", scoped(write, handler.UpdateStock))
+	mux.Handle("PUT /products/{id}/reorder-settings", scoped(write, handler.UpdateReorderSettings))
+	mux.Handle("POST /products/{id}/stock-moves", scoped(write, handler.MoveStock))
+	mux.Handle("GET /products/{id}/stock", scoped(read, handler.ProductStockAt))
+	mux.Handle("GET /products/{id}/forecast", scoped(read, handler.ProductForecast))
+	mux.Handle("GET /products/{id}/lots", scoped(read, handler.FindProductLots))
+	mux.Handle("GET /products/{id}/atp", scoped(read, handler.AvailableToPromise))
+	mux.Handle("GET /products/{id}/units", scoped(read, handler.FindProductUnits))
+	mux.Handle("PUT /products/{id}/units/{unit}", scoped(write, handler.SetProductUnit))
+	//EXPECTED RECEIPT ROUTES
+	mux.Handle("POST /expected-receipts", scoped(write, handler.CreateExpectedReceipt))
+	mux.Handle("GET /expected-receipts", scoped(read, handler.FindExpectedReceipts))
+	mux.Handle("POST /expected-receipts/{id}/close", scoped(write, handler.CloseExpectedReceipt))
+	//LOT ROUTES
+	mux.Handle("GET /lots/{lot}/trace", scoped(read, handler.TraceLot))
+	mux.Handle("POST /lots/{lot}/quarantine", scoped(write, handler.QuarantineLot))
+	mux.Handle("POST /lots/{lot}/release", scoped(write, handler.ReleaseLot))
+	//SERIAL ROUTES
+	mux.Handle("GET /serials/{serial}", scoped(read, handler.SerialHistory))
+	mux.Handle("POST /serials/{serial}/return", scoped(write, handler.ReturnSerial))
+	//EVENT ROUTES
+	mux.Handle("GET /events/stream", scoped(read, handler.StreamEvents))
+	//WEBHOOK ROUTES
+	mux.Handle("POST /webhooks", scoped(admin, handler.CreateWebhook))
+	mux.Handle("GET /webhooks", scoped(admin, handler.FindAllWebhooks))
+	mux.Handle("GET /webhooks/{id}", scoped(admin, handler.FindWebhookByID))
+	mux.Handle("DELETE /webhooks/{id}", scoped(admin, handler.DeleteWebhook))
+	mux.Handle("GET /webhooks/{id}/deliveries", scoped(admin, handler.FindWebhookDeliveries))
+	mux.Handle("POST /webhook-deliveries/{id}/retry", scoped(admin, handler.RetryWebhookDelivery))
+	//STOCK HISTORY ROUTES
+	mux.Handle("GET /stock/snapshot", scoped(read, handler.StockSnapshot))
+	mux.Handle("POST /stock/snapshots", scoped(write, handler.TakeStockSnapshot))
 	//ORDER ROUTES
-	mux.HandleFunc("POST /orders", handler.CreateOrder)
-	mux.HandleFunc("GET /orders", handler.FindAllOrders)
-	mux.HandleFunc("GET /orders/{id}", handler.FindOrderByID)
+	mux.Handle("POST /orders", scoped(orders, handler.CreateOrder))
+	mux.Handle("GET /orders", scoped(read, handler.FindAllOrders))
+	mux.Handle("GET /orders/{id}", scoped(read, handler.FindOrderByID))
+	//SUPPLIER ROUTES
+	mux.Handle("POST /suppliers", scoped(write, handler.CreateSupplier))
+	mux.Handle("GET /suppliers", scoped(read, handler.FindAllSuppliers))
+	mux.Handle("GET /suppliers/{id}", scoped(read, handler.FindSupplierByID))
+	mux.Handle("POST /suppliers/{id}/products", scoped(write, handler.LinkSupplierProduct))
+	mux.Handle("GET /suppliers/{id}/products", scoped(read, handler.FindSupplierProducts))
+	//PURCHASE ORDER ROUTES
+	mux.Handle("POST /purchase-orders", scoped(write, handler.CreatePurchaseOrder))
+	mux.Handle("GET /purchase-orders", scoped(read, handler.FindAllPurchaseOrders))
+	mux.Handle("GET /purchase-orders/{id}", scoped(read, handler.FindPurchaseOrderByID))
+	mux.Handle("POST /purchase-orders/{id}/send", scoped(write, handler.SendPurchaseOrder))
+	mux.Handle("POST /purchase-orders/{id}/close", scoped(write, handler.ClosePurchaseOrder))
+	mux.Handle("POST /purchase-orders/{id}/receipts", scoped(write, handler.ReceivePurchaseOrder))
+	//REPLENISHMENT ROUTES
+	mux.Handle("GET /replenishment/suggestions", scoped(read, handler.FindReplenishmentSuggestions))
+	mux.Handle("POST /replenishment/purchase-orders", scoped(write, handler.CreateReplenishmentPurchaseOrders))
+	//REPORT ROUTES
+	mux.Handle("GET /reports/valuation", scoped(read, handler.Valuation))
+	mux.Handle("GET /reports/abc-xyz", scoped(read, handler.ABCXYZClassification))
+	mux.Handle("GET /reports/inventory-kpis", scoped(read, handler.InventoryKPIs))
+	mux.Handle("GET /reports/negative-stock", scoped(read, handler.NegativeStock))
+	//API KEY ROUTES
+	mux.Handle("POST /api-keys", scoped(admin, handler.CreateAPIKey))
+	mux.Handle("GET /api-keys", scoped(admin, handler.FindAllAPIKeys))
+	mux.Handle("DELETE /api-keys/{id}", scoped(admin, handler.RevokeAPIKey))
+	//AUTH ROUTES
+	mux.HandleFunc("POST /auth/login", handler.Login)
+	mux.HandleFunc("POST /auth/refresh", handler.RefreshToken)
+	mux.Handle("GET /auth/me", scoped("", handler.CurrentUser))
+	//USER ROUTES
+	mux.Handle("POST /users", scoped(admin, handler.CreateUser))
+	mux.Handle("GET /users", scoped(admin, handler.FindAllUsers))
+	mux.Handle("PUT /users/{id}/role", scoped(admin, handler.UpdateUserRole))
+	//ADMIN ROUTES
+	mux.Handle("GET /admin/stock-check", scoped(admin, handler.CheckStock))
+	mux.Handle("POST /admin/stock-check/repair", scoped(admin, handler.RepairStock))
 
+	root := http.NewServeMux()
+	root.Handle("/v1/", http.StripPrefix("/v1", handler.rateLimit(limits, handler.resolveTenant(mux))))
+	//SWAGGER
+	var swagger http.Handler = httpSwagger.WrapHandler
+	if auth.Enabled && !auth.PublicSwagger {
+		swagger = handler.authorize("", swagger)
+	}
+	root.Handle("GET /swagger/", swagger)
 	//HEALTH CHECK
-	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+	var health http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	})
-	return LoggerMiddleware(mux)
+	if auth.Enabled && !auth.PublicHealth {
+		health = handler.authorize("", health)
+	}
+	root.Handle("GET /health", health)
+	var routes http.Handler = root
+	if auth.Enabled {
+		routes = handler.authenticate(root)
+	}
+	routes = handler.limitIP(limits.PerIP, limits.TrustProxy, routes)
+	return RequestIDMiddleware(LoggerMiddleware(limits.TrustProxy, routes))
 }
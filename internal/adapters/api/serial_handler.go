@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+)
+
+// SerialHistory godoc
+// @Summary Find a serial's history
+// @Description Finds a serial number with its status and every time it was received, sold or returned
+// @Tags serials
+// @Accept json
+// @Produce json
+// @Param serial path string true "Serial number"
+// @Success 200 {object} SerialHistoryResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /serials/{serial} [get]
+func (h *HTTPHandler) SerialHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serialNumber := r.PathValue("serial")
+	if serialNumber == "" {
+		h.writeError(w, http.StatusBadRequest, "serial is empty")
+		return
+	}
+	history, err := h.serialService.History(serialNumber, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newSerialHistoryResponse(history))
+}
+
+// ReturnSerial godoc
+// @Summary Return a sold unit
+// @Description Takes a sold serial back into stock and records the return in its history
+// @Tags serials
+// @Accept json
+// @Produce json
+// @Param serial path string true "Serial number"
+// @Success 200 {object} SerialResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /serials/{serial}/return [post]
+func (h *HTTPHandler) ReturnSerial(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serialNumber := r.PathValue("serial")
+	if serialNumber == "" {
+		h.writeError(w, http.StatusBadRequest, "serial is empty")
+		return
+	}
+	serial, err := h.serialService.Return(serialNumber, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newSerialResponse(serial))
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+type TakeStockSnapshotResponse struct {
+	TakenAt  time.Time `json:"taken_at"`
+	Products int       `json:"products"`
+}
+
+// ProductStockAt godoc
+// @Summary Find a product's stock at a point in time
+// @Description Rebuilds a product's stock at as_of from the recorded stock history
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param as_of query string false "RFC 3339 timestamp or date (end of day); defaults to now"
+// @Success 200 {object} StockLevelResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{id}/stock [get]
+func (h *HTTPHandler) ProductStockAt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	asOf, err := h.readTimeQuery(r, "as_of", time.Now().UTC())
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "as_of must be an RFC 3339 timestamp or a date")
+		return
+	}
+	level, err := h.stockHistoryService.StockAt(id, asOf, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newStockLevelResponse(level))
+}
+
+// StockSnapshot godoc
+// @Summary Find the stock of all products at a point in time
+// @Description Rebuilds every product's stock at as_of from the recorded stock history
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param as_of query string false "RFC 3339 timestamp or date (end of day); defaults to now"
+// @Success 200 {object} []StockLevelResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /stock/snapshot [get]
+func (h *HTTPHandler) StockSnapshot(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	asOf, err := h.readTimeQuery(r, "as_of", time.Now().UTC())
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "as_of must be an RFC 3339 timestamp or a date")
+		return
+	}
+	levels, err := h.stockHistoryService.StockAtAll(asOf, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newStockLevelResponses(levels))
+}
+
+// TakeStockSnapshot godoc
+// @Summary Take a stock snapshot
+// @Description Stores the current stock of the tenant's products to speed up later as-of queries
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Success 201 {object} TakeStockSnapshotResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /stock/snapshots [post]
+func (h *HTTPHandler) TakeStockSnapshot(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	takenAt, count, err := h.stockHistoryService.TakeSnapshot(ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, &TakeStockSnapshotResponse{TakenAt: takenAt, Products: count})
+}
+
+// CheckStock godoc
+// @Summary Check stock consistency
+// @Description Compares every product's stock with the stock explained by its baseline count, receipts, adjustments and orders (dry run)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} StockCheckResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/stock-check [get]
+func (h *HTTPHandler) CheckStock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	report, err := h.stockCheckService.Check(false, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newStockCheckResponse(report))
+}
+
+// RepairStock godoc
+// @Summary Repair stock consistency
+// @Description Runs the stock consistency check and books each difference as a reconciliation movement
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Success 200 {object} StockCheckResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /admin/stock-check/repair [post]
+func (h *HTTPHandler) RepairStock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	report, err := h.stockCheckService.Check(true, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newStockCheckResponse(report))
+}
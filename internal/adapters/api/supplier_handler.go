@@ -0,0 +1,151 @@
+package api
+
+import (
+	"net/http"
+)
+
+// CreateSupplier godoc
+// @Summary Create a new supplier
+// @Description Adds a new supplier
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param supplier body CreateSupplierRequest true "Supplier Info"
+// @Success 201 {object} SupplierResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /suppliers [post]
+func (h *HTTPHandler) CreateSupplier(w http.ResponseWriter, r *http.Request) {
+	var req CreateSupplierRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := validateSupplier(&req); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	ctx := r.Context()
+	supplier := req.toDomain()
+	err := h.supplierService.CreateSupplier(supplier, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, newSupplierResponse(supplier))
+}
+
+// FindAllSuppliers godoc
+// @Summary Find all suppliers
+// @Description Finds all suppliers
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Success 200 {object} []SupplierResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /suppliers [get]
+func (h *HTTPHandler) FindAllSuppliers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	suppliers, err := h.supplierService.FindAll(ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newSupplierResponses(suppliers))
+}
+
+// FindSupplierByID godoc
+// @Summary Find a supplier by ID
+// @Description Finds a supplier by ID
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Success 200 {object} SupplierResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /suppliers/{id} [get]
+func (h *HTTPHandler) FindSupplierByID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+
+	supplier, err := h.supplierService.FindByID(id, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newSupplierResponse(supplier))
+}
+
+// LinkSupplierProduct godoc
+// @Summary Link a product to a supplier
+// @Description Sets the unit cost and lead time at which the supplier delivers a product
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Param link body LinkSupplierProductRequest true "Supplier product info"
+// @Success 200 {object} SupplierProductResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /suppliers/{id}/products [post]
+func (h *HTTPHandler) LinkSupplierProduct(w http.ResponseWriter, r *http.Request) {
+	var req LinkSupplierProductRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := validateSupplierProduct(&req); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	link := req.toDomain(id)
+	err := h.supplierService.LinkProduct(link, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newSupplierProductResponse(link))
+}
+
+// FindSupplierProducts godoc
+// @Summary Find the products of a supplier
+// @Description Finds the products a supplier delivers with their cost and lead time
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Success 200 {object} []SupplierProductResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /suppliers/{id}/products [get]
+func (h *HTTPHandler) FindSupplierProducts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+
+	links, err := h.supplierService.FindProducts(id, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newSupplierProductResponses(links))
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+type SetProductUnitRequest struct {
+	Factor int `json:"factor"`
+}
+
+// SetProductUnit godoc
+// @Summary Define a product unit of measure
+// @Description Sets how many base units (each) a pack, case or pallet of the product holds. The units are part of the product, so the change moves it to a new version
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param unit path string true "each, pack, case or pallet"
+// @Param unit_factor body SetProductUnitRequest true "Base units per unit"
+// @Param If-Match header string false "ETag of the product version being changed"
+// @Success 200 {object} ProductUnitResponse
+// @Header 200 {string} ETag "New product version"
+// @Failure 400 {object} string
+// @Failure 412 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Failure 428 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{id}/units/{unit} [put]
+func (h *HTTPHandler) SetProductUnit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var req SetProductUnitRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	unit := domain.ProductUnit{
+		ProductID: id,
+		Unit:      domain.UnitOfMeasure(r.PathValue("unit")),
+		Factor:    req.Factor,
+	}
+	if errs := validateProductUnit(&unit); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	version, ok := h.readIfMatch(w, r)
+	if !ok {
+		return
+	}
+	product, err := h.productService.SetUnit(&unit, version, ctx)
+	if err != nil {
+		h.writeProductError(w, err)
+		return
+	}
+	w.Header().Set("ETag", productETag(product.Version))
+	h.writeJSON(w, http.StatusOK, newProductUnitResponse(&unit))
+}
+
+// FindProductUnits godoc
+// @Summary Find a product's units of measure
+// @Description Lists the units the product can be ordered and received in, with their base unit factors
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} []ProductUnitResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{id}/units [get]
+func (h *HTTPHandler) FindProductUnits(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	units, err := h.productService.FindUnits(id, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newProductUnitResponses(units))
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+// Login godoc
+// @Summary Log in
+// @Description Checks a user's email and password and issues an access token for the Authorization bearer header and a refresh token for POST /auth/refresh
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param login body LoginRequest true "Credentials"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Router /auth/login [post]
+func (h *HTTPHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := validateLogin(&req); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	ctx := r.Context()
+	pair, err := h.userService.Login(req.Email, req.Password, time.Now().UTC(), ctx)
+	if err != nil {
+		h.writeAuthError(w, err, domain.ErrInvalidCredentials)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newTokenResponse(pair))
+}
+
+// RefreshToken godoc
+// @Summary Refresh tokens
+// @Description Exchanges a refresh token for a new access and refresh token, with the user's current role
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param refresh body RefreshRequest true "Refresh token"
+// @Success 200 {object} TokenResponse
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Router /auth/refresh [post]
+func (h *HTTPHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := validateRefresh(&req); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	ctx := r.Context()
+	pair, err := h.userService.Refresh(req.RefreshToken, time.Now().UTC(), ctx)
+	if err != nil {
+		h.writeAuthError(w, err, domain.ErrInvalidToken)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newTokenResponse(pair))
+}
+
+// CurrentUser godoc
+// @Summary Find the logged in user
+// @Description Returns the user of the access token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /auth/me [get]
+func (h *HTTPHandler) CurrentUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	acting, ok := UserFromContext(ctx)
+	if !ok {
+		h.writeError(w, http.StatusUnauthorized, "the request was not made by a logged in user")
+		return
+	}
+	user, err := h.userService.FindByID(acting.ID, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newUserResponse(user))
+}
+
+// CreateUser godoc
+// @Summary Create a user
+// @Description Creates a staff login. Roles: viewer reads, clerk also books orders, manager also changes products, stock, suppliers and purchase orders, admin does everything.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body CreateUserRequest true "User"
+// @Success 201 {object} UserResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /users [post]
+func (h *HTTPHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req CreateUserRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := validateUser(&req); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	ctx := r.Context()
+	user := req.toDomain()
+	if err := h.userService.CreateUser(user, req.Password, ctx); err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, newUserResponse(user))
+}
+
+// FindAllUsers godoc
+// @Summary Find all users
+// @Description Lists the users
+// @Tags users
+// @Accept json
+// @Produce json
+// @Success 200 {object} []UserResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /users [get]
+func (h *HTTPHandler) FindAllUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	users, err := h.userService.FindAll(ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newUserResponses(users))
+}
+
+// UpdateUserRole godoc
+// @Summary Change a user's role
+// @Description Changes a user's role. Access tokens already issued keep the old role until they expire; refreshed tokens get the new one.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param role body UpdateUserRoleRequest true "Role"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /users/{id}/role [put]
+func (h *HTTPHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	var req UpdateUserRoleRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := validateUserRole(&req); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	if acting, ok := UserFromContext(ctx); ok && acting.ID == id && req.Role != domain.RoleAdmin {
+		h.writeError(w, http.StatusForbidden, "admins cannot remove their own admin role")
+		return
+	}
+	user, err := h.userService.UpdateRole(id, req.Role, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newUserResponse(user))
+}
@@ -0,0 +1,359 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+// FieldError describes why one request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ValidationErrorResponse struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Validation error codes.
+const (
+	codeRequired     = "required"
+	codeTooLong      = "too_long"
+	codeUUID         = "uuid"
+	codeMin          = "min"
+	codeOneOf        = "one_of"
+	codeInvalid      = "invalid"
+	codeUnknownField = "unknown_field"
+	codeType         = "type"
+)
+
+// validator collects field errors so a request reports all of its problems at once.
+type validator struct {
+	errors []FieldError
+}
+
+func (v *validator) valid() bool {
+	return len(v.errors) == 0
+}
+
+func (v *validator) add(field string, code string, message string) {
+	v.errors = append(v.errors, FieldError{Field: field, Code: code, Message: message})
+}
+
+func (v *validator) check(ok bool, field string, code string, message string) {
+	if !ok {
+		v.add(field, code, message)
+	}
+}
+
+func (v *validator) required(field string, value string) {
+	v.check(strings.TrimSpace(value) != "", field, codeRequired, field+" is required")
+}
+
+func (v *validator) maxLength(field string, value string, max int) {
+	v.check(len(value) <= max, field, codeTooLong, field+" must be at most "+strconv.Itoa(max)+" characters")
+}
+
+// uuid checks the format of an optional ID.
+func (v *validator) uuid(field string, value string) {
+	if value == "" {
+		return
+	}
+	_, err := uuid.Parse(value)
+	v.check(err == nil, field, codeUUID, field+" must be a UUID")
+}
+
+func (v *validator) requiredUUID(field string, value string) {
+	if value == "" {
+		v.required(field, value)
+		return
+	}
+	v.uuid(field, value)
+}
+
+func (v *validator) min(field string, value int, min int) {
+	v.check(value >= min, field, codeMin, field+" must be at least "+strconv.Itoa(min))
+}
+
+func (v *validator) minFloat(field string, value float64, min float64) {
+	v.check(value >= min, field, codeMin, field+" must be at least "+strconv.FormatFloat(min, 'f', -1, 64))
+}
+
+func (v *validator) positive(field string, value float64) {
+	v.check(value > 0, field, codeMin, field+" must be greater than 0")
+}
+
+func (v *validator) oneOf(field string, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add(field, codeOneOf, field+" must be one of "+strings.Join(allowed, ", "))
+}
+
+// writeValidationErrors answers 422 with the collected field errors.
+func (h *HTTPHandler) writeValidationErrors(w http.ResponseWriter, errs []FieldError) {
+	h.writeJSON(w, http.StatusUnprocessableEntity, &ValidationErrorResponse{Errors: errs})
+}
+
+// decodeJSON reads the request body into data, rejecting unknown fields and
+// values of the wrong type with 422 and malformed JSON with 400. It reports
+// whether the handler can go on.
+func (h *HTTPHandler) decodeJSON(w http.ResponseWriter, r *http.Request, data interface{}) bool {
+	err := h.readJSON(w, r, data)
+	if err == nil {
+		return true
+	}
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		h.writeValidationErrors(w, []FieldError{{Field: typeErr.Field, Code: codeType, Message: typeErr.Field + " must be " + typeErr.Type.String()}})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		h.writeValidationErrors(w, []FieldError{{Field: field, Code: codeUnknownField, Message: field + " is not a known field"}})
+	default:
+		h.writeError(w, http.StatusBadRequest, "Invalid JSON format or body too large")
+	}
+	return false
+}
+
+// readPathID returns the ID path parameter, answering 422 when it is not a UUID.
+func (h *HTTPHandler) readPathID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := r.PathValue("id")
+	var v validator
+	v.requiredUUID("id", id)
+	if !v.valid() {
+		h.writeValidationErrors(w, v.errors)
+		return "", false
+	}
+	return id, true
+}
+
+const (
+	maxNameLength  = 200
+	maxCodeLength  = 100
+	maxEmailLength = 254
+)
+
+var (
+	inventoryPolicies = []string{string(domain.PolicyTracked), string(domain.PolicyUntracked), string(domain.PolicyAllowNegative)}
+	stockBuckets      = []string{string(domain.BucketAvailable), string(domain.BucketReserved), string(domain.BucketDamaged), string(domain.BucketQuarantined)}
+	unitsOfMeasure    = []string{string(domain.UnitEach), string(domain.UnitPack), string(domain.UnitCase), string(domain.UnitPallet)}
+	eventTypes        = []string{string(domain.EventStockChanged), string(domain.EventOrderCreated)}
+	roles             = []string{string(domain.RoleViewer), string(domain.RoleClerk), string(domain.RoleManager), string(domain.RoleAdmin)}
+	scopes            = []string{string(domain.ScopeProductsRead), string(domain.ScopeProductsWrite), string(domain.ScopeOrdersWrite), string(domain.ScopeAdmin)}
+)
+
+func validateProduct(product *CreateProductRequest) []FieldError {
+	var v validator
+	v.required("name", product.Name)
+	v.maxLength("name", product.Name, maxNameLength)
+	v.minFloat("price", product.Price, 0)
+	v.minFloat("unit_cost", product.UnitCost, 0)
+	v.min("reorder_point", product.ReorderPoint, 0)
+	v.min("reorder_quantity", product.ReorderQuantity, 0)
+	if product.InventoryPolicy != "" {
+		v.oneOf("inventory_policy", string(product.InventoryPolicy), inventoryPolicies...)
+	}
+	switch {
+	case product.LotTracked || product.Serialized:
+		v.check(product.Stock == 0, "stock", codeInvalid, "lot-tracked and serialized products start with no stock and are stocked by receipts")
+	case product.InventoryPolicy == domain.PolicyUntracked:
+		v.min("stock", product.Stock, 0)
+	default:
+		v.min("stock", product.Stock, 1)
+	}
+	return v.errors
+}
+
+func validateOrder(order *CreateOrderRequest) []FieldError {
+	var v validator
+	v.requiredUUID("product_id", order.ProductID)
+	if order.Unit == "" {
+		v.min("quantity", order.Quantity, 1)
+		v.check(order.UnitQuantity == 0, "unit_quantity", codeInvalid, "unit_quantity needs a unit")
+	} else {
+		v.oneOf("unit", string(order.Unit), unitsOfMeasure...)
+		v.positive("unit_quantity", order.UnitQuantity)
+	}
+	v.maxLength("customer", order.Customer, maxNameLength)
+	for i, serial := range order.Serials {
+		field := "serials[" + strconv.Itoa(i) + "]"
+		v.required(field, serial)
+		v.maxLength(field, serial, maxCodeLength)
+	}
+	return v.errors
+}
+
+func validateUpdateStock(req *UpdateStockRequest) []FieldError {
+	var v validator
+	v.min("quantity", req.Quantity, 1)
+	return v.errors
+}
+
+func validateReorderSettings(req *UpdateReorderSettingsRequest) []FieldError {
+	var v validator
+	v.min("reorder_point", req.ReorderPoint, 0)
+	v.min("reorder_quantity", req.ReorderQuantity, 0)
+	return v.errors
+}
+
+func validateStockMove(move *StockMoveRequest) []FieldError {
+	var v validator
+	v.oneOf("from", string(move.From), stockBuckets...)
+	v.oneOf("to", string(move.To), stockBuckets...)
+	v.check(move.From != move.To, "to", codeInvalid, "to must differ from from")
+	v.min("quantity", move.Quantity, 1)
+	return v.errors
+}
+
+func validateSupplier(supplier *CreateSupplierRequest) []FieldError {
+	var v validator
+	v.required("name", supplier.Name)
+	v.maxLength("name", supplier.Name, maxNameLength)
+	v.maxLength("email", supplier.Email, maxNameLength)
+	if supplier.Email != "" {
+		v.check(strings.Contains(supplier.Email, "@"), "email", codeInvalid, "email must be an email address")
+	}
+	v.maxLength("phone", supplier.Phone, 50)
+	return v.errors
+}
+
+func validateSupplierProduct(link *LinkSupplierProductRequest) []FieldError {
+	var v validator
+	v.requiredUUID("product_id", link.ProductID)
+	v.minFloat("unit_cost", link.UnitCost, 0)
+	v.min("lead_time_days", link.LeadTimeDays, 0)
+	return v.errors
+}
+
+func validatePurchaseOrder(purchaseOrder *CreatePurchaseOrderRequest) []FieldError {
+	var v validator
+	v.requiredUUID("supplier_id", purchaseOrder.SupplierID)
+	v.check(len(purchaseOrder.Lines) > 0, "lines", codeRequired, "lines must have at least one line")
+	for i, line := range purchaseOrder.Lines {
+		prefix := "lines[" + strconv.Itoa(i) + "]."
+		v.requiredUUID(prefix+"product_id", line.ProductID)
+		v.min(prefix+"quantity", line.Quantity, 1)
+		v.minFloat(prefix+"unit_cost", line.UnitCost, 0)
+	}
+	return v.errors
+}
+
+func validateReceipt(receipt *ReceiptRequest) []FieldError {
+	var v validator
+	v.check(len(receipt.Lines) > 0, "lines", codeRequired, "lines must have at least one line")
+	for i, line := range receipt.Lines {
+		prefix := "lines[" + strconv.Itoa(i) + "]."
+		if line.LineID == "" && line.ProductID == "" {
+			v.add(prefix+"line_id", codeRequired, prefix+"line_id or "+prefix+"product_id is required")
+		}
+		v.uuid(prefix+"line_id", line.LineID)
+		v.uuid(prefix+"product_id", line.ProductID)
+		if line.Unit == "" {
+			v.min(prefix+"quantity", line.Quantity, 1)
+		} else {
+			v.oneOf(prefix+"unit", string(line.Unit), unitsOfMeasure...)
+			v.positive(prefix+"unit_quantity", line.UnitQuantity)
+		}
+		v.maxLength(prefix+"lot_number", line.LotNumber, maxCodeLength)
+		for j, serial := range line.Serials {
+			field := prefix + "serials[" + strconv.Itoa(j) + "]"
+			v.required(field, serial)
+			v.maxLength(field, serial, maxCodeLength)
+		}
+	}
+	return v.errors
+}
+
+func validateExpectedReceipt(expectedReceipt *CreateExpectedReceiptRequest) []FieldError {
+	var v validator
+	v.requiredUUID("product_id", expectedReceipt.ProductID)
+	v.uuid("purchase_order_id", expectedReceipt.PurchaseOrderID)
+	v.min("quantity", expectedReceipt.Quantity, 1)
+	v.check(!expectedReceipt.ExpectedAt.IsZero(), "expected_at", codeRequired, "expected_at is required")
+	return v.errors
+}
+
+func validateProductUnit(unit *domain.ProductUnit) []FieldError {
+	var v validator
+	v.oneOf("unit", string(unit.Unit), unitsOfMeasure...)
+	v.min("factor", unit.Factor, 1)
+	if unit.Unit == domain.UnitEach {
+		v.check(unit.Factor == 1, "factor", codeInvalid, "each is the base unit and always has factor 1")
+	}
+	return v.errors
+}
+
+func validateWebhook(webhook *CreateWebhookRequest) []FieldError {
+	var v validator
+	v.required("url", webhook.URL)
+	v.maxLength("url", webhook.URL, 2048)
+	if webhook.URL != "" {
+		target, err := url.Parse(webhook.URL)
+		v.check(err == nil && (target.Scheme == "http" || target.Scheme == "https") && target.Host != "", "url", codeInvalid, "url must be an absolute http or https URL")
+	}
+	v.check(len(webhook.EventTypes) > 0, "event_types", codeRequired, "event_types must have at least one event type")
+	for i, eventType := range webhook.EventTypes {
+		v.oneOf("event_types["+strconv.Itoa(i)+"]", string(eventType), eventTypes...)
+	}
+	if webhook.Secret != "" {
+		v.check(len(webhook.Secret) >= 16, "secret", codeInvalid, "secret must be at least 16 characters")
+		v.maxLength("secret", webhook.Secret, 256)
+	}
+	return v.errors
+}
+
+func validateAPIKey(apiKey *CreateAPIKeyRequest) []FieldError {
+	var v validator
+	v.required("name", apiKey.Name)
+	v.maxLength("name", apiKey.Name, maxNameLength)
+	v.check(len(apiKey.Scopes) > 0, "scopes", codeRequired, "scopes must have at least one scope")
+	for i, scope := range apiKey.Scopes {
+		v.oneOf("scopes["+strconv.Itoa(i)+"]", string(scope), scopes...)
+	}
+	return v.errors
+}
+
+func validateUser(user *CreateUserRequest) []FieldError {
+	var v validator
+	v.required("email", user.Email)
+	v.maxLength("email", user.Email, maxEmailLength)
+	if user.Email != "" {
+		v.check(strings.Contains(user.Email, "@"), "email", codeInvalid, "email must be a valid email address")
+	}
+	v.required("name", user.Name)
+	v.maxLength("name", user.Name, maxNameLength)
+	v.check(len(user.Password) >= 8, "password", codeMin, "password must be at least 8 characters")
+	v.maxLength("password", user.Password, 72)
+	v.oneOf("role", string(user.Role), roles...)
+	return v.errors
+}
+
+func validateUserRole(req *UpdateUserRoleRequest) []FieldError {
+	var v validator
+	v.oneOf("role", string(req.Role), roles...)
+	return v.errors
+}
+
+func validateLogin(login *LoginRequest) []FieldError {
+	var v validator
+	v.required("email", login.Email)
+	v.check(login.Password != "", "password", codeRequired, "password is required")
+	return v.errors
+}
+
+func validateRefresh(req *RefreshRequest) []FieldError {
+	var v validator
+	v.required("refresh_token", req.RefreshToken)
+	return v.errors
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TESTS
+func TestValidation_FieldErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		errors []FieldError
+	}{
+		{
+			name:   "invalid fields",
+			method: http.MethodPost,
+			target: "/v1/products",
+			body:   `{"name":" ","price":-1,"stock":0,"inventory_policy":"sometimes"}`,
+			errors: []FieldError{
+				{Field: "name", Code: codeRequired, Message: "name is required"},
+				{Field: "price", Code: codeMin, Message: "price must be at least 0"},
+				{Field: "inventory_policy", Code: codeOneOf, Message: "inventory_policy must be one of tracked, untracked, allow_negative"},
+				{Field: "stock", Code: codeMin, Message: "stock must be at least 1"},
+			},
+		},
+		{
+			name:   "unknown field",
+			method: http.MethodPost,
+			target: "/v1/products",
+			body:   `{"name":"Widget","price":10,"stock":5,"colour":"red"}`,
+			errors: []FieldError{{Field: "colour", Code: codeUnknownField, Message: "colour is not a known field"}},
+		},
+		{
+			name:   "wrong type",
+			method: http.MethodPost,
+			target: "/v1/products",
+			body:   `{"name":"Widget","price":"ten","stock":5}`,
+			errors: []FieldError{{Field: "price", Code: codeType, Message: "price must be float64"}},
+		},
+		{
+			name:   "path id",
+			method: http.MethodGet,
+			target: "/v1/products/not-a-uuid",
+			errors: []FieldError{{Field: "id", Code: codeUUID, Message: "id must be a UUID"}},
+		},
+		{
+			name:   "path id of an update",
+			method: http.MethodPatch,
+			target: "/v1/products/42",
+			body:   `{"quantity":1}`,
+			errors: []FieldError{{Field: "id", Code: codeUUID, Message: "id must be a UUID"}},
+		},
+		{
+			name:   "body id",
+			method: http.MethodPost,
+			target: "/v1/orders",
+			body:   `{"product_id":"prod-1","quantity":1}`,
+			errors: []FieldError{{Field: "product_id", Code: codeUUID, Message: "product_id must be a UUID"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, _ := newProductRouter(false)
+			rec := serve(router, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("expected status 422, got %v (%v)", rec.Code, rec.Body.String())
+			}
+			var response ValidationErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("expected a validation error body, got %v (%v)", rec.Body.String(), err)
+			}
+			if !reflect.DeepEqual(response.Errors, tt.errors) {
+				t.Errorf("expected %+v, got %+v", tt.errors, response.Errors)
+			}
+		})
+	}
+}
+
+func TestValidation_MalformedJSON(t *testing.T) {
+	router, _ := newProductRouter(false)
+	for _, body := range []string{`{"name":`, `{"name":"Widget",}`, ``} {
+		rec := serve(router, httptest.NewRequest(http.MethodPost, "/v1/products", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for %q, got %v", body, rec.Code)
+		}
+	}
+}
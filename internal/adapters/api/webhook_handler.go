@@ -0,0 +1,181 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+// CreateWebhook godoc
+// @Summary Register a webhook
+// @Description Subscribes a URL to stock.changed and/or order.created events. Deliveries are signed with HMAC-SHA256 of "timestamp.body" in X-Webhook-Signature; the secret is only returned here.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body CreateWebhookRequest true "Webhook"
+// @Success 201 {object} WebhookResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /webhooks [post]
+func (h *HTTPHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req CreateWebhookRequest
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if errs := validateWebhook(&req); len(errs) > 0 {
+		h.writeValidationErrors(w, errs)
+		return
+	}
+	ctx := r.Context()
+	webhook := req.toDomain()
+	if err := h.webhookService.CreateWebhook(webhook, ctx); err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response := newWebhookResponse(webhook)
+	response.Secret = webhook.Secret
+	h.writeJSON(w, http.StatusCreated, response)
+}
+
+// FindAllWebhooks godoc
+// @Summary Find all webhooks
+// @Description Lists the registered webhooks
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Success 200 {object} []WebhookResponse
+// @Failure 400 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /webhooks [get]
+func (h *HTTPHandler) FindAllWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	webhooks, err := h.webhookService.FindAll(ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newWebhookResponses(webhooks))
+}
+
+// FindWebhookByID godoc
+// @Summary Find a webhook by ID
+// @Description Finds a webhook by ID
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} WebhookResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /webhooks/{id} [get]
+func (h *HTTPHandler) FindWebhookByID(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	webhook, err := h.webhookService.FindByID(id, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newWebhookResponse(webhook))
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Removes a webhook together with its pending deliveries and delivery log
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /webhooks/{id} [delete]
+func (h *HTTPHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.webhookService.Delete(id, ctx); err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// FindWebhookDeliveries godoc
+// @Summary Find the delivery log of a webhook
+// @Description Lists a webhook's deliveries newest first with their attempts, last error and receiver response
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param status query string false "pending, delivered or dead"
+// @Param limit query int false "Maximum number of deliveries" default(50)
+// @Success 200 {object} []WebhookDeliveryResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /webhooks/{id}/deliveries [get]
+func (h *HTTPHandler) FindWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	status := domain.WebhookDeliveryStatus(r.URL.Query().Get("status"))
+	if status != "" && !status.Valid() {
+		h.writeError(w, http.StatusBadRequest, "status must be pending, delivered or dead")
+		return
+	}
+	limit, err := h.readIntQuery(r, "limit", 50)
+	if err != nil || limit < 1 || limit > 500 {
+		h.writeError(w, http.StatusBadRequest, "limit must be a number between 1 and 500")
+		return
+	}
+	deliveries, err := h.webhookService.FindDeliveries(id, status, limit, ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newWebhookDeliveryResponses(deliveries))
+}
+
+// RetryWebhookDelivery godoc
+// @Summary Retry a webhook delivery
+// @Description Queues a dead-lettered delivery for an immediate attempt with a fresh set of retries
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Delivery ID"
+// @Success 200 {object} WebhookDeliveryResponse
+// @Failure 400 {object} string
+// @Failure 422 {object} ValidationErrorResponse
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /webhook-deliveries/{id}/retry [post]
+func (h *HTTPHandler) RetryWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.readPathID(w, r)
+	if !ok {
+		return
+	}
+	delivery, err := h.webhookService.RetryDelivery(id, time.Now().UTC(), ctx)
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, newWebhookDeliveryResponse(delivery))
+}
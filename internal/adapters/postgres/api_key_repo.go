@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const apiKeyColumns = `id, tenant_id, name, prefix, hash, scopes, created_at, last_used_at, revoked_at`
+
+type APIKeyRepository struct {
+	conn dbConn
+}
+
+// NEW API KEY REPO
+func NewAPIKeyRepository(conn *pgxpool.Pool) *APIKeyRepository {
+	return &APIKeyRepository{conn: dbConn{pool: conn}}
+}
+
+func scanAPIKey(row pgx.Row, apiKey *domain.APIKey) error {
+	var scopes []string
+	if err := row.Scan(&apiKey.ID, &apiKey.TenantID, &apiKey.Name, &apiKey.Prefix, &apiKey.Hash, &scopes, &apiKey.CreatedAt, &apiKey.LastUsedAt, &apiKey.RevokedAt); err != nil {
+		return err
+	}
+	apiKey.Scopes = make([]domain.Scope, 0, len(scopes))
+	for _, scope := range scopes {
+		apiKey.Scopes = append(apiKey.Scopes, domain.Scope(scope))
+	}
+	return nil
+}
+
+// SAVE
+func (r *APIKeyRepository) Save(apiKey *domain.APIKey, ctx context.Context) error {
+	scopes := make([]string, 0, len(apiKey.Scopes))
+	for _, scope := range apiKey.Scopes {
+		scopes = append(scopes, string(scope))
+	}
+	apiKey.TenantID = domain.TenantFromContext(ctx)
+	query := `INSERT INTO api_keys (id, tenant_id, name, prefix, hash, scopes, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.conn.Exec(ctx, query, apiKey.ID, apiKey.TenantID, apiKey.Name, apiKey.Prefix, apiKey.Hash, scopes, apiKey.CreatedAt)
+	return err
+}
+
+// FIND ALL
+func (r *APIKeyRepository) FindAll(ctx context.Context) ([]domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE tenant_id=$1 ORDER BY created_at, id`
+	rows, err := r.conn.Query(ctx, query, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var apiKeys []domain.APIKey
+	for rows.Next() {
+		var apiKey domain.APIKey
+		if err := scanAPIKey(rows, &apiKey); err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+	return apiKeys, rows.Err()
+}
+
+// FIND BY HASH
+func (r *APIKeyRepository) FindByHash(hash string, ctx context.Context) (*domain.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE hash=$1`
+	var apiKey domain.APIKey
+	err := scanAPIKey(r.conn.QueryRow(ctx, query, hash), &apiKey)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// REVOKE
+func (r *APIKeyRepository) Revoke(id string, revokedAt time.Time, ctx context.Context) error {
+	tag, err := r.conn.Exec(ctx, `UPDATE api_keys SET revoked_at=$2 WHERE id=$1 AND tenant_id=$3 AND revoked_at IS NULL`, id, revokedAt, domain.TenantFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("api key not found or already revoked")
+	}
+	return nil
+}
+
+// UPDATE LAST USED
+func (r *APIKeyRepository) UpdateLastUsed(id string, lastUsedAt time.Time, ctx context.Context) error {
+	_, err := r.conn.Exec(ctx, `UPDATE api_keys SET last_used_at=$2 WHERE id=$1`, id, lastUsedAt)
+	return err
+}
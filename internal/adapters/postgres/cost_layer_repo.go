@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CostLayerRepository struct {
+	conn dbConn
+}
+
+// NEW COST LAYER REPO
+func NewCostLayerRepository(conn *pgxpool.Pool) *CostLayerRepository {
+	return &CostLayerRepository{conn: dbConn{pool: conn}}
+}
+
+// SAVE LAYER
+func (r *CostLayerRepository) SaveLayer(layer *domain.CostLayer, ctx context.Context) error {
+	query := `INSERT INTO cost_layers (id, product_id, receipt_line_id, quantity, remaining, unit_cost, created_at) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)`
+	_, err := r.conn.Exec(ctx, query, layer.ID, layer.ProductID, layer.ReceiptLineID, layer.Quantity, layer.Remaining, layer.UnitCost, layer.CreatedAt)
+	return err
+}
+
+// FIND OPEN LAYERS
+// The layers stay locked until the transaction in ctx ends, so concurrent
+// orders cannot plan against the same remaining quantities.
+func (r *CostLayerRepository) FindOpenLayers(productID string, ctx context.Context) ([]domain.CostLayer, error) {
+	query := `SELECT id, product_id, COALESCE(receipt_line_id, ''), quantity, remaining, unit_cost, created_at FROM cost_layers
+		WHERE product_id=$1 AND remaining > 0 ORDER BY created_at, id FOR UPDATE`
+	rows, err := r.conn.Query(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var layers []domain.CostLayer
+	for rows.Next() {
+		var layer domain.CostLayer
+		if err := rows.Scan(&layer.ID, &layer.ProductID, &layer.ReceiptLineID, &layer.Quantity, &layer.Remaining, &layer.UnitCost, &layer.CreatedAt); err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+	return layers, rows.Err()
+}
+
+// CONSUME
+func (r *CostLayerRepository) Consume(consumptions []domain.CostConsumption, ctx context.Context) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	insertQuery := `INSERT INTO cost_consumptions (id, layer_id, product_id, order_id, quantity, unit_cost, created_at) VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7)`
+	updateQuery := `UPDATE cost_layers SET remaining=remaining-$2 WHERE id=$1 AND remaining>=$2`
+	for _, c := range consumptions {
+		tag, err := tx.Exec(ctx, updateQuery, c.LayerID, c.Quantity)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return errors.New("cost layer has not enough remaining quantity")
+		}
+		_, err = tx.Exec(ctx, insertQuery, c.ID, c.LayerID, c.ProductID, c.OrderID, c.Quantity, c.UnitCost, c.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// FIND CONSUMPTIONS
+func (r *CostLayerRepository) FindConsumptions(orderID string, productID string, ctx context.Context) ([]domain.CostConsumption, error) {
+	query := `SELECT id, layer_id, product_id, COALESCE(order_id, ''), quantity, unit_cost, created_at FROM cost_consumptions
+		WHERE order_id=$1 AND product_id=$2 ORDER BY created_at, id`
+	rows, err := r.conn.Query(ctx, query, orderID, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var consumptions []domain.CostConsumption
+	for rows.Next() {
+		var c domain.CostConsumption
+		if err := rows.Scan(&c.ID, &c.LayerID, &c.ProductID, &c.OrderID, &c.Quantity, &c.UnitCost, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		consumptions = append(consumptions, c)
+	}
+	return consumptions, rows.Err()
+}
+
+// PRODUCT TOTALS
+func (r *CostLayerRepository) ProductTotals(productID string, ctx context.Context) (*domain.CostTotals, error) {
+	query := `SELECT
+			COALESCE((SELECT SUM(quantity) FROM cost_layers WHERE product_id=$1), 0),
+			COALESCE((SELECT SUM(quantity * unit_cost) FROM cost_layers WHERE product_id=$1), 0),
+			COALESCE((SELECT SUM(quantity) FROM cost_consumptions WHERE product_id=$1), 0),
+			COALESCE((SELECT SUM(quantity * unit_cost) FROM cost_consumptions WHERE product_id=$1), 0)`
+	t := domain.CostTotals{ProductID: productID}
+	err := r.conn.QueryRow(ctx, query, productID).Scan(&t.ReceivedQuantity, &t.ReceivedValue, &t.ConsumedQuantity, &t.ConsumedValue)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// TOTALS
+func (r *CostLayerRepository) Totals(asOf time.Time, ctx context.Context) ([]domain.CostTotals, error) {
+	query := `SELECT p.id, p.name,
+			COALESCE(l.quantity, 0), COALESCE(l.value, 0),
+			COALESCE(c.quantity, 0), COALESCE(c.value, 0)
+		FROM products p
+		LEFT JOIN (
+			SELECT product_id, SUM(quantity) AS quantity, SUM(quantity * unit_cost) AS value
+			FROM cost_layers WHERE created_at <= $1 GROUP BY product_id
+		) l ON l.product_id = p.id
+		LEFT JOIN (
+			SELECT product_id, SUM(quantity) AS quantity, SUM(quantity * unit_cost) AS value
+			FROM cost_consumptions WHERE created_at <= $1 GROUP BY product_id
+		) c ON c.product_id = p.id
+		WHERE p.tenant_id = $2
+		ORDER BY p.name`
+	rows, err := r.conn.Query(ctx, query, asOf, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []domain.CostTotals
+	for rows.Next() {
+		var t domain.CostTotals
+		if err := rows.Scan(&t.ProductID, &t.ProductName, &t.ReceivedQuantity, &t.ReceivedValue, &t.ConsumedQuantity, &t.ConsumedValue); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
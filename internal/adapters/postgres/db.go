@@ -3,6 +3,8 @@ package postgres
 import (
 	"context"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -14,3 +16,83 @@ func NewDB(connStr string) (*pgxpool.Pool, error) {
 
 	return pgxpool.NewWithConfig(context.Background(), config)
 }
+
+type txContextKey struct{}
+
+// querier is what the pool and a transaction have in common. Begin on a
+// transaction starts a savepoint.
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// dbConn sends the statements of the repositories through the transaction a
+// Transactor put into the context, or through the pool outside of one.
+type dbConn struct {
+	pool *pgxpool.Pool
+}
+
+func (c dbConn) querier(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txContextKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return c.pool
+}
+
+func (c dbConn) Begin(ctx context.Context) (pgx.Tx, error) {
+	return c.querier(ctx).Begin(ctx)
+}
+
+func (c dbConn) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return c.querier(ctx).Exec(ctx, sql, args...)
+}
+
+func (c dbConn) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return c.querier(ctx).Query(ctx, sql, args...)
+}
+
+func (c dbConn) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return c.querier(ctx).QueryRow(ctx, sql, args...)
+}
+
+// Transactor runs service work in one database transaction that every
+// repository of this package takes part in when called with the context the
+// work is given.
+type Transactor struct {
+	pool *pgxpool.Pool
+}
+
+// NEW TRANSACTOR
+func NewTransactor(conn *pgxpool.Pool) *Transactor {
+	return &Transactor{pool: conn}
+}
+
+// WITHIN TX
+func (t *Transactor) WithinTx(fn func(ctx context.Context) error, ctx context.Context) error {
+	return t.run(pgx.TxOptions{}, fn, ctx)
+}
+
+// WITHIN SNAPSHOT
+func (t *Transactor) WithinSnapshot(fn func(ctx context.Context) error, ctx context.Context) error {
+	return t.run(pgx.TxOptions{IsoLevel: pgx.RepeatableRead}, fn, ctx)
+}
+
+// run joins the transaction already in ctx, if any, and otherwise commits a
+// new one when fn succeeds and rolls it back when it fails.
+func (t *Transactor) run(options pgx.TxOptions, fn func(ctx context.Context) error, ctx context.Context) error {
+	if _, ok := ctx.Value(txContextKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+	tx, err := t.pool.BeginTx(ctx, options)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txContextKey{}, tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const expectedReceiptColumns = `id, product_id, COALESCE(purchase_order_id, ''), quantity, received, expected_at, created_at, closed_at`
+
+// ExpectedReceiptRepository only sees the expected receipts of the tenant in
+// the context of each call.
+type ExpectedReceiptRepository struct {
+	conn dbConn
+}
+
+// NEW EXPECTED RECEIPT REPO
+func NewExpectedReceiptRepository(conn *pgxpool.Pool) *ExpectedReceiptRepository {
+	return &ExpectedReceiptRepository{conn: dbConn{pool: conn}}
+}
+
+func scanExpectedReceipt(row pgx.Row, e *domain.ExpectedReceipt) error {
+	return row.Scan(&e.ID, &e.ProductID, &e.PurchaseOrderID, &e.Quantity, &e.Received, &e.ExpectedAt, &e.CreatedAt, &e.ClosedAt)
+}
+
+// SAVE
+func (r *ExpectedReceiptRepository) Save(expectedReceipt *domain.ExpectedReceipt, ctx context.Context) error {
+	query := `INSERT INTO expected_receipts (id, tenant_id, product_id, purchase_order_id, quantity, received, expected_at, created_at) VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8)`
+	_, err := r.conn.Exec(ctx, query, expectedReceipt.ID, domain.TenantFromContext(ctx), expectedReceipt.ProductID, expectedReceipt.PurchaseOrderID, expectedReceipt.Quantity, expectedReceipt.Received, expectedReceipt.ExpectedAt, expectedReceipt.CreatedAt)
+	return err
+}
+
+// FIND OPEN
+func (r *ExpectedReceiptRepository) FindOpen(productID string, ctx context.Context) ([]domain.ExpectedReceipt, error) {
+	query := `SELECT ` + expectedReceiptColumns + ` FROM expected_receipts
+		WHERE tenant_id=$2 AND received < quantity AND closed_at IS NULL AND ($1 = '' OR product_id=$1) ORDER BY expected_at, created_at, id`
+	rows, err := r.conn.Query(ctx, query, productID, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expectedReceipts []domain.ExpectedReceipt
+	for rows.Next() {
+		var e domain.ExpectedReceipt
+		if err := scanExpectedReceipt(rows, &e); err != nil {
+			return nil, err
+		}
+		expectedReceipts = append(expectedReceipts, e)
+	}
+	return expectedReceipts, rows.Err()
+}
+
+// RECEIVE
+// Spreads the quantity over the open expected receipts in expected date
+// order; anything beyond what was expected is ignored.
+func (r *ExpectedReceiptRepository) Receive(purchaseOrderID string, productID string, quantity int, ctx context.Context) error {
+	query := `WITH open AS (
+			SELECT id, quantity - received AS open,
+				SUM(quantity - received) OVER (ORDER BY expected_at, created_at, id) - (quantity - received) AS before
+			FROM expected_receipts
+			WHERE purchase_order_id=$1 AND product_id=$2 AND tenant_id=$4 AND received < quantity AND closed_at IS NULL
+		)
+		UPDATE expected_receipts e SET received = e.received + LEAST(o.open, $3 - o.before)
+		FROM open o WHERE e.id = o.id AND o.before < $3`
+	_, err := r.conn.Exec(ctx, query, purchaseOrderID, productID, quantity, domain.TenantFromContext(ctx))
+	return err
+}
+
+// CLOSE
+func (r *ExpectedReceiptRepository) Close(id string, closedAt time.Time, ctx context.Context) (*domain.ExpectedReceipt, error) {
+	query := `UPDATE expected_receipts SET closed_at=$2
+		WHERE id=$1 AND tenant_id=$3 AND received < quantity AND closed_at IS NULL
+		RETURNING ` + expectedReceiptColumns
+	var e domain.ExpectedReceipt
+	if err := scanExpectedReceipt(r.conn.QueryRow(ctx, query, id, closedAt, domain.TenantFromContext(ctx)), &e); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New("open expected receipt not found")
+		}
+		return nil, err
+	}
+	return &e, nil
+}
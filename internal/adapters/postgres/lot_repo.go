@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const lotColumns = `id, product_id, lot_number, manufactured_at, expires_at, quantity, remaining, received_at, quarantined`
+
+// LotRepository only sees the lots of the tenant in the context of each call.
+type LotRepository struct {
+	conn dbConn
+}
+
+// NEW LOT REPO
+func NewLotRepository(conn *pgxpool.Pool) *LotRepository {
+	return &LotRepository{conn: dbConn{pool: conn}}
+}
+
+func scanLot(row pgx.Row, lot *domain.Lot) error {
+	return row.Scan(&lot.ID, &lot.ProductID, &lot.LotNumber, &lot.ManufacturedAt, &lot.ExpiresAt, &lot.Quantity, &lot.Remaining, &lot.ReceivedAt, &lot.Quarantined)
+}
+
+// RECEIVE
+// Receiving a lot number the product already has tops that lot up and fills
+// in dates the lot did not have yet; dates that contradict the stored ones
+// are rejected.
+func (r *LotRepository) Receive(lot *domain.Lot, ctx context.Context) (*domain.Lot, error) {
+	query := `INSERT INTO lots (` + lotColumns + `, tenant_id) VALUES ($1, $2, $3, $4, $5, $6, $6, $7, FALSE, $8)
+		ON CONFLICT (product_id, lot_number) DO UPDATE SET
+			quantity=lots.quantity+EXCLUDED.quantity,
+			remaining=lots.remaining+EXCLUDED.remaining,
+			manufactured_at=COALESCE(lots.manufactured_at, EXCLUDED.manufactured_at),
+			expires_at=COALESCE(lots.expires_at, EXCLUDED.expires_at)
+		WHERE lots.tenant_id = EXCLUDED.tenant_id
+			AND (lots.manufactured_at IS NULL OR EXCLUDED.manufactured_at IS NULL OR lots.manufactured_at = EXCLUDED.manufactured_at)
+			AND (lots.expires_at IS NULL OR EXCLUDED.expires_at IS NULL OR lots.expires_at = EXCLUDED.expires_at)
+		RETURNING ` + lotColumns
+	var stored domain.Lot
+	row := r.conn.QueryRow(ctx, query, lot.ID, lot.ProductID, lot.LotNumber, lot.ManufacturedAt, lot.ExpiresAt, lot.Quantity, lot.ReceivedAt, domain.TenantFromContext(ctx))
+	if err := scanLot(row, &stored); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New("lot " + lot.LotNumber + " was received before with other dates")
+		}
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// FIND BY PRODUCT
+func (r *LotRepository) FindByProduct(productID string, ctx context.Context) ([]domain.Lot, error) {
+	query := `SELECT ` + lotColumns + ` FROM lots WHERE product_id=$1 AND tenant_id=$2 ORDER BY expires_at NULLS LAST, received_at, id`
+	rows, err := r.conn.Query(ctx, query, productID, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return collectLots(rows)
+}
+
+// FIND ALLOCATABLE
+// The lots stay locked until the transaction in ctx ends, so concurrent
+// orders cannot plan against the same remaining quantities.
+func (r *LotRepository) FindAllocatable(productID string, at time.Time, ctx context.Context) ([]domain.Lot, error) {
+	query := `SELECT ` + lotColumns + ` FROM lots
+		WHERE product_id=$1 AND tenant_id=$3 AND remaining > 0 AND NOT quarantined AND (expires_at IS NULL OR expires_at > $2)
+		ORDER BY expires_at NULLS LAST, received_at, id FOR UPDATE`
+	rows, err := r.conn.Query(ctx, query, productID, at, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return collectLots(rows)
+}
+
+// ALLOCATE
+func (r *LotRepository) Allocate(allocations []domain.LotAllocation, ctx context.Context) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	updateQuery := `UPDATE lots SET remaining=remaining-$2 WHERE id=$1 AND tenant_id=$3 AND remaining>=$2`
+	insertQuery := `INSERT INTO order_lot_allocations (order_id, lot_id, quantity) VALUES ($1, $2, $3)`
+	for _, a := range allocations {
+		tag, err := tx.Exec(ctx, updateQuery, a.LotID, a.Quantity, domain.TenantFromContext(ctx))
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return errors.New("lot has not enough remaining quantity")
+		}
+		if _, err := tx.Exec(ctx, insertQuery, a.OrderID, a.LotID, a.Quantity); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// FIND ALLOCATIONS
+func (r *LotRepository) FindAllocations(orderID string, ctx context.Context) ([]domain.LotAllocation, error) {
+	query := `SELECT a.order_id, a.lot_id, l.lot_number, a.quantity, l.expires_at FROM order_lot_allocations a
+		JOIN lots l ON l.id = a.lot_id
+		WHERE a.order_id=$1 AND l.tenant_id=$2 ORDER BY l.expires_at NULLS LAST, l.lot_number`
+	rows, err := r.conn.Query(ctx, query, orderID, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var allocations []domain.LotAllocation
+	for rows.Next() {
+		var a domain.LotAllocation
+		if err := rows.Scan(&a.OrderID, &a.LotID, &a.LotNumber, &a.Quantity, &a.ExpiresAt); err != nil {
+			return nil, err
+		}
+		allocations = append(allocations, a)
+	}
+	return allocations, rows.Err()
+}
+
+// FIND BY NUMBER
+func (r *LotRepository) FindByNumber(lotNumber string, productID string, ctx context.Context) ([]domain.Lot, error) {
+	query := `SELECT ` + lotColumns + ` FROM lots WHERE lot_number=$1 AND tenant_id=$3 AND ($2 = '' OR product_id=$2) ORDER BY product_id`
+	rows, err := r.conn.Query(ctx, query, lotNumber, productID, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return collectLots(rows)
+}
+
+// FIND ORDERS
+func (r *LotRepository) FindOrders(lotIDs []string, ctx context.Context) ([]domain.LotTraceOrder, error) {
+	query := `SELECT o.id, o.product_id, a.lot_id, o.customer, a.quantity, o.created_at FROM order_lot_allocations a
+		JOIN orders o ON o.id = a.order_id
+		WHERE a.lot_id = ANY($1) AND o.tenant_id=$2 ORDER BY o.created_at, o.id`
+	rows, err := r.conn.Query(ctx, query, lotIDs, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []domain.LotTraceOrder
+	for rows.Next() {
+		var o domain.LotTraceOrder
+		if err := rows.Scan(&o.OrderID, &o.ProductID, &o.LotID, &o.Customer, &o.Quantity, &o.OrderedAt); err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+// SET QUARANTINED
+func (r *LotRepository) SetQuarantined(lotIDs []string, quarantined bool, ctx context.Context) ([]domain.Lot, error) {
+	query := `UPDATE lots SET quarantined=$2 WHERE id = ANY($1) AND tenant_id=$3 AND quarantined <> $2 RETURNING ` + lotColumns
+	rows, err := r.conn.Query(ctx, query, lotIDs, quarantined, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return collectLots(rows)
+}
+
+func collectLots(rows pgx.Rows) ([]domain.Lot, error) {
+	defer rows.Close()
+
+	var lots []domain.Lot
+	for rows.Next() {
+		var lot domain.Lot
+		if err := scanLot(rows, &lot); err != nil {
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+	return lots, rows.Err()
+}
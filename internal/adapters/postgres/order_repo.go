@@ -2,24 +2,36 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const orderColumns = `id, tenant_id, product_id, quantity, unit, unit_quantity, total_price, customer, created_at`
+
+// OrderRepository only sees the orders of the tenant in the context of each
+// call.
 type OrderRepository struct {
-	conn *pgxpool.Pool
+	conn dbConn
 }
 
 // NEW ORDER REPO
 func NewOrderRepository(conn *pgxpool.Pool) *OrderRepository {
-	return &OrderRepository{conn: conn}
+	return &OrderRepository{conn: dbConn{pool: conn}}
+}
+
+func scanOrder(row pgx.Row, order *domain.Order) error {
+	return row.Scan(&order.ID, &order.TenantID, &order.ProductID, &order.Quantity, &order.Unit, &order.UnitQuantity, &order.TotalPrice, &order.Customer, &order.CreatedAt)
 }
 
 func (r *OrderRepository) Save(order *domain.Order, ctx context.Context) error {
-	var query = `INSERT INTO orders (id, product_id, quantity,total_price) VALUES ($1, $2, $3,$4)`
+	order.TenantID = domain.TenantFromContext(ctx)
+	var query = `INSERT INTO orders (` + orderColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
 
-	_, err := r.conn.Exec(ctx, query, order.ID, order.ProductID, order.Quantity, order.TotalPrice)
+	_, err := r.conn.Exec(ctx, query, order.ID, order.TenantID, order.ProductID, order.Quantity, order.Unit, order.UnitQuantity, order.TotalPrice, order.Customer, order.CreatedAt)
 	if err != nil {
 		return err
 	}
@@ -29,8 +41,8 @@ func (r *OrderRepository) Save(order *domain.Order, ctx context.Context) error {
 
 // ORDERS MUST DO
 func (r *OrderRepository) FindAll(ctx context.Context) ([]domain.Order, error) {
-	var query = `SELECT * FROM orders`
-	rows, err := r.conn.Query(ctx, query)
+	var query = `SELECT ` + orderColumns + ` FROM orders WHERE tenant_id = $1 ORDER BY created_at DESC`
+	rows, err := r.conn.Query(ctx, query, domain.TenantFromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -39,20 +51,109 @@ func (r *OrderRepository) FindAll(ctx context.Context) ([]domain.Order, error) {
 	var orders []domain.Order
 	for rows.Next() {
 		var order domain.Order
-		if err := rows.Scan(&order.ID, &order.ProductID, &order.Quantity, &order.TotalPrice); err != nil {
+		if err := scanOrder(rows, &order); err != nil {
 			return nil, err
 		}
 		orders = append(orders, order)
 	}
-	return orders, nil
+	return orders, rows.Err()
 }
 
 func (r *OrderRepository) FindByID(id string, ctx context.Context) (*domain.Order, error) {
-	var query = `SELECT * FROM orders WHERE id = $1`
-	row := r.conn.QueryRow(ctx, query, id)
+	var query = `SELECT ` + orderColumns + ` FROM orders WHERE id = $1 AND tenant_id = $2`
+	row := r.conn.QueryRow(ctx, query, id, domain.TenantFromContext(ctx))
 	var order domain.Order
-	if err := row.Scan(&order.ID, &order.ProductID, &order.Quantity, &order.TotalPrice); err != nil {
+	if err := scanOrder(row, &order); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New("order not found")
+		}
 		return nil, err
 	}
 	return &order, nil
 }
+
+// SALES BY PRODUCT
+func (r *OrderRepository) SalesByProduct(since time.Time, ctx context.Context) (map[string]int, error) {
+	var query = `SELECT product_id, SUM(quantity) FROM orders WHERE tenant_id = $2 AND created_at >= $1 GROUP BY product_id`
+	rows, err := r.conn.Query(ctx, query, since, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sales := make(map[string]int)
+	for rows.Next() {
+		var productID string
+		var quantity int
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, err
+		}
+		sales[productID] = quantity
+	}
+	return sales, rows.Err()
+}
+
+// DAILY SALES
+func (r *OrderRepository) DailySales(productID string, from time.Time, to time.Time, ctx context.Context) ([]domain.DailySales, error) {
+	var query = `SELECT created_at::DATE AS day, SUM(quantity), SUM(total_price) FROM orders
+		WHERE product_id = $1 AND tenant_id = $4 AND created_at >= $2 AND created_at < $3
+		GROUP BY day ORDER BY day`
+	rows, err := r.conn.Query(ctx, query, productID, from, to, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sales []domain.DailySales
+	for rows.Next() {
+		day := domain.DailySales{ProductID: productID}
+		if err := rows.Scan(&day.Day, &day.Quantity, &day.Revenue); err != nil {
+			return nil, err
+		}
+		sales = append(sales, day)
+	}
+	return sales, rows.Err()
+}
+
+// DAILY SALES ALL
+func (r *OrderRepository) DailySalesAll(from time.Time, to time.Time, ctx context.Context) ([]domain.DailySales, error) {
+	var query = `SELECT product_id, created_at::DATE AS day, SUM(quantity), SUM(total_price) FROM orders
+		WHERE tenant_id = $3 AND created_at >= $1 AND created_at < $2
+		GROUP BY product_id, day ORDER BY product_id, day`
+	rows, err := r.conn.Query(ctx, query, from, to, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sales []domain.DailySales
+	for rows.Next() {
+		var day domain.DailySales
+		if err := rows.Scan(&day.ProductID, &day.Day, &day.Quantity, &day.Revenue); err != nil {
+			return nil, err
+		}
+		sales = append(sales, day)
+	}
+	return sales, rows.Err()
+}
+
+// LAST SALE DATES
+func (r *OrderRepository) LastSaleDates(before time.Time, ctx context.Context) (map[string]time.Time, error) {
+	var query = `SELECT product_id, MAX(created_at) FROM orders WHERE tenant_id = $2 AND created_at < $1 GROUP BY product_id`
+	rows, err := r.conn.Query(ctx, query, before, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dates := make(map[string]time.Time)
+	for rows.Next() {
+		var productID string
+		var lastSale time.Time
+		if err := rows.Scan(&productID, &lastSale); err != nil {
+			return nil, err
+		}
+		dates[productID] = lastSale
+	}
+	return dates, rows.Err()
+}
@@ -9,19 +9,28 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const productColumns = `id, tenant_id, name, price, stock, reserved, damaged, quarantined, reorder_point, reorder_quantity, lot_tracked, serialized, inventory_policy, version`
+
+// ProductRepository only sees the products of the tenant in the context of
+// each call.
 type ProductRepository struct {
-	conn *pgxpool.Pool
+	conn dbConn
 }
 
 // NEW PRODUCT REPO
 func NewProductRepository(conn *pgxpool.Pool) *ProductRepository {
-	return &ProductRepository{conn: conn}
+	return &ProductRepository{conn: dbConn{pool: conn}}
+}
+
+func scanProduct(row pgx.Row, product *domain.Product) error {
+	return row.Scan(&product.ID, &product.TenantID, &product.Name, &product.Price, &product.Stock, &product.Reserved, &product.Damaged, &product.Quarantined, &product.ReorderPoint, &product.ReorderQuantity, &product.LotTracked, &product.Serialized, &product.InventoryPolicy, &product.Version)
 }
 
 // SAVE
 func (r *ProductRepository) Save(product *domain.Product, ctx context.Context) error {
-	query := `INSERT INTO products (id, name, price, stock) VALUES ($1, $2, $3, $4)`
-	_, err := r.conn.Exec(ctx, query, product.ID, product.Name, product.Price, product.Stock)
+	product.TenantID = domain.TenantFromContext(ctx)
+	query := `INSERT INTO products (id, tenant_id, name, price, stock, reorder_point, reorder_quantity, lot_tracked, serialized, inventory_policy) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err := r.conn.Exec(ctx, query, product.ID, product.TenantID, product.Name, product.Price, product.Stock, product.ReorderPoint, product.ReorderQuantity, product.LotTracked, product.Serialized, product.InventoryPolicy)
 	if err != nil {
 		return err
 	}
@@ -31,8 +40,8 @@ func (r *ProductRepository) Save(product *domain.Product, ctx context.Context) e
 // ! MUST DO
 // FIND ALL
 func (r *ProductRepository) FindAll(ctx context.Context) ([]domain.Product, error) {
-	var query = `SELECT * FROM products`
-	rows, err := r.conn.Query(ctx, query)
+	var query = `SELECT ` + productColumns + ` FROM products WHERE tenant_id=$1`
+	rows, err := r.conn.Query(ctx, query, domain.TenantFromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +50,7 @@ func (r *ProductRepository) FindAll(ctx context.Context) ([]domain.Product, erro
 	var products []domain.Product
 	for rows.Next() {
 		var product domain.Product
-		if err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.Stock); err != nil {
+		if err := scanProduct(rows, &product); err != nil {
 			return nil, err
 		}
 		products = append(products, product)
@@ -51,9 +60,9 @@ func (r *ProductRepository) FindAll(ctx context.Context) ([]domain.Product, erro
 
 // FIND BY ID
 func (r *ProductRepository) FindByID(id string, ctx context.Context) (*domain.Product, error) {
-	query := `SELECT * FROM products WHERE id=$1`
+	query := `SELECT ` + productColumns + ` FROM products WHERE id=$1 AND tenant_id=$2`
 	var product domain.Product
-	err := r.conn.QueryRow(ctx, query, id).Scan(&product.ID, &product.Name, &product.Price, &product.Stock)
+	err := scanProduct(r.conn.QueryRow(ctx, query, id, domain.TenantFromContext(ctx)), &product)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, errors.New("product not found")
@@ -63,14 +72,141 @@ func (r *ProductRepository) FindByID(id string, ctx context.Context) (*domain.Pr
 	return &product, nil
 }
 
+// versionConflict reports whether a conditional update matched no row because
+// the product is at another version rather than for its other conditions.
+func (r *ProductRepository) versionConflict(id string, version int, ctx context.Context) bool {
+	if version == 0 {
+		return false
+	}
+	var current int
+	err := r.conn.QueryRow(ctx, `SELECT version FROM products WHERE id=$1 AND tenant_id=$2`, id, domain.TenantFromContext(ctx)).Scan(&current)
+	return err == nil && current != version
+}
+
 // UPDATE STOCK
-func (r *ProductRepository) UpdateStock(id string, stockQuantity int, ctx context.Context) (*domain.Product, error) {
-	query := `UPDATE products SET stock=stock-$2 WHERE id=$1 AND stock>=$2 RETURNING *`
+// Only tracked products are kept from going below zero.
+func (r *ProductRepository) UpdateStock(id string, stockQuantity int, version int, ctx context.Context) (*domain.Product, error) {
+	query := `UPDATE products SET stock=stock-$2, version=version+1
+		WHERE id=$1 AND tenant_id=$5 AND (stock>=$2 OR inventory_policy<>$3) AND ($4=0 OR version=$4) RETURNING ` + productColumns
+	var product domain.Product
+	err := scanProduct(r.conn.QueryRow(ctx, query, id, stockQuantity, domain.PolicyTracked, version, domain.TenantFromContext(ctx)), &product)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			if r.versionConflict(id, version, ctx) {
+				return nil, domain.ErrVersionConflict
+			}
+			return nil, errors.New("product not found or stock is not enough")
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// INCREASE STOCK
+func (r *ProductRepository) IncreaseStock(id string, stockQuantity int, ctx context.Context) (*domain.Product, error) {
+	query := `UPDATE products SET stock=stock+$2, version=version+1 WHERE id=$1 AND tenant_id=$3 RETURNING ` + productColumns
+	var product domain.Product
+	err := scanProduct(r.conn.QueryRow(ctx, query, id, stockQuantity, domain.TenantFromContext(ctx)), &product)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New("product not found")
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// UPDATE REORDER SETTINGS
+func (r *ProductRepository) UpdateReorderSettings(id string, reorderPoint int, reorderQuantity int, version int, ctx context.Context) (*domain.Product, error) {
+	query := `UPDATE products SET reorder_point=$2, reorder_quantity=$3, version=version+1
+		WHERE id=$1 AND tenant_id=$5 AND ($4=0 OR version=$4) RETURNING ` + productColumns
+	var product domain.Product
+	err := scanProduct(r.conn.QueryRow(ctx, query, id, reorderPoint, reorderQuantity, version, domain.TenantFromContext(ctx)), &product)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			if r.versionConflict(id, version, ctx) {
+				return nil, domain.ErrVersionConflict
+			}
+			return nil, errors.New("product not found")
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// SAVE UNIT
+func (r *ProductRepository) SaveUnit(unit *domain.ProductUnit, version int, ctx context.Context) (*domain.Product, error) {
+	query := `WITH product AS (
+			UPDATE products SET version=version+1 WHERE id=$1 AND tenant_id=$5 AND ($4=0 OR version=$4) RETURNING ` + productColumns + `
+		), saved AS (
+			INSERT INTO product_units (product_id, unit, factor) SELECT id, $2::TEXT, $3::INT FROM product
+			ON CONFLICT (product_id, unit) DO UPDATE SET factor=EXCLUDED.factor
+		)
+		SELECT ` + productColumns + ` FROM product`
+	var product domain.Product
+	err := scanProduct(r.conn.QueryRow(ctx, query, unit.ProductID, unit.Unit, unit.Factor, version, domain.TenantFromContext(ctx)), &product)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			if r.versionConflict(unit.ProductID, version, ctx) {
+				return nil, domain.ErrVersionConflict
+			}
+			return nil, errors.New("product not found")
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// FIND UNITS
+func (r *ProductRepository) FindUnits(productID string, ctx context.Context) ([]domain.ProductUnit, error) {
+	query := `SELECT u.product_id, u.unit, u.factor FROM product_units u
+		JOIN products p ON p.id = u.product_id
+		WHERE u.product_id=$1 AND p.tenant_id=$2 ORDER BY u.factor`
+	rows, err := r.conn.Query(ctx, query, productID, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var units []domain.ProductUnit
+	for rows.Next() {
+		var unit domain.ProductUnit
+		if err := rows.Scan(&unit.ProductID, &unit.Unit, &unit.Factor); err != nil {
+			return nil, err
+		}
+		units = append(units, unit)
+	}
+	return units, rows.Err()
+}
+
+// bucketColumns maps stock buckets to their product columns.
+var bucketColumns = map[domain.StockBucket]string{
+	domain.BucketAvailable:   "stock",
+	domain.BucketReserved:    "reserved",
+	domain.BucketDamaged:     "damaged",
+	domain.BucketQuarantined: "quarantined",
+}
+
+// MOVE STOCK
+func (r *ProductRepository) MoveStock(id string, from domain.StockBucket, to domain.StockBucket, quantity int, version int, ctx context.Context) (*domain.Product, error) {
+	fromColumn, ok := bucketColumns[from]
+	if !ok {
+		return nil, errors.New("unknown stock bucket " + string(from))
+	}
+	toColumn, ok := bucketColumns[to]
+	if !ok {
+		return nil, errors.New("unknown stock bucket " + string(to))
+	}
+	query := `UPDATE products SET ` + fromColumn + `=` + fromColumn + `-$2, ` + toColumn + `=` + toColumn + `+$2, version=version+1
+		WHERE id=$1 AND tenant_id=$4 AND ` + fromColumn + `>=$2 AND ($3=0 OR version=$3) RETURNING ` + productColumns
 	var product domain.Product
-	err := r.conn.QueryRow(ctx, query, id, stockQuantity).Scan(&product.ID, &product.Name, &product.Price, &product.Stock)
+	err := scanProduct(r.conn.QueryRow(ctx, query, id, quantity, version, domain.TenantFromContext(ctx)), &product)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, errors.New("stock is not enough")
+			if r.versionConflict(id, version, ctx) {
+				return nil, domain.ErrVersionConflict
+			}
+			return nil, errors.New("product not found or not enough stock in " + string(from))
 		}
 		return nil, err
 	}
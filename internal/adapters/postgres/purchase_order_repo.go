@@ -0,0 +1,208 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PurchaseOrderRepository only sees the purchase orders of the tenant in the
+// context of each call.
+type PurchaseOrderRepository struct {
+	conn dbConn
+}
+
+// NEW PURCHASE ORDER REPO
+func NewPurchaseOrderRepository(conn *pgxpool.Pool) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{conn: dbConn{pool: conn}}
+}
+
+// SAVE
+func (r *PurchaseOrderRepository) Save(purchaseOrder *domain.PurchaseOrder, ctx context.Context) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO purchase_orders (id, tenant_id, supplier_id, status, created_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err = tx.Exec(ctx, query, purchaseOrder.ID, domain.TenantFromContext(ctx), purchaseOrder.SupplierID, purchaseOrder.Status, purchaseOrder.CreatedAt)
+	if err != nil {
+		return err
+	}
+	lineQuery := `INSERT INTO purchase_order_lines (id, purchase_order_id, product_id, quantity, received_quantity, unit_cost) VALUES ($1, $2, $3, $4, $5, $6)`
+	for _, line := range purchaseOrder.Lines {
+		_, err = tx.Exec(ctx, lineQuery, line.ID, purchaseOrder.ID, line.ProductID, line.Quantity, line.ReceivedQuantity, line.UnitCost)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// FIND ALL
+func (r *PurchaseOrderRepository) FindAll(ctx context.Context) ([]domain.PurchaseOrder, error) {
+	query := `SELECT id, supplier_id, status, created_at FROM purchase_orders WHERE tenant_id=$1 ORDER BY created_at DESC`
+	rows, err := r.conn.Query(ctx, query, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var purchaseOrders []domain.PurchaseOrder
+	for rows.Next() {
+		var purchaseOrder domain.PurchaseOrder
+		if err := rows.Scan(&purchaseOrder.ID, &purchaseOrder.SupplierID, &purchaseOrder.Status, &purchaseOrder.CreatedAt); err != nil {
+			return nil, err
+		}
+		purchaseOrders = append(purchaseOrders, purchaseOrder)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range purchaseOrders {
+		lines, err := r.findLines(purchaseOrders[i].ID, ctx)
+		if err != nil {
+			return nil, err
+		}
+		purchaseOrders[i].Lines = lines
+	}
+	return purchaseOrders, nil
+}
+
+// FIND BY ID
+func (r *PurchaseOrderRepository) FindByID(id string, ctx context.Context) (*domain.PurchaseOrder, error) {
+	return r.findByID(`SELECT id, supplier_id, status, created_at FROM purchase_orders WHERE id=$1 AND tenant_id=$2`, id, ctx)
+}
+
+// FIND BY ID FOR UPDATE
+// The order stays locked until the transaction in ctx ends, so concurrent
+// receipts cannot check against the same open quantities and status.
+func (r *PurchaseOrderRepository) FindByIDForUpdate(id string, ctx context.Context) (*domain.PurchaseOrder, error) {
+	return r.findByID(`SELECT id, supplier_id, status, created_at FROM purchase_orders WHERE id=$1 AND tenant_id=$2 FOR UPDATE`, id, ctx)
+}
+
+func (r *PurchaseOrderRepository) findByID(query string, id string, ctx context.Context) (*domain.PurchaseOrder, error) {
+	var purchaseOrder domain.PurchaseOrder
+	err := r.conn.QueryRow(ctx, query, id, domain.TenantFromContext(ctx)).Scan(&purchaseOrder.ID, &purchaseOrder.SupplierID, &purchaseOrder.Status, &purchaseOrder.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New("purchase order not found")
+		}
+		return nil, err
+	}
+	purchaseOrder.Lines, err = r.findLines(id, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &purchaseOrder, nil
+}
+
+// UPDATE STATUS
+func (r *PurchaseOrderRepository) UpdateStatus(id string, status domain.PurchaseOrderStatus, ctx context.Context) error {
+	query := `UPDATE purchase_orders SET status=$2 WHERE id=$1 AND tenant_id=$3`
+	tag, err := r.conn.Exec(ctx, query, id, status, domain.TenantFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("purchase order not found")
+	}
+	return nil
+}
+
+// SAVE RECEIPT
+// Stores the receipt and books the received quantities on the order lines in
+// one transaction, then moves the order to partially received or closed by
+// the stored line totals and returns that status. Orders of other tenants are
+// not found and stay untouched.
+func (r *PurchaseOrderRepository) SaveReceipt(receipt *domain.Receipt, ctx context.Context) (domain.PurchaseOrderStatus, error) {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `INSERT INTO receipts (id, purchase_order_id, received_at) VALUES ($1, $2, $3)`
+	_, err = tx.Exec(ctx, query, receipt.ID, receipt.PurchaseOrderID, receipt.ReceivedAt)
+	if err != nil {
+		return "", err
+	}
+	lineQuery := `INSERT INTO receipt_lines (id, receipt_id, purchase_order_line_id, product_id, quantity, unit, unit_quantity, lot_number) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))`
+	receivedQuery := `UPDATE purchase_order_lines SET received_quantity=received_quantity+$2 WHERE id=$1 AND purchase_order_id=$3 AND received_quantity+$2<=quantity`
+	for _, line := range receipt.Lines {
+		_, err = tx.Exec(ctx, lineQuery, line.ID, receipt.ID, line.PurchaseOrderLineID, line.ProductID, line.Quantity, line.Unit, line.UnitQuantity, line.LotNumber)
+		if err != nil {
+			return "", err
+		}
+		tag, err := tx.Exec(ctx, receivedQuery, line.PurchaseOrderLineID, line.Quantity, receipt.PurchaseOrderID)
+		if err != nil {
+			return "", err
+		}
+		if tag.RowsAffected() == 0 {
+			return "", errors.New("received quantity exceeds ordered quantity")
+		}
+	}
+	statusQuery := `UPDATE purchase_orders po SET status = CASE WHEN EXISTS (
+			SELECT 1 FROM purchase_order_lines l WHERE l.purchase_order_id = po.id AND l.received_quantity < l.quantity
+		) THEN $2 ELSE $3 END
+		WHERE po.id=$1 AND po.tenant_id=$4 RETURNING po.status`
+	var status domain.PurchaseOrderStatus
+	err = tx.QueryRow(ctx, statusQuery, receipt.PurchaseOrderID, domain.PurchaseOrderPartiallyReceived, domain.PurchaseOrderClosed, domain.TenantFromContext(ctx)).Scan(&status)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return "", errors.New("purchase order not found")
+		}
+		return "", err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return status, nil
+}
+
+// OPEN QUANTITIES
+func (r *PurchaseOrderRepository) OpenQuantities(ctx context.Context) (map[string]int, error) {
+	query := `SELECT l.product_id, SUM(l.quantity - l.received_quantity) FROM purchase_order_lines l
+		JOIN purchase_orders po ON po.id = l.purchase_order_id
+		WHERE po.status <> $1 AND po.tenant_id=$2 GROUP BY l.product_id`
+	rows, err := r.conn.Query(ctx, query, domain.PurchaseOrderClosed, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quantities := make(map[string]int)
+	for rows.Next() {
+		var productID string
+		var quantity int
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, err
+		}
+		quantities[productID] = quantity
+	}
+	return quantities, rows.Err()
+}
+
+func (r *PurchaseOrderRepository) findLines(purchaseOrderID string, ctx context.Context) ([]domain.PurchaseOrderLine, error) {
+	query := `SELECT id, purchase_order_id, product_id, quantity, received_quantity, unit_cost FROM purchase_order_lines WHERE purchase_order_id=$1 ORDER BY id`
+	rows, err := r.conn.Query(ctx, query, purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []domain.PurchaseOrderLine
+	for rows.Next() {
+		var line domain.PurchaseOrderLine
+		if err := rows.Scan(&line.ID, &line.PurchaseOrderID, &line.ProductID, &line.Quantity, &line.ReceivedQuantity, &line.UnitCost); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
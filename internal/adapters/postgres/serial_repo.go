@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SerialRepository only sees the serials of the tenant in the context of each
+// call. Serial numbers are unique per tenant.
+type SerialRepository struct {
+	conn dbConn
+}
+
+// NEW SERIAL REPO
+func NewSerialRepository(conn *pgxpool.Pool) *SerialRepository {
+	return &SerialRepository{conn: dbConn{pool: conn}}
+}
+
+const serialEventInsert = `INSERT INTO serial_events (id, tenant_id, serial_number, product_id, event, reference_id, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+// SAVE
+func (r *SerialRepository) Save(serials []domain.Serial, events []domain.SerialEvent, ctx context.Context) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tenantID := domain.TenantFromContext(ctx)
+	query := `INSERT INTO serials (tenant_id, serial_number, product_id, status, updated_at) VALUES ($1, $2, $3, $4, $5)`
+	for _, serial := range serials {
+		_, err := tx.Exec(ctx, query, tenantID, serial.SerialNumber, serial.ProductID, serial.Status, serial.UpdatedAt)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+				return errors.New("serial " + serial.SerialNumber + " already exists")
+			}
+			return err
+		}
+	}
+	if err := insertSerialEvents(tx, events, ctx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// FIND BY NUMBER
+func (r *SerialRepository) FindByNumber(serialNumber string, ctx context.Context) (*domain.Serial, error) {
+	query := `SELECT serial_number, product_id, status, updated_at FROM serials WHERE serial_number=$1 AND tenant_id=$2`
+	var serial domain.Serial
+	err := r.conn.QueryRow(ctx, query, serialNumber, domain.TenantFromContext(ctx)).Scan(&serial.SerialNumber, &serial.ProductID, &serial.Status, &serial.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New("serial not found")
+		}
+		return nil, err
+	}
+	return &serial, nil
+}
+
+// FIND EVENTS
+func (r *SerialRepository) FindEvents(serialNumber string, ctx context.Context) ([]domain.SerialEvent, error) {
+	query := `SELECT id, serial_number, product_id, event, reference_id, created_at FROM serial_events
+		WHERE serial_number=$1 AND tenant_id=$2 ORDER BY created_at, id`
+	rows, err := r.conn.Query(ctx, query, serialNumber, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.SerialEvent
+	for rows.Next() {
+		var e domain.SerialEvent
+		if err := rows.Scan(&e.ID, &e.SerialNumber, &e.ProductID, &e.Event, &e.ReferenceID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// FIND BY ORDER
+func (r *SerialRepository) FindByOrder(orderID string, ctx context.Context) ([]string, error) {
+	query := `SELECT serial_number FROM serial_events WHERE reference_id=$1 AND event=$2 AND tenant_id=$3 ORDER BY serial_number`
+	rows, err := r.conn.Query(ctx, query, orderID, domain.SerialEventSold, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var serials []string
+	for rows.Next() {
+		var serial string
+		if err := rows.Scan(&serial); err != nil {
+			return nil, err
+		}
+		serials = append(serials, serial)
+	}
+	return serials, rows.Err()
+}
+
+// TRANSITION
+func (r *SerialRepository) Transition(events []domain.SerialEvent, from domain.SerialStatus, to domain.SerialStatus, ctx context.Context) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE serials SET status=$4, updated_at=$5 WHERE serial_number=$1 AND product_id=$2 AND status=$3 AND tenant_id=$6`
+	for _, e := range events {
+		tag, err := tx.Exec(ctx, query, e.SerialNumber, e.ProductID, from, to, e.CreatedAt, domain.TenantFromContext(ctx))
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return errors.New("serial " + e.SerialNumber + " is not " + string(from))
+		}
+	}
+	if err := insertSerialEvents(tx, events, ctx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func insertSerialEvents(tx pgx.Tx, events []domain.SerialEvent, ctx context.Context) error {
+	for _, e := range events {
+		_, err := tx.Exec(ctx, serialEventInsert, e.ID, domain.TenantFromContext(ctx), e.SerialNumber, e.ProductID, e.Event, e.ReferenceID, e.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
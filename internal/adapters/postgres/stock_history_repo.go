@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// stockAtQuery computes the stock of every product at $1: the latest
+// snapshot at or before $1 plus the movements between that snapshot and $1.
+var stockAtQuery = stockLevelsQuery("<=")
+
+// stockBeforeQuery is stockAtQuery without what happened at $1 itself.
+var stockBeforeQuery = stockLevelsQuery("<")
+
+func stockLevelsQuery(cmp string) string {
+	return `
+	WITH last_snapshot AS (
+		SELECT DISTINCT ON (product_id) product_id, stock, taken_at
+		FROM stock_snapshots WHERE taken_at ` + cmp + ` $1
+		ORDER BY product_id, taken_at DESC
+	)
+	SELECT p.id, (COALESCE(s.stock, 0) + COALESCE((
+		SELECT SUM(m.quantity) FROM stock_movements m
+		WHERE m.product_id = p.id AND m.created_at ` + cmp + ` $1
+		AND (s.taken_at IS NULL OR m.created_at > s.taken_at)
+	), 0))::INT AS stock
+	FROM products p
+	LEFT JOIN last_snapshot s ON s.product_id = p.id`
+}
+
+type StockHistoryRepository struct {
+	conn dbConn
+}
+
+// NEW STOCK HISTORY REPO
+func NewStockHistoryRepository(conn *pgxpool.Pool) *StockHistoryRepository {
+	return &StockHistoryRepository{conn: dbConn{pool: conn}}
+}
+
+// SAVE MOVEMENT
+func (r *StockHistoryRepository) SaveMovement(movement *domain.StockMovement, ctx context.Context) error {
+	query := `INSERT INTO stock_movements (id, product_id, quantity, reason, reference_id, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := r.conn.Exec(ctx, query, movement.ID, movement.ProductID, movement.Quantity, movement.Reason, movement.ReferenceID, movement.CreatedAt)
+	return err
+}
+
+// STOCK AT
+// Products of other tenants are not found.
+func (r *StockHistoryRepository) StockAt(productID string, asOf time.Time, ctx context.Context) (int, error) {
+	query := `SELECT stock FROM (` + stockAtQuery + ` WHERE p.id = $2 AND p.tenant_id = $3) levels`
+	var stock int
+	err := r.conn.QueryRow(ctx, query, asOf, productID, domain.TenantFromContext(ctx)).Scan(&stock)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, errors.New("product not found")
+		}
+		return 0, err
+	}
+	return stock, nil
+}
+
+// STOCK AT ALL
+func (r *StockHistoryRepository) StockAtAll(asOf time.Time, ctx context.Context) ([]domain.StockLevel, error) {
+	return r.stockLevels(stockAtQuery, asOf, ctx)
+}
+
+// STOCK BEFORE ALL
+func (r *StockHistoryRepository) StockBeforeAll(before time.Time, ctx context.Context) ([]domain.StockLevel, error) {
+	return r.stockLevels(stockBeforeQuery, before, ctx)
+}
+
+func (r *StockHistoryRepository) stockLevels(levelsQuery string, asOf time.Time, ctx context.Context) ([]domain.StockLevel, error) {
+	query := levelsQuery + ` WHERE p.tenant_id = $2 ORDER BY p.id`
+	rows, err := r.conn.Query(ctx, query, asOf, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var levels []domain.StockLevel
+	for rows.Next() {
+		level := domain.StockLevel{AsOf: asOf}
+		if err := rows.Scan(&level.ProductID, &level.Stock); err != nil {
+			return nil, err
+		}
+		levels = append(levels, level)
+	}
+	return levels, rows.Err()
+}
+
+// SAVE SNAPSHOT
+func (r *StockHistoryRepository) SaveSnapshot(takenAt time.Time, ctx context.Context) (int, error) {
+	query := `INSERT INTO stock_snapshots (product_id, stock, taken_at)
+		SELECT id, stock, $1 FROM (` + stockAtQuery + ` WHERE p.tenant_id = $2) levels
+		ON CONFLICT (product_id, taken_at) DO NOTHING`
+	tag, err := r.conn.Exec(ctx, query, takenAt, domain.TenantFromContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// SAVE SNAPSHOT ALL
+// Snapshots are taken for the products of all tenants.
+func (r *StockHistoryRepository) SaveSnapshotAll(takenAt time.Time, ctx context.Context) (int, error) {
+	query := `INSERT INTO stock_snapshots (product_id, stock, taken_at)
+		SELECT id, stock, $1 FROM (` + stockAtQuery + `) levels
+		ON CONFLICT (product_id, taken_at) DO NOTHING`
+	tag, err := r.conn.Exec(ctx, query, takenAt)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// MOVEMENT TOTALS
+func (r *StockHistoryRepository) MovementTotals(reason domain.StockMovementReason, from time.Time, to time.Time, ctx context.Context) (map[string]int, error) {
+	query := `SELECT product_id, SUM(quantity) FROM stock_movements
+		WHERE reason = $1 AND created_at >= $2 AND created_at < $3 GROUP BY product_id`
+	rows, err := r.conn.Query(ctx, query, reason, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make(map[string]int)
+	for rows.Next() {
+		var productID string
+		var quantity int
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, err
+		}
+		totals[productID] = quantity
+	}
+	return totals, rows.Err()
+}
+
+// TOTALS EXCLUDING
+func (r *StockHistoryRepository) TotalsExcluding(reason domain.StockMovementReason, ctx context.Context) (map[string]int, error) {
+	query := `SELECT product_id, SUM(quantity) FROM stock_movements WHERE reason <> $1 GROUP BY product_id`
+	rows, err := r.conn.Query(ctx, query, reason)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make(map[string]int)
+	for rows.Next() {
+		var productID string
+		var quantity int
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, err
+		}
+		totals[productID] = quantity
+	}
+	return totals, rows.Err()
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SupplierRepository only sees the suppliers of the tenant in the context of
+// each call.
+type SupplierRepository struct {
+	conn dbConn
+}
+
+// NEW SUPPLIER REPO
+func NewSupplierRepository(conn *pgxpool.Pool) *SupplierRepository {
+	return &SupplierRepository{conn: dbConn{pool: conn}}
+}
+
+// SAVE
+func (r *SupplierRepository) Save(supplier *domain.Supplier, ctx context.Context) error {
+	query := `INSERT INTO suppliers (id, tenant_id, name, email, phone) VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.conn.Exec(ctx, query, supplier.ID, domain.TenantFromContext(ctx), supplier.Name, supplier.Email, supplier.Phone)
+	return err
+}
+
+// FIND ALL
+func (r *SupplierRepository) FindAll(ctx context.Context) ([]domain.Supplier, error) {
+	query := `SELECT id, name, email, phone FROM suppliers WHERE tenant_id=$1 ORDER BY name`
+	rows, err := r.conn.Query(ctx, query, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suppliers []domain.Supplier
+	for rows.Next() {
+		var supplier domain.Supplier
+		if err := rows.Scan(&supplier.ID, &supplier.Name, &supplier.Email, &supplier.Phone); err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, supplier)
+	}
+	return suppliers, rows.Err()
+}
+
+// FIND BY ID
+func (r *SupplierRepository) FindByID(id string, ctx context.Context) (*domain.Supplier, error) {
+	query := `SELECT id, name, email, phone FROM suppliers WHERE id=$1 AND tenant_id=$2`
+	var supplier domain.Supplier
+	err := r.conn.QueryRow(ctx, query, id, domain.TenantFromContext(ctx)).Scan(&supplier.ID, &supplier.Name, &supplier.Email, &supplier.Phone)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New("supplier not found")
+		}
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+// SAVE PRODUCT LINK
+// Linking the same product twice updates the cost and lead time.
+func (r *SupplierRepository) SaveProduct(link *domain.SupplierProduct, ctx context.Context) error {
+	query := `INSERT INTO supplier_products (supplier_id, product_id, unit_cost, lead_time_days)
+		SELECT $1::TEXT, $2::TEXT, $3::DECIMAL, $4::INT
+		WHERE EXISTS (SELECT 1 FROM suppliers WHERE id=$1 AND tenant_id=$5)
+			AND EXISTS (SELECT 1 FROM products WHERE id=$2 AND tenant_id=$5)
+		ON CONFLICT (supplier_id, product_id) DO UPDATE SET unit_cost=EXCLUDED.unit_cost, lead_time_days=EXCLUDED.lead_time_days`
+	tag, err := r.conn.Exec(ctx, query, link.SupplierID, link.ProductID, link.UnitCost, link.LeadTimeDays, domain.TenantFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("supplier or product not found")
+	}
+	return nil
+}
+
+// FIND PRODUCT LINKS
+func (r *SupplierRepository) FindProducts(supplierID string, ctx context.Context) ([]domain.SupplierProduct, error) {
+	query := `SELECT sp.supplier_id, sp.product_id, sp.unit_cost, sp.lead_time_days FROM supplier_products sp
+		JOIN suppliers s ON s.id = sp.supplier_id
+		WHERE sp.supplier_id=$1 AND s.tenant_id=$2`
+	rows, err := r.conn.Query(ctx, query, supplierID, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []domain.SupplierProduct
+	for rows.Next() {
+		var link domain.SupplierProduct
+		if err := rows.Scan(&link.SupplierID, &link.ProductID, &link.UnitCost, &link.LeadTimeDays); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+// FIND ALL PRODUCT LINKS
+func (r *SupplierRepository) FindAllProducts(ctx context.Context) ([]domain.SupplierProduct, error) {
+	query := `SELECT sp.supplier_id, sp.product_id, sp.unit_cost, sp.lead_time_days FROM supplier_products sp
+		JOIN suppliers s ON s.id = sp.supplier_id
+		WHERE s.tenant_id=$1`
+	rows, err := r.conn.Query(ctx, query, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []domain.SupplierProduct
+	for rows.Next() {
+		var link domain.SupplierProduct
+		if err := rows.Scan(&link.SupplierID, &link.ProductID, &link.UnitCost, &link.LeadTimeDays); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
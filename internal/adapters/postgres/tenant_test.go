@@ -0,0 +1,397 @@
+package postgres
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/pkg/helpers"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newTestDB migrates a fresh schema in the database at TEST_DATABASE_URL and
+// drops it when the test ends. Tests using it are skipped without the variable.
+func newTestDB(t *testing.T) *pgxpool.Pool {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	ctx := context.Background()
+	admin, err := NewDB(url)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	t.Cleanup(admin.Close)
+
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	schema := "test_" + hex.EncodeToString(suffix)
+	if _, err := admin.Exec(ctx, `CREATE SCHEMA `+schema); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	t.Cleanup(func() { admin.Exec(context.Background(), `DROP SCHEMA `+schema+` CASCADE`) })
+
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+	conn, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	t.Cleanup(conn.Close)
+
+	migrations, err := filepath.Glob("../../../migrations/*.up.sql")
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("expected migrations, got %v (%v)", migrations, err)
+	}
+	sort.Strings(migrations)
+	for _, migration := range migrations {
+		sql, err := os.ReadFile(migration)
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if _, err := conn.Exec(ctx, string(sql)); err != nil {
+			t.Fatalf("migration %v failed: %v", filepath.Base(migration), err)
+		}
+	}
+	return conn
+}
+
+// TESTS
+func TestProductRepository_TenantIsolation(t *testing.T) {
+	repo := NewProductRepository(newTestDB(t))
+	acme := domain.WithTenant(context.Background(), "acme")
+	globex := domain.WithTenant(context.Background(), "globex")
+
+	product := &domain.Product{ID: helpers.GenerateUUID(), Name: "Widget", Price: 10, Stock: 5, InventoryPolicy: domain.PolicyTracked}
+	if err := repo.Save(product, acme); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if saved, err := repo.SaveUnit(&domain.ProductUnit{ProductID: product.ID, Unit: domain.UnitPack, Factor: 6}, 1, acme); err != nil || saved.Version != 2 {
+		t.Fatalf("expected the unit to move the product to version 2, got %+v (%v)", saved, err)
+	}
+
+	if _, err := repo.FindByID(product.ID, globex); err == nil {
+		t.Errorf("expected another tenant not to find the product")
+	}
+	if products, err := repo.FindAll(globex); err != nil || len(products) != 0 {
+		t.Errorf("expected another tenant to list no products, got %v (%v)", products, err)
+	}
+	if units, err := repo.FindUnits(product.ID, globex); err != nil || len(units) != 0 {
+		t.Errorf("expected another tenant to see no units, got %v (%v)", units, err)
+	}
+
+	if _, err := repo.UpdateStock(product.ID, 1, 0, globex); err == nil {
+		t.Errorf("expected another tenant not to take stock")
+	}
+	if _, err := repo.IncreaseStock(product.ID, 100, globex); err == nil {
+		t.Errorf("expected another tenant not to add stock")
+	}
+	if _, err := repo.UpdateReorderSettings(product.ID, 50, 50, 0, globex); err == nil {
+		t.Errorf("expected another tenant not to change reorder settings")
+	}
+	if _, err := repo.MoveStock(product.ID, domain.BucketAvailable, domain.BucketDamaged, 1, 0, globex); err == nil {
+		t.Errorf("expected another tenant not to move stock")
+	}
+	if _, err := repo.SaveUnit(&domain.ProductUnit{ProductID: product.ID, Unit: domain.UnitCase, Factor: 24}, 0, globex); err == nil {
+		t.Errorf("expected another tenant not to add units")
+	}
+
+	found, err := repo.FindByID(product.ID, acme)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if found.TenantID != "acme" || found.Stock != 5 || found.Damaged != 0 || found.ReorderPoint != 0 || found.Version != 2 {
+		t.Errorf("expected the product to be unchanged, got %+v", found)
+	}
+	if units, _ := repo.FindUnits(product.ID, acme); len(units) != 1 {
+		t.Errorf("expected only the owner's unit, got %v", units)
+	}
+}
+
+func TestOrderRepository_TenantIsolation(t *testing.T) {
+	conn := newTestDB(t)
+	productRepo := NewProductRepository(conn)
+	orderRepo := NewOrderRepository(conn)
+	acme := domain.WithTenant(context.Background(), "acme")
+	globex := domain.WithTenant(context.Background(), "globex")
+
+	product := &domain.Product{ID: helpers.GenerateUUID(), Name: "Widget", Price: 10, Stock: 5, InventoryPolicy: domain.PolicyTracked}
+	if err := productRepo.Save(product, acme); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	now := time.Now().UTC()
+	order := &domain.Order{ID: helpers.GenerateUUID(), ProductID: product.ID, Quantity: 2, TotalPrice: 20, CreatedAt: now}
+	if err := orderRepo.Save(order, acme); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if _, err := orderRepo.FindByID(order.ID, globex); err == nil {
+		t.Errorf("expected another tenant not to find the order")
+	}
+	if orders, err := orderRepo.FindAll(globex); err != nil || len(orders) != 0 {
+		t.Errorf("expected another tenant to list no orders, got %v (%v)", orders, err)
+	}
+	if sales, err := orderRepo.SalesByProduct(now.Add(-time.Hour), globex); err != nil || len(sales) != 0 {
+		t.Errorf("expected another tenant to see no sales, got %v (%v)", sales, err)
+	}
+	if sales, err := orderRepo.DailySales(product.ID, now.Add(-24*time.Hour), now.Add(24*time.Hour), globex); err != nil || len(sales) != 0 {
+		t.Errorf("expected another tenant to see no daily sales, got %v (%v)", sales, err)
+	}
+	if sales, err := orderRepo.DailySalesAll(now.Add(-24*time.Hour), now.Add(24*time.Hour), globex); err != nil || len(sales) != 0 {
+		t.Errorf("expected another tenant to see no daily sales, got %v (%v)", sales, err)
+	}
+	if dates, err := orderRepo.LastSaleDates(now.Add(time.Hour), globex); err != nil || len(dates) != 0 {
+		t.Errorf("expected another tenant to see no sale dates, got %v (%v)", dates, err)
+	}
+
+	found, err := orderRepo.FindByID(order.ID, acme)
+	if err != nil || found.TenantID != "acme" {
+		t.Errorf("expected the owner to find the order, got %+v (%v)", found, err)
+	}
+	if sales, _ := orderRepo.SalesByProduct(now.Add(-time.Hour), acme); sales[product.ID] != 2 {
+		t.Errorf("expected the owner to see 2 sold, got %v", sales)
+	}
+}
+
+func saveTenantProduct(t *testing.T, conn *pgxpool.Pool, ctx context.Context) *domain.Product {
+	t.Helper()
+	product := &domain.Product{ID: helpers.GenerateUUID(), Name: "Widget", Price: 10, InventoryPolicy: domain.PolicyTracked}
+	if err := NewProductRepository(conn).Save(product, ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	return product
+}
+
+func TestSupplierRepository_TenantIsolation(t *testing.T) {
+	conn := newTestDB(t)
+	repo := NewSupplierRepository(conn)
+	acme := domain.WithTenant(context.Background(), "acme")
+	globex := domain.WithTenant(context.Background(), "globex")
+
+	product := saveTenantProduct(t, conn, acme)
+	supplier := &domain.Supplier{ID: helpers.GenerateUUID(), Name: "Initech"}
+	if err := repo.Save(supplier, acme); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if _, err := repo.FindByID(supplier.ID, globex); err == nil {
+		t.Errorf("expected another tenant not to find the supplier")
+	}
+	if suppliers, err := repo.FindAll(globex); err != nil || len(suppliers) != 0 {
+		t.Errorf("expected another tenant to list no suppliers, got %v (%v)", suppliers, err)
+	}
+	if err := repo.SaveProduct(&domain.SupplierProduct{SupplierID: supplier.ID, ProductID: product.ID, UnitCost: 1}, globex); err == nil {
+		t.Errorf("expected another tenant not to link products")
+	}
+
+	if err := repo.SaveProduct(&domain.SupplierProduct{SupplierID: supplier.ID, ProductID: product.ID, UnitCost: 4, LeadTimeDays: 3}, acme); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if links, err := repo.FindProducts(supplier.ID, globex); err != nil || len(links) != 0 {
+		t.Errorf("expected another tenant to see no links, got %v (%v)", links, err)
+	}
+	if links, err := repo.FindAllProducts(globex); err != nil || len(links) != 0 {
+		t.Errorf("expected another tenant to see no links, got %v (%v)", links, err)
+	}
+	if links, _ := repo.FindAllProducts(acme); len(links) != 1 || links[0].UnitCost != 4 {
+		t.Errorf("expected the owner's link, got %v", links)
+	}
+}
+
+func TestPurchaseOrderRepository_TenantIsolation(t *testing.T) {
+	conn := newTestDB(t)
+	repo := NewPurchaseOrderRepository(conn)
+	acme := domain.WithTenant(context.Background(), "acme")
+	globex := domain.WithTenant(context.Background(), "globex")
+
+	product := saveTenantProduct(t, conn, acme)
+	supplier := &domain.Supplier{ID: helpers.GenerateUUID(), Name: "Initech"}
+	if err := NewSupplierRepository(conn).Save(supplier, acme); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	line := domain.PurchaseOrderLine{ID: helpers.GenerateUUID(), ProductID: product.ID, Quantity: 10, UnitCost: 2}
+	purchaseOrder := &domain.PurchaseOrder{ID: helpers.GenerateUUID(), SupplierID: supplier.ID, Status: domain.PurchaseOrderSent, Lines: []domain.PurchaseOrderLine{line}, CreatedAt: time.Now().UTC()}
+	if err := repo.Save(purchaseOrder, acme); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if _, err := repo.FindByID(purchaseOrder.ID, globex); err == nil {
+		t.Errorf("expected another tenant not to find the purchase order")
+	}
+	if purchaseOrders, err := repo.FindAll(globex); err != nil || len(purchaseOrders) != 0 {
+		t.Errorf("expected another tenant to list no purchase orders, got %v (%v)", purchaseOrders, err)
+	}
+	if quantities, err := repo.OpenQuantities(globex); err != nil || len(quantities) != 0 {
+		t.Errorf("expected another tenant to see no open quantities, got %v (%v)", quantities, err)
+	}
+	if err := repo.UpdateStatus(purchaseOrder.ID, domain.PurchaseOrderClosed, globex); err == nil {
+		t.Errorf("expected another tenant not to change the status")
+	}
+	receipt := &domain.Receipt{
+		ID:              helpers.GenerateUUID(),
+		PurchaseOrderID: purchaseOrder.ID,
+		Lines:           []domain.ReceiptLine{{ID: helpers.GenerateUUID(), PurchaseOrderLineID: line.ID, ProductID: product.ID, Quantity: 4}},
+		ReceivedAt:      time.Now().UTC(),
+	}
+	if _, err := repo.SaveReceipt(receipt, globex); err == nil {
+		t.Errorf("expected another tenant not to receive the purchase order")
+	}
+
+	found, err := repo.FindByID(purchaseOrder.ID, acme)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if found.Status != domain.PurchaseOrderSent || found.Lines[0].ReceivedQuantity != 0 {
+		t.Errorf("expected the purchase order to be unchanged, got %+v", found)
+	}
+	if quantities, _ := repo.OpenQuantities(acme); quantities[product.ID] != 10 {
+		t.Errorf("expected the owner to see 10 open, got %v", quantities)
+	}
+}
+
+func TestLotRepository_TenantIsolation(t *testing.T) {
+	conn := newTestDB(t)
+	repo := NewLotRepository(conn)
+	acme := domain.WithTenant(context.Background(), "acme")
+	globex := domain.WithTenant(context.Background(), "globex")
+
+	product := saveTenantProduct(t, conn, acme)
+	now := time.Now().UTC()
+	lot, err := repo.Receive(&domain.Lot{ID: helpers.GenerateUUID(), ProductID: product.ID, LotNumber: "L1", Quantity: 5, ReceivedAt: now}, acme)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if lots, err := repo.FindByProduct(product.ID, globex); err != nil || len(lots) != 0 {
+		t.Errorf("expected another tenant to see no lots, got %v (%v)", lots, err)
+	}
+	if lots, err := repo.FindAllocatable(product.ID, now, globex); err != nil || len(lots) != 0 {
+		t.Errorf("expected another tenant to see no allocatable lots, got %v (%v)", lots, err)
+	}
+	if lots, err := repo.FindByNumber("L1", "", globex); err != nil || len(lots) != 0 {
+		t.Errorf("expected another tenant to find no lots by number, got %v (%v)", lots, err)
+	}
+	if changed, err := repo.SetQuarantined([]string{lot.ID}, true, globex); err != nil || len(changed) != 0 {
+		t.Errorf("expected another tenant not to quarantine the lot, got %v (%v)", changed, err)
+	}
+	order := &domain.Order{ID: helpers.GenerateUUID(), ProductID: product.ID, Quantity: 1, TotalPrice: 10, CreatedAt: now}
+	if err := NewOrderRepository(conn).Save(order, acme); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := repo.Allocate([]domain.LotAllocation{{OrderID: order.ID, LotID: lot.ID, Quantity: 1}}, globex); err == nil {
+		t.Errorf("expected another tenant not to allocate the lot")
+	}
+
+	lots, err := repo.FindByNumber("L1", "", acme)
+	if err != nil || len(lots) != 1 {
+		t.Fatalf("expected the owner to find the lot, got %v (%v)", lots, err)
+	}
+	if lots[0].Quarantined || lots[0].Remaining != 5 {
+		t.Errorf("expected the lot to be unchanged, got %+v", lots[0])
+	}
+}
+
+func TestSerialRepository_TenantIsolation(t *testing.T) {
+	conn := newTestDB(t)
+	repo := NewSerialRepository(conn)
+	acme := domain.WithTenant(context.Background(), "acme")
+	globex := domain.WithTenant(context.Background(), "globex")
+	now := time.Now().UTC()
+
+	receive := func(product *domain.Product, ctx context.Context) {
+		serial := domain.Serial{SerialNumber: "SN-1", ProductID: product.ID, Status: domain.SerialInStock, UpdatedAt: now}
+		event := domain.SerialEvent{ID: helpers.GenerateUUID(), SerialNumber: "SN-1", ProductID: product.ID, Event: domain.SerialEventReceived, CreatedAt: now}
+		if err := repo.Save([]domain.Serial{serial}, []domain.SerialEvent{event}, ctx); err != nil {
+			t.Fatalf("expected both tenants to own SN-1, got %v", err)
+		}
+	}
+	acmeProduct := saveTenantProduct(t, conn, acme)
+	globexProduct := saveTenantProduct(t, conn, globex)
+	receive(acmeProduct, acme)
+	receive(globexProduct, globex)
+
+	sold := domain.SerialEvent{ID: helpers.GenerateUUID(), SerialNumber: "SN-1", ProductID: acmeProduct.ID, Event: domain.SerialEventSold, ReferenceID: "order-1", CreatedAt: now}
+	if err := repo.Transition([]domain.SerialEvent{sold}, domain.SerialInStock, domain.SerialSold, globex); err == nil {
+		t.Errorf("expected another tenant not to sell the serial")
+	}
+	if err := repo.Transition([]domain.SerialEvent{sold}, domain.SerialInStock, domain.SerialSold, acme); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	serial, err := repo.FindByNumber("SN-1", globex)
+	if err != nil || serial.ProductID != globexProduct.ID || serial.Status != domain.SerialInStock {
+		t.Errorf("expected globex's own serial in stock, got %+v (%v)", serial, err)
+	}
+	if events, err := repo.FindEvents("SN-1", globex); err != nil || len(events) != 1 {
+		t.Errorf("expected globex to see only its own event, got %v (%v)", events, err)
+	}
+	if serials, err := repo.FindByOrder("order-1", globex); err != nil || len(serials) != 0 {
+		t.Errorf("expected globex to see no sold serials, got %v (%v)", serials, err)
+	}
+	if serials, _ := repo.FindByOrder("order-1", acme); len(serials) != 1 {
+		t.Errorf("expected acme to see the sold serial, got %v", serials)
+	}
+}
+
+func TestExpectedReceiptRepository_TenantIsolation(t *testing.T) {
+	conn := newTestDB(t)
+	repo := NewExpectedReceiptRepository(conn)
+	acme := domain.WithTenant(context.Background(), "acme")
+	globex := domain.WithTenant(context.Background(), "globex")
+
+	product := saveTenantProduct(t, conn, acme)
+	now := time.Now().UTC()
+	expectedReceipt := &domain.ExpectedReceipt{ID: helpers.GenerateUUID(), ProductID: product.ID, Quantity: 10, ExpectedAt: now.Add(24 * time.Hour), CreatedAt: now}
+	if err := repo.Save(expectedReceipt, acme); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if open, err := repo.FindOpen("", globex); err != nil || len(open) != 0 {
+		t.Errorf("expected another tenant to see no expected receipts, got %v (%v)", open, err)
+	}
+	if _, err := repo.Close(expectedReceipt.ID, now, globex); err == nil {
+		t.Errorf("expected another tenant not to close the expected receipt")
+	}
+
+	open, err := repo.FindOpen(product.ID, acme)
+	if err != nil || len(open) != 1 || open[0].ClosedAt != nil {
+		t.Errorf("expected the owner's open receipt, got %v (%v)", open, err)
+	}
+}
+
+func TestStockHistoryRepository_TenantIsolation(t *testing.T) {
+	conn := newTestDB(t)
+	repo := NewStockHistoryRepository(conn)
+	acme := domain.WithTenant(context.Background(), "acme")
+	globex := domain.WithTenant(context.Background(), "globex")
+
+	product := saveTenantProduct(t, conn, acme)
+	now := time.Now().UTC()
+	movement := &domain.StockMovement{ID: helpers.GenerateUUID(), ProductID: product.ID, Quantity: 5, Reason: domain.MovementInitial, CreatedAt: now}
+	if err := repo.SaveMovement(movement, acme); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if stock, err := repo.StockAt(product.ID, now, globex); err == nil {
+		t.Errorf("expected another tenant not to find the product, got stock %v", stock)
+	}
+	if count, err := repo.SaveSnapshot(now, globex); err != nil || count != 0 {
+		t.Errorf("expected another tenant to snapshot no products, got %v (%v)", count, err)
+	}
+
+	if stock, err := repo.StockAt(product.ID, now, acme); err != nil || stock != 5 {
+		t.Errorf("expected the owner to see stock 5, got %v (%v)", stock, err)
+	}
+	if count, err := repo.SaveSnapshot(now, acme); err != nil || count != 1 {
+		t.Errorf("expected the owner to snapshot its product, got %v (%v)", count, err)
+	}
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const userColumns = `id, tenant_id, email, name, password_hash, role, created_at`
+
+type UserRepository struct {
+	conn dbConn
+}
+
+// NEW USER REPO
+func NewUserRepository(conn *pgxpool.Pool) *UserRepository {
+	return &UserRepository{conn: dbConn{pool: conn}}
+}
+
+func scanUser(row pgx.Row, user *domain.User) error {
+	return row.Scan(&user.ID, &user.TenantID, &user.Email, &user.Name, &user.PasswordHash, &user.Role, &user.CreatedAt)
+}
+
+// SAVE
+func (r *UserRepository) Save(user *domain.User, ctx context.Context) error {
+	user.TenantID = domain.TenantFromContext(ctx)
+	query := `INSERT INTO users (id, tenant_id, email, name, password_hash, role, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.conn.Exec(ctx, query, user.ID, user.TenantID, user.Email, user.Name, user.PasswordHash, user.Role, user.CreatedAt)
+	return err
+}
+
+// FIND ALL
+func (r *UserRepository) FindAll(ctx context.Context) ([]domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE tenant_id=$1 ORDER BY email`
+	rows, err := r.conn.Query(ctx, query, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var users []domain.User
+	for rows.Next() {
+		var user domain.User
+		if err := scanUser(rows, &user); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// FIND BY ID
+func (r *UserRepository) FindByID(id string, ctx context.Context) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE id=$1 AND tenant_id=$2`
+	var user domain.User
+	err := scanUser(r.conn.QueryRow(ctx, query, id, domain.TenantFromContext(ctx)), &user)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FIND BY EMAIL
+func (r *UserRepository) FindByEmail(email string, ctx context.Context) (*domain.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE email=$1`
+	var user domain.User
+	err := scanUser(r.conn.QueryRow(ctx, query, email), &user)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UPDATE ROLE
+func (r *UserRepository) UpdateRole(id string, role domain.Role, ctx context.Context) error {
+	tag, err := r.conn.Exec(ctx, `UPDATE users SET role=$2 WHERE id=$1 AND tenant_id=$3`, id, role, domain.TenantFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
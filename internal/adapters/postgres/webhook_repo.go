@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const webhookColumns = `id, tenant_id, url, event_types, secret, created_at`
+
+const deliveryColumns = `id, tenant_id, webhook_id, event_type, payload, status, attempts, next_attempt_at, last_error, response_status, created_at, delivered_at`
+
+type WebhookRepository struct {
+	conn dbConn
+}
+
+// NEW WEBHOOK REPO
+func NewWebhookRepository(conn *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{conn: dbConn{pool: conn}}
+}
+
+func scanWebhook(row pgx.Row, webhook *domain.Webhook) error {
+	var eventTypes []string
+	if err := row.Scan(&webhook.ID, &webhook.TenantID, &webhook.URL, &eventTypes, &webhook.Secret, &webhook.CreatedAt); err != nil {
+		return err
+	}
+	webhook.EventTypes = make([]domain.EventType, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		webhook.EventTypes = append(webhook.EventTypes, domain.EventType(eventType))
+	}
+	return nil
+}
+
+func scanDelivery(row pgx.Row, delivery *domain.WebhookDelivery) error {
+	return row.Scan(&delivery.ID, &delivery.TenantID, &delivery.WebhookID, &delivery.EventType, &delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastError, &delivery.ResponseStatus, &delivery.CreatedAt, &delivery.DeliveredAt)
+}
+
+func collectWebhooks(rows pgx.Rows) ([]domain.Webhook, error) {
+	defer rows.Close()
+	var webhooks []domain.Webhook
+	for rows.Next() {
+		var webhook domain.Webhook
+		if err := scanWebhook(rows, &webhook); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func collectDeliveries(rows pgx.Rows) ([]domain.WebhookDelivery, error) {
+	defer rows.Close()
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		var delivery domain.WebhookDelivery
+		if err := scanDelivery(rows, &delivery); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// SAVE
+func (r *WebhookRepository) Save(webhook *domain.Webhook, ctx context.Context) error {
+	eventTypes := make([]string, 0, len(webhook.EventTypes))
+	for _, eventType := range webhook.EventTypes {
+		eventTypes = append(eventTypes, string(eventType))
+	}
+	webhook.TenantID = domain.TenantFromContext(ctx)
+	query := `INSERT INTO webhooks (id, tenant_id, url, event_types, secret, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := r.conn.Exec(ctx, query, webhook.ID, webhook.TenantID, webhook.URL, eventTypes, webhook.Secret, webhook.CreatedAt)
+	return err
+}
+
+// FIND ALL
+func (r *WebhookRepository) FindAll(ctx context.Context) ([]domain.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE tenant_id=$1 ORDER BY created_at, id`
+	rows, err := r.conn.Query(ctx, query, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return collectWebhooks(rows)
+}
+
+// FIND BY ID
+func (r *WebhookRepository) FindByID(id string, ctx context.Context) (*domain.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id=$1 AND tenant_id=$2`
+	var webhook domain.Webhook
+	err := scanWebhook(r.conn.QueryRow(ctx, query, id, domain.TenantFromContext(ctx)), &webhook)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// DELETE
+func (r *WebhookRepository) Delete(id string, ctx context.Context) error {
+	tag, err := r.conn.Exec(ctx, `DELETE FROM webhooks WHERE id=$1 AND tenant_id=$2`, id, domain.TenantFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrWebhookNotFound
+	}
+	return nil
+}
+
+// FIND BY EVENT TYPE
+func (r *WebhookRepository) FindByEventType(eventType domain.EventType, ctx context.Context) ([]domain.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE tenant_id=$2 AND $1 = ANY(event_types)`
+	rows, err := r.conn.Query(ctx, query, string(eventType), domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return collectWebhooks(rows)
+}
+
+// SAVE DELIVERIES
+func (r *WebhookRepository) SaveDeliveries(deliveries []domain.WebhookDelivery, ctx context.Context) error {
+	tx, err := r.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tenantID := domain.TenantFromContext(ctx)
+	query := `INSERT INTO webhook_deliveries (id, tenant_id, webhook_id, event_type, payload, status, attempts, next_attempt_at, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+	for _, d := range deliveries {
+		if _, err := tx.Exec(ctx, query, d.ID, tenantID, d.WebhookID, d.EventType, d.Payload, d.Status, d.Attempts, d.NextAttemptAt, d.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// CLAIM DUE
+// SKIP LOCKED lets several instances work the queue without sending a
+// delivery twice.
+func (r *WebhookRepository) ClaimDue(now time.Time, leaseUntil time.Time, limit int, ctx context.Context) ([]domain.WebhookDelivery, error) {
+	query := `UPDATE webhook_deliveries SET next_attempt_at=$2
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries WHERE status=$3 AND next_attempt_at<=$1
+			ORDER BY next_attempt_at LIMIT $4 FOR UPDATE SKIP LOCKED
+		) RETURNING ` + deliveryColumns
+	rows, err := r.conn.Query(ctx, query, now, leaseUntil, domain.DeliveryPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectDeliveries(rows)
+}
+
+// UPDATE DELIVERY
+func (r *WebhookRepository) UpdateDelivery(delivery *domain.WebhookDelivery, ctx context.Context) error {
+	query := `UPDATE webhook_deliveries SET status=$2, attempts=$3, next_attempt_at=$4, last_error=$5, response_status=$6, delivered_at=$7 WHERE id=$1`
+	tag, err := r.conn.Exec(ctx, query, delivery.ID, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError, delivery.ResponseStatus, delivery.DeliveredAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
+
+// FIND DELIVERY
+func (r *WebhookRepository) FindDelivery(id string, ctx context.Context) (*domain.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id=$1 AND tenant_id=$2`
+	var delivery domain.WebhookDelivery
+	err := scanDelivery(r.conn.QueryRow(ctx, query, id, domain.TenantFromContext(ctx)), &delivery)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FIND DELIVERIES
+func (r *WebhookRepository) FindDeliveries(webhookID string, status domain.WebhookDeliveryStatus, limit int, ctx context.Context) ([]domain.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+		WHERE webhook_id=$1 AND tenant_id=$4 AND ($2 = '' OR status=$2) ORDER BY created_at DESC, id LIMIT $3`
+	rows, err := r.conn.Query(ctx, query, webhookID, string(status), limit, domain.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return collectDeliveries(rows)
+}
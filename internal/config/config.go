@@ -1,17 +1,73 @@
 package config
 
-import "os"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
 
 type Config struct {
-	DatabaseURL string
-	Port        string
+	DatabaseURL   string
+	Port          string
+	CostingMethod string
+	// SnapshotInterval is how often stock snapshots are taken; 0 disables them.
+	SnapshotInterval time.Duration
+	// RequireIfMatch makes product updates without an If-Match header fail with 428.
+	RequireIfMatch bool
+	// EventBufferSize is how many recent events are kept for resuming event streams.
+	EventBufferSize int
+	// WebhookInterval is how often due webhook deliveries are sent; 0 disables sending.
+	WebhookInterval time.Duration
+	// AuthEnabled makes every API route require an API key.
+	AuthEnabled bool
+	// PublicHealth and PublicSwagger keep /health and the Swagger UI open when auth is enabled.
+	PublicHealth  bool
+	PublicSwagger bool
+	// JWTSecret signs user tokens. A random one is used when it is empty, which
+	// logs everyone out on restart.
+	JWTSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// RateLimit is the default limit per client, like "300/1m"; "off" disables it.
+	RateLimit string
+	// RateLimitRoutes are limits for single routes, like "POST /orders=60/1m".
+	RateLimitRoutes string
+	// RateLimitIP is the limit per IP checked before credentials, like "1200/1m".
+	RateLimitIP string
+	// TrustProxy takes the client IP for rate limits and the access log from X-Forwarded-For.
+	TrustProxy bool
 }
 
-func LoadConfig() *Config {
-	return &Config{
-		DatabaseURL: getEnv("DATABASE_URL", ""),
-		Port:        getEnv("PORT", "8080"),
+// LoadConfig reads the configuration from the environment. Unset variables
+// take their defaults, but set ones that cannot be parsed are errors, so a
+// typo fails at startup instead of silently running with the default.
+func LoadConfig() (*Config, error) {
+	env := &envReader{}
+	config := &Config{
+		DatabaseURL:      getEnv("DATABASE_URL", ""),
+		Port:             getEnv("PORT", "8080"),
+		CostingMethod:    getEnv("COSTING_METHOD", "fifo"),
+		SnapshotInterval: env.getDuration("SNAPSHOT_INTERVAL", 24*time.Hour),
+		RequireIfMatch:   env.getBool("REQUIRE_IF_MATCH", false),
+		EventBufferSize:  env.getInt("EVENT_BUFFER_SIZE", 1000),
+		WebhookInterval:  env.getDuration("WEBHOOK_INTERVAL", 5*time.Second),
+		AuthEnabled:      env.getBool("AUTH_ENABLED", true),
+		PublicHealth:     env.getBool("PUBLIC_HEALTH", true),
+		PublicSwagger:    env.getBool("PUBLIC_SWAGGER", true),
+		JWTSecret:        getEnv("JWT_SECRET", ""),
+		AccessTokenTTL:   env.getDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:  env.getDuration("REFRESH_TOKEN_TTL", 7*24*time.Hour),
+		RateLimit:        getEnv("RATE_LIMIT", "300/1m"),
+		RateLimitRoutes:  getEnv("RATE_LIMIT_ROUTES", "POST /orders=60/1m"),
+		RateLimitIP:      getEnv("RATE_LIMIT_IP", "1200/1m"),
+		TrustProxy:       env.getBool("TRUST_PROXY", false),
 	}
+	if err := errors.Join(env.errs...); err != nil {
+		return nil, err
+	}
+	return config, nil
 }
 
 func getEnv(key, fallback string) string {
@@ -20,3 +76,48 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// envReader parses typed variables and collects an error for every one that
+// is set to an invalid value.
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) getDuration(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		e.errs = append(e.errs, fmt.Errorf("invalid %s %q, expected a non-negative duration like 30s", key, value))
+		return fallback
+	}
+	return duration
+}
+
+func (e *envReader) getBool(key string, fallback bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("invalid %s %q, expected true or false", key, value))
+		return fallback
+	}
+	return b
+}
+
+func (e *envReader) getInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		e.errs = append(e.errs, fmt.Errorf("invalid %s %q, expected a non-negative integer", key, value))
+		return fallback
+	}
+	return i
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TESTS
+func TestLoadConfig_SnapshotInterval(t *testing.T) {
+	tests := []struct {
+		value    string
+		interval time.Duration
+	}{
+		{value: "6h", interval: 6 * time.Hour},
+		{value: "0s", interval: 0},
+	}
+	for _, tt := range tests {
+		t.Setenv("SNAPSHOT_INTERVAL", tt.value)
+		config, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if config.SnapshotInterval != tt.interval {
+			t.Errorf("expected snapshot interval %v for %q, got %v", tt.interval, tt.value, config.SnapshotInterval)
+		}
+	}
+}
+
+func TestLoadConfig_InvalidValues(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+	}{
+		{key: "SNAPSHOT_INTERVAL", value: "daily"},
+		{key: "SNAPSHOT_INTERVAL", value: "24"},
+		{key: "SNAPSHOT_INTERVAL", value: "-1h"},
+		{key: "REQUIRE_IF_MATCH", value: "yes please"},
+		{key: "EVENT_BUFFER_SIZE", value: "lots"},
+		{key: "EVENT_BUFFER_SIZE", value: "-1"},
+		{key: "WEBHOOK_INTERVAL", value: "5"},
+		{key: "AUTH_ENABLED", value: "maybe"},
+		{key: "PUBLIC_HEALTH", value: "on please"},
+		{key: "PUBLIC_SWAGGER", value: ""},
+		{key: "ACCESS_TOKEN_TTL", value: "-1m"},
+		{key: "REFRESH_TOKEN_TTL", value: "7d"},
+		{key: "TRUST_PROXY", value: "proxy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+			if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), tt.key) {
+				t.Errorf("expected an error about %v, got %v", tt.key, err)
+			}
+		})
+	}
+}
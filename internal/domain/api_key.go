@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrInvalidAPIKey is returned when a key is unknown or revoked.
+var ErrInvalidAPIKey = errors.New("invalid or revoked api key")
+
+// Scope is a permission granted to an API key.
+type Scope string
+
+const (
+	ScopeProductsRead  Scope = "products:read"
+	ScopeProductsWrite Scope = "products:write"
+	ScopeOrdersWrite   Scope = "orders:write"
+	// ScopeAdmin grants every other scope as well.
+	ScopeAdmin Scope = "admin"
+)
+
+func (s Scope) Valid() bool {
+	switch s {
+	case ScopeProductsRead, ScopeProductsWrite, ScopeOrdersWrite, ScopeAdmin:
+		return true
+	}
+	return false
+}
+
+// APIKey authenticates API clients. Only the SHA-256 hash of the key is
+// stored; Prefix is its first characters so keys can be told apart.
+type APIKey struct {
+	ID         string     `json:"id"`
+	TenantID   string     `json:"tenant_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     []Scope    `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Allows reports whether the key grants the scope.
+func (k APIKey) Allows(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+// ExpectedReceipt is inbound stock announced for a date. Received tracks how
+// much of it has arrived through purchase order receipts; closed expected
+// receipts are not waited for any more.
+type ExpectedReceipt struct {
+	ID              string     `json:"id"`
+	ProductID       string     `json:"product_id"`
+	PurchaseOrderID string     `json:"purchase_order_id,omitempty"`
+	Quantity        int        `json:"quantity"`
+	Received        int        `json:"received"`
+	ExpectedAt      time.Time  `json:"expected_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ClosedAt        *time.Time `json:"closed_at,omitempty"`
+}
+
+func (e ExpectedReceipt) Open() int {
+	if e.ClosedAt != nil {
+		return 0
+	}
+	return e.Quantity - e.Received
+}
+
+// ATPPoint is the quantity available to promise from a date on.
+type ATPPoint struct {
+	Date      time.Time `json:"date"`
+	Available int       `json:"available"`
+}
+
+// AvailableToPromise answers when a quantity of a product can be fulfilled.
+// PromiseDate is empty when stock and expected receipts cannot cover it.
+// OnHand includes the Reserved units, which are not available. Overdue is the
+// open quantity of expected receipts whose date has passed; it is left out of
+// the schedule, as nobody knows when it arrives.
+type AvailableToPromise struct {
+	ProductID   string     `json:"product_id"`
+	Quantity    int        `json:"quantity"`
+	OnHand      int        `json:"on_hand"`
+	Reserved    int        `json:"reserved"`
+	Overdue     int        `json:"overdue"`
+	Promisable  bool       `json:"promisable"`
+	PromiseDate *time.Time `json:"promise_date"`
+	Shortfall   int        `json:"shortfall"`
+	Schedule    []ATPPoint `json:"schedule"`
+}
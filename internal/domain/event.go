@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// EventType names a change pushed to event stream subscribers.
+type EventType string
+
+const (
+	EventStockChanged EventType = "stock.changed"
+	EventOrderCreated EventType = "order.created"
+)
+
+// Event is a change published to event stream subscribers. IDs increase by
+// one per event, so a client can resume after the last event it saw. Only
+// subscribers of the tenant whose data changed get the event.
+type Event struct {
+	ID        uint64      `json:"id"`
+	TenantID  string      `json:"-"`
+	Type      EventType   `json:"type"`
+	ProductID string      `json:"product_id"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// StockChangedEvent is the data of a stock.changed event: the product's stock
+// buckets after the change.
+type StockChangedEvent struct {
+	ProductID   string `json:"product_id"`
+	Stock       int    `json:"stock"`
+	Reserved    int    `json:"reserved"`
+	Damaged     int    `json:"damaged"`
+	Quarantined int    `json:"quarantined"`
+	Version     int    `json:"version"`
+}
+
+// OrderCreatedEvent is the data of an order.created event.
+type OrderCreatedEvent struct {
+	OrderID   string    `json:"order_id"`
+	ProductID string    `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (t EventType) Valid() bool {
+	switch t {
+	case EventStockChanged, EventOrderCreated:
+		return true
+	}
+	return false
+}
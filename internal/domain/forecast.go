@@ -0,0 +1,48 @@
+package domain
+
+import "time"
+
+type ForecastMethod string
+
+const (
+	ForecastMovingAverage        ForecastMethod = "moving_average"
+	ForecastExponentialSmoothing ForecastMethod = "exponential_smoothing"
+)
+
+type ForecastParams struct {
+	Method ForecastMethod
+	// Days is how many days ahead to forecast, starting today.
+	Days int
+	// HistoryDays is how many complete days of order history to learn from.
+	HistoryDays int
+	// Window is the number of most recent days the moving average covers.
+	Window int
+	// Alpha is the exponential smoothing factor, between 0 and 1.
+	Alpha float64
+	// WeeklySeasonality scales the forecast by each weekday's share of demand.
+	WeeklySeasonality bool
+}
+
+// DailySales is the quantity and revenue ordered of a product on one day.
+type DailySales struct {
+	ProductID string
+	Day       time.Time
+	Quantity  int
+	Revenue   float64
+}
+
+type ForecastPoint struct {
+	Date     string  `json:"date"`
+	Quantity float64 `json:"quantity"`
+}
+
+type Forecast struct {
+	ProductID          string          `json:"product_id"`
+	Method             ForecastMethod  `json:"method"`
+	WeeklySeasonality  bool            `json:"weekly_seasonality"`
+	Stock              int             `json:"stock"`
+	AverageDailyDemand float64         `json:"average_daily_demand"`
+	TotalDemand        float64         `json:"total_demand"`
+	StockoutDate       string          `json:"stockout_date,omitempty"`
+	Points             []ForecastPoint `json:"points"`
+}
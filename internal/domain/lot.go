@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+// Lot is a batch of a lot-tracked product received under one lot number.
+type Lot struct {
+	ID             string     `json:"id"`
+	ProductID      string     `json:"product_id"`
+	LotNumber      string     `json:"lot_number"`
+	ManufacturedAt *time.Time `json:"manufactured_at"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	Quantity       int        `json:"quantity"`
+	Remaining      int        `json:"remaining"`
+	ReceivedAt     time.Time  `json:"received_at"`
+	// Quarantined lots are kept out of allocation until released.
+	Quarantined bool `json:"quarantined"`
+}
+
+// Expired reports whether the lot can no longer be sold at the given time.
+func (l Lot) Expired(at time.Time) bool {
+	return l.ExpiresAt != nil && !l.ExpiresAt.After(at)
+}
+
+// LotAllocation is the quantity of an order taken from one lot.
+type LotAllocation struct {
+	OrderID   string     `json:"order_id"`
+	LotID     string     `json:"lot_id"`
+	LotNumber string     `json:"lot_number"`
+	Quantity  int        `json:"quantity"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// LotTraceOrder is an order that consumed units of a traced lot.
+type LotTraceOrder struct {
+	OrderID   string    `json:"order_id"`
+	ProductID string    `json:"product_id"`
+	LotID     string    `json:"lot_id"`
+	Customer  string    `json:"customer"`
+	Quantity  int       `json:"quantity"`
+	OrderedAt time.Time `json:"ordered_at"`
+}
+
+// LotTrace shows where the units of a lot number went. The same lot number
+// can exist for several products, so it may cover more than one lot.
+type LotTrace struct {
+	LotNumber string          `json:"lot_number"`
+	Lots      []Lot           `json:"lots"`
+	Orders    []LotTraceOrder `json:"orders"`
+	Shipped   int             `json:"shipped"`
+	OnHand    int             `json:"on_hand"`
+}
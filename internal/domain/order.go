@@ -1,8 +1,21 @@
 package domain
 
+import "time"
+
 type Order struct {
-	ID         string  `json:"id"`
-	ProductID  string  `json:"product_id"`
-	Quantity   int     `json:"quantity"`
-	TotalPrice float64 `json:"total_price"`
+	ID        string `json:"id"`
+	TenantID  string `json:"tenant_id"`
+	ProductID string `json:"product_id"`
+	// Quantity is in base units. Orders placed in another unit set Unit and
+	// UnitQuantity, and Quantity is derived from them.
+	Quantity     int           `json:"quantity"`
+	Unit         UnitOfMeasure `json:"unit,omitempty"`
+	UnitQuantity float64       `json:"unit_quantity,omitempty"`
+	TotalPrice   float64       `json:"total_price"`
+	Customer     string        `json:"customer"`
+	CreatedAt    time.Time     `json:"created_at"`
+	// Allocations lists the lots a lot-tracked product was shipped from.
+	Allocations []LotAllocation `json:"allocations,omitempty"`
+	// Serials are the units of a serialized product shipped on the order.
+	Serials []string `json:"serials,omitempty"`
 }
@@ -1,8 +1,49 @@
 package domain
 
+import "errors"
+
+// InventoryPolicy says how a product's stock limits orders.
+type InventoryPolicy string
+
+const (
+	// PolicyTracked products can only be ordered from stock on hand.
+	PolicyTracked InventoryPolicy = "tracked"
+	// PolicyUntracked products (services, digital goods) keep no stock.
+	PolicyUntracked InventoryPolicy = "untracked"
+	// PolicyAllowNegative products keep stock but may be oversold below zero.
+	PolicyAllowNegative InventoryPolicy = "allow_negative"
+)
+
+func (p InventoryPolicy) Valid() bool {
+	switch p {
+	case PolicyTracked, PolicyUntracked, PolicyAllowNegative:
+		return true
+	}
+	return false
+}
+
+// ErrVersionConflict is returned when a product changed since the version a
+// conditional update was based on.
+var ErrVersionConflict = errors.New("product was modified by another request")
+
 type Product struct {
-	ID    string  `json:"id"`
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
-	Stock int     `json:"stock"`
+	ID       string  `json:"id"`
+	TenantID string  `json:"tenant_id"`
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Stock    int     `json:"stock"`
+	// Units held back from sale, by bucket.
+	Reserved        int `json:"reserved"`
+	Damaged         int `json:"damaged"`
+	Quarantined     int `json:"quarantined"`
+	ReorderPoint    int `json:"reorder_point"`
+	ReorderQuantity int `json:"reorder_quantity"`
+	// LotTracked products keep their stock in lots and are allocated first-expiry-first-out.
+	LotTracked bool `json:"lot_tracked"`
+	// Serialized products carry a unique serial number per unit.
+	Serialized bool `json:"serialized"`
+	// InventoryPolicy defaults to tracked.
+	InventoryPolicy InventoryPolicy `json:"inventory_policy"`
+	// Version is increased on every change to the product.
+	Version int `json:"version"`
 }
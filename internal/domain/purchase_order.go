@@ -0,0 +1,59 @@
+package domain
+
+import "time"
+
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderDraft             PurchaseOrderStatus = "draft"
+	PurchaseOrderSent              PurchaseOrderStatus = "sent"
+	PurchaseOrderPartiallyReceived PurchaseOrderStatus = "partially_received"
+	PurchaseOrderClosed            PurchaseOrderStatus = "closed"
+)
+
+type PurchaseOrder struct {
+	ID         string              `json:"id"`
+	SupplierID string              `json:"supplier_id"`
+	Status     PurchaseOrderStatus `json:"status"`
+	Lines      []PurchaseOrderLine `json:"lines"`
+	CreatedAt  time.Time           `json:"created_at"`
+}
+
+type PurchaseOrderLine struct {
+	ID               string  `json:"id"`
+	PurchaseOrderID  string  `json:"purchase_order_id"`
+	ProductID        string  `json:"product_id"`
+	Quantity         int     `json:"quantity"`
+	ReceivedQuantity int     `json:"received_quantity"`
+	UnitCost         float64 `json:"unit_cost"`
+}
+
+// Remaining returns the quantity that is still expected from the supplier.
+func (l PurchaseOrderLine) Remaining() int {
+	return l.Quantity - l.ReceivedQuantity
+}
+
+// Receipt records goods received against a purchase order.
+type Receipt struct {
+	ID              string        `json:"id"`
+	PurchaseOrderID string        `json:"purchase_order_id"`
+	Lines           []ReceiptLine `json:"lines"`
+	ReceivedAt      time.Time     `json:"received_at"`
+}
+
+type ReceiptLine struct {
+	ID                  string `json:"id"`
+	PurchaseOrderLineID string `json:"line_id"`
+	ProductID           string `json:"product_id"`
+	// Quantity is in base units, derived from Unit and UnitQuantity when the
+	// line is received in another unit.
+	Quantity     int           `json:"quantity"`
+	Unit         UnitOfMeasure `json:"unit,omitempty"`
+	UnitQuantity float64       `json:"unit_quantity,omitempty"`
+	// Lot details are required for lot-tracked products.
+	LotNumber      string     `json:"lot_number,omitempty"`
+	ManufacturedAt *time.Time `json:"manufactured_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	// Serials are required for serialized products, one per received unit.
+	Serials []string `json:"serials,omitempty"`
+}
@@ -0,0 +1,21 @@
+package domain
+
+type ReplenishmentParams struct {
+	// WindowDays is how many days of order history the sales velocity is based on.
+	WindowDays int
+	// CoverageDays is how many days of sales a reorder should cover beyond the lead time.
+	CoverageDays int
+}
+
+type ReplenishmentSuggestion struct {
+	ProductID         string  `json:"product_id"`
+	ProductName       string  `json:"product_name"`
+	SupplierID        string  `json:"supplier_id"`
+	Stock             int     `json:"stock"`
+	Inbound           int     `json:"inbound"`
+	ReorderPoint      int     `json:"reorder_point"`
+	DailySales        float64 `json:"daily_sales"`
+	LeadTimeDays      int     `json:"lead_time_days"`
+	UnitCost          float64 `json:"unit_cost"`
+	SuggestedQuantity int     `json:"suggested_quantity"`
+}
@@ -0,0 +1,107 @@
+package domain
+
+import "time"
+
+type DemandPeriod string
+
+const (
+	PeriodDay  DemandPeriod = "day"
+	PeriodWeek DemandPeriod = "week"
+)
+
+type ClassificationParams struct {
+	From time.Time
+	To   time.Time
+	// A and B are the cumulative revenue shares closing the A and B classes,
+	// e.g. 0.8 and 0.95.
+	AThreshold float64
+	BThreshold float64
+	// X and Y are the highest coefficients of variation of demand allowed in
+	// the X and Y classes, e.g. 0.5 and 1.0.
+	XThreshold float64
+	YThreshold float64
+	// Period is the bucket demand variability is measured over.
+	Period DemandPeriod
+}
+
+type ProductClassification struct {
+	ProductID              string  `json:"product_id"`
+	ProductName            string  `json:"product_name"`
+	Revenue                float64 `json:"revenue"`
+	RevenueShare           float64 `json:"revenue_share"`
+	CumulativeShare        float64 `json:"cumulative_share"`
+	ABC                    string  `json:"abc"`
+	DemandMean             float64 `json:"demand_mean"`
+	DemandStdDev           float64 `json:"demand_std_dev"`
+	CoefficientOfVariation float64 `json:"coefficient_of_variation"`
+	XYZ                    string  `json:"xyz"`
+	Class                  string  `json:"class"`
+}
+
+type ClassificationReport struct {
+	From         time.Time               `json:"from"`
+	To           time.Time               `json:"to"`
+	Period       DemandPeriod            `json:"period"`
+	TotalRevenue float64                 `json:"total_revenue"`
+	Products     []ProductClassification `json:"products"`
+}
+
+type KPIParams struct {
+	From time.Time
+	To   time.Time
+	// DeadStockDays is how long a product with stock must go without sales
+	// to count as dead stock.
+	DeadStockDays int
+}
+
+type InventoryKPI struct {
+	UnitsSold     int     `json:"units_sold"`
+	Revenue       float64 `json:"revenue"`
+	OpeningStock  int     `json:"opening_stock"`
+	ClosingStock  int     `json:"closing_stock"`
+	UnitsReceived int     `json:"units_received"`
+	AverageStock  float64 `json:"average_stock"`
+	// Turnover is units sold divided by the average stock over the range.
+	Turnover float64 `json:"turnover"`
+	// DaysOfSupply is how long the closing stock lasts at the range's sales
+	// rate; null when nothing was sold.
+	DaysOfSupply *float64 `json:"days_of_supply"`
+	// SellThroughRate is units sold divided by opening stock plus units received.
+	SellThroughRate float64 `json:"sell_through_rate"`
+}
+
+type ProductKPI struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	InventoryKPI
+}
+
+type DeadStockItem struct {
+	ProductID   string     `json:"product_id"`
+	ProductName string     `json:"product_name"`
+	Stock       int        `json:"stock"`
+	LastSoldAt  *time.Time `json:"last_sold_at"`
+}
+
+type InventoryKPIReport struct {
+	From          time.Time       `json:"from"`
+	To            time.Time       `json:"to"`
+	DeadStockDays int             `json:"dead_stock_days"`
+	Overall       InventoryKPI    `json:"overall"`
+	Products      []ProductKPI    `json:"products"`
+	DeadStock     []DeadStockItem `json:"dead_stock"`
+}
+
+// NegativeStockItem is a product oversold below zero.
+type NegativeStockItem struct {
+	ProductID       string          `json:"product_id"`
+	ProductName     string          `json:"product_name"`
+	Stock           int             `json:"stock"`
+	InventoryPolicy InventoryPolicy `json:"inventory_policy"`
+}
+
+type NegativeStockReport struct {
+	AsOf      time.Time           `json:"as_of"`
+	Products  []NegativeStockItem `json:"products"`
+	Shortfall int                 `json:"shortfall"`
+}
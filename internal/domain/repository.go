@@ -1,16 +1,204 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
+// Transactor runs service work that has to happen completely or not at all.
+// The repository calls fn makes with the ctx it is given share one
+// transaction, which commits when fn returns nil and rolls back otherwise;
+// inside a running transaction fn just joins it.
+type Transactor interface {
+	WithinTx(fn func(ctx context.Context) error, ctx context.Context) error
+	// WithinSnapshot is WithinTx at REPEATABLE READ, so every read of fn sees
+	// the data as of the first one.
+	WithinSnapshot(fn func(ctx context.Context) error, ctx context.Context) error
+}
+
+// ProductRepository and OrderRepository only read and change the data of the
+// tenant of ctx (see WithTenant), so one tenant cannot see or touch another's
+// products and orders even by ID.
 type ProductRepository interface {
 	Save(product *Product, ctx context.Context) error
 	FindAll(ctx context.Context) ([]Product, error)
 	FindByID(id string, ctx context.Context) (*Product, error)
-	UpdateStock(id string, stockQuantity int, ctx context.Context) (*Product, error)
+	// UpdateStock, UpdateReorderSettings, MoveStock and SaveUnit only apply
+	// when the product is still at version, failing with ErrVersionConflict
+	// otherwise; version 0 applies them unconditionally.
+	UpdateStock(id string, stockQuantity int, version int, ctx context.Context) (*Product, error)
+	IncreaseStock(id string, stockQuantity int, ctx context.Context) (*Product, error)
+	UpdateReorderSettings(id string, reorderPoint int, reorderQuantity int, version int, ctx context.Context) (*Product, error)
+	// MoveStock moves units between buckets, failing when the source bucket
+	// holds fewer units than the quantity.
+	MoveStock(id string, from StockBucket, to StockBucket, quantity int, version int, ctx context.Context) (*Product, error)
+	// SaveUnit creates or updates a unit of measure of a product and moves the
+	// product to its next version.
+	SaveUnit(unit *ProductUnit, version int, ctx context.Context) (*Product, error)
+	FindUnits(productID string, ctx context.Context) ([]ProductUnit, error)
 }
 
 type OrderRepository interface {
 	Save(order *Order, ctx context.Context) error
 	FindAll(ctx context.Context) ([]Order, error)
 	FindByID(id string, ctx context.Context) (*Order, error)
+	// SalesByProduct returns the ordered quantity per product since the given time.
+	SalesByProduct(since time.Time, ctx context.Context) (map[string]int, error)
+	// DailySales returns the days in [from, to) on which the product was ordered, oldest first.
+	DailySales(productID string, from time.Time, to time.Time, ctx context.Context) ([]DailySales, error)
+	// DailySalesAll returns the daily sales of every product in [from, to).
+	DailySalesAll(from time.Time, to time.Time, ctx context.Context) ([]DailySales, error)
+	// LastSaleDates returns when each product was last ordered before the given time.
+	LastSaleDates(before time.Time, ctx context.Context) (map[string]time.Time, error)
+}
+
+type SupplierRepository interface {
+	Save(supplier *Supplier, ctx context.Context) error
+	FindAll(ctx context.Context) ([]Supplier, error)
+	FindByID(id string, ctx context.Context) (*Supplier, error)
+	SaveProduct(link *SupplierProduct, ctx context.Context) error
+	FindProducts(supplierID string, ctx context.Context) ([]SupplierProduct, error)
+	FindAllProducts(ctx context.Context) ([]SupplierProduct, error)
+}
+
+type PurchaseOrderRepository interface {
+	Save(purchaseOrder *PurchaseOrder, ctx context.Context) error
+	FindAll(ctx context.Context) ([]PurchaseOrder, error)
+	FindByID(id string, ctx context.Context) (*PurchaseOrder, error)
+	// FindByIDForUpdate is FindByID that locks the order for the rest of the
+	// transaction.
+	FindByIDForUpdate(id string, ctx context.Context) (*PurchaseOrder, error)
+	UpdateStatus(id string, status PurchaseOrderStatus, ctx context.Context) error
+	// SaveReceipt stores the receipt, books it on the order lines and returns
+	// the status the order moved to by the stored line totals.
+	SaveReceipt(receipt *Receipt, ctx context.Context) (PurchaseOrderStatus, error)
+	// OpenQuantities returns the quantity per product still to be received on
+	// purchase orders that are not closed.
+	OpenQuantities(ctx context.Context) (map[string]int, error)
+}
+
+type CostLayerRepository interface {
+	SaveLayer(layer *CostLayer, ctx context.Context) error
+	// FindOpenLayers returns the layers of a product with remaining quantity,
+	// oldest first, and locks them for the rest of the transaction.
+	FindOpenLayers(productID string, ctx context.Context) ([]CostLayer, error)
+	// Consume stores the consumptions and takes their quantities off the layers.
+	Consume(consumptions []CostConsumption, ctx context.Context) error
+	// FindConsumptions returns what an order took out of the layers of a product.
+	FindConsumptions(orderID string, productID string, ctx context.Context) ([]CostConsumption, error)
+	// ProductTotals returns the valuation totals of one product up to now.
+	ProductTotals(productID string, ctx context.Context) (*CostTotals, error)
+	// Totals returns the valuation totals of the tenant's products.
+	Totals(asOf time.Time, ctx context.Context) ([]CostTotals, error)
+}
+
+type StockHistoryRepository interface {
+	SaveMovement(movement *StockMovement, ctx context.Context) error
+	// StockAt rebuilds the stock of one of the tenant's products at asOf from
+	// the latest snapshot taken before it plus the movements since.
+	StockAt(productID string, asOf time.Time, ctx context.Context) (int, error)
+	// StockAtAll returns the stock of the tenant's products at asOf.
+	StockAtAll(asOf time.Time, ctx context.Context) ([]StockLevel, error)
+	// StockBeforeAll is StockAtAll without the movements made at before itself.
+	StockBeforeAll(before time.Time, ctx context.Context) ([]StockLevel, error)
+	// SaveSnapshot stores the stock of the tenant's products at takenAt and returns how many were stored.
+	SaveSnapshot(takenAt time.Time, ctx context.Context) (int, error)
+	// SaveSnapshotAll is SaveSnapshot for the products of every tenant.
+	SaveSnapshotAll(takenAt time.Time, ctx context.Context) (int, error)
+	// MovementTotals sums the movements with the given reason per product in [from, to).
+	MovementTotals(reason StockMovementReason, from time.Time, to time.Time, ctx context.Context) (map[string]int, error)
+	// TotalsExcluding sums all movements per product except those with the given reason.
+	TotalsExcluding(reason StockMovementReason, ctx context.Context) (map[string]int, error)
+}
+
+type LotRepository interface {
+	// Receive adds the lot's quantity to the product's lot with the same
+	// number, creating it if needed, and returns the stored lot.
+	Receive(lot *Lot, ctx context.Context) (*Lot, error)
+	FindByProduct(productID string, ctx context.Context) ([]Lot, error)
+	// FindAllocatable returns the lots of a product with remaining stock that
+	// have not expired at the given time, first expiry first, and locks them
+	// for the rest of the transaction.
+	FindAllocatable(productID string, at time.Time, ctx context.Context) ([]Lot, error)
+	// Allocate stores the allocations and takes their quantities off the lots.
+	Allocate(allocations []LotAllocation, ctx context.Context) error
+	FindAllocations(orderID string, ctx context.Context) ([]LotAllocation, error)
+	// FindByNumber returns the lots with the lot number, of one product when
+	// productID is not empty.
+	FindByNumber(lotNumber string, productID string, ctx context.Context) ([]Lot, error)
+	// FindOrders returns the orders allocated to the given lots.
+	FindOrders(lotIDs []string, ctx context.Context) ([]LotTraceOrder, error)
+	// SetQuarantined sets the quarantine flag of the lots and returns the lots
+	// whose flag changed.
+	SetQuarantined(lotIDs []string, quarantined bool, ctx context.Context) ([]Lot, error)
+}
+
+type SerialRepository interface {
+	// Save stores newly received serials with their received events.
+	Save(serials []Serial, events []SerialEvent, ctx context.Context) error
+	FindByNumber(serialNumber string, ctx context.Context) (*Serial, error)
+	FindEvents(serialNumber string, ctx context.Context) ([]SerialEvent, error)
+	// FindByOrder returns the serials sold on the order.
+	FindByOrder(orderID string, ctx context.Context) ([]string, error)
+	// Transition moves every serial of the events from one status to another
+	// and stores the events. It fails without changes when any serial is not
+	// in the from status.
+	Transition(events []SerialEvent, from SerialStatus, to SerialStatus, ctx context.Context) error
+}
+
+type ExpectedReceiptRepository interface {
+	Save(expectedReceipt *ExpectedReceipt, ctx context.Context) error
+	// FindOpen returns the expected receipts neither fully received nor
+	// closed, of one product when productID is not empty, earliest first.
+	FindOpen(productID string, ctx context.Context) ([]ExpectedReceipt, error)
+	// Receive books received units against the open expected receipts of the
+	// purchase order and product, earliest first.
+	Receive(purchaseOrderID string, productID string, quantity int, ctx context.Context) error
+	// Close closes an open expected receipt at closedAt.
+	Close(id string, closedAt time.Time, ctx context.Context) (*ExpectedReceipt, error)
+}
+
+// WebhookRepository keeps webhooks and deliveries per tenant of ctx. ClaimDue
+// and UpdateDelivery work across tenants for the delivery job, so deliveries
+// carry their tenant.
+type WebhookRepository interface {
+	Save(webhook *Webhook, ctx context.Context) error
+	FindAll(ctx context.Context) ([]Webhook, error)
+	FindByID(id string, ctx context.Context) (*Webhook, error)
+	// Delete removes the webhook together with its deliveries.
+	Delete(id string, ctx context.Context) error
+	// FindByEventType returns the webhooks subscribed to the event type.
+	FindByEventType(eventType EventType, ctx context.Context) ([]Webhook, error)
+	SaveDeliveries(deliveries []WebhookDelivery, ctx context.Context) error
+	// ClaimDue returns up to limit pending deliveries due at now and moves
+	// their next attempt to leaseUntil, so concurrent workers skip them while
+	// they are being sent.
+	ClaimDue(now time.Time, leaseUntil time.Time, limit int, ctx context.Context) ([]WebhookDelivery, error)
+	UpdateDelivery(delivery *WebhookDelivery, ctx context.Context) error
+	FindDelivery(id string, ctx context.Context) (*WebhookDelivery, error)
+	// FindDeliveries returns the webhook's deliveries newest first, only those
+	// in status when it is not empty.
+	FindDeliveries(webhookID string, status WebhookDeliveryStatus, limit int, ctx context.Context) ([]WebhookDelivery, error)
+}
+
+// APIKeyRepository and UserRepository keep keys and users per tenant of ctx,
+// except FindByHash and FindByEmail, which find the tenant of a credential.
+type APIKeyRepository interface {
+	Save(apiKey *APIKey, ctx context.Context) error
+	// FindAll returns all keys, revoked ones included.
+	FindAll(ctx context.Context) ([]APIKey, error)
+	// FindByHash fails with ErrInvalidAPIKey when no key has the hash.
+	FindByHash(hash string, ctx context.Context) (*APIKey, error)
+	// Revoke marks an active key as revoked at revokedAt.
+	Revoke(id string, revokedAt time.Time, ctx context.Context) error
+	UpdateLastUsed(id string, lastUsedAt time.Time, ctx context.Context) error
+}
+
+type UserRepository interface {
+	Save(user *User, ctx context.Context) error
+	FindAll(ctx context.Context) ([]User, error)
+	FindByID(id string, ctx context.Context) (*User, error)
+	// FindByEmail fails with ErrInvalidCredentials when no user has the email.
+	FindByEmail(email string, ctx context.Context) (*User, error)
+	UpdateRole(id string, role Role, ctx context.Context) error
 }
@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+type SerialStatus string
+
+const (
+	SerialInStock SerialStatus = "in_stock"
+	SerialSold    SerialStatus = "sold"
+)
+
+type SerialEventType string
+
+const (
+	SerialEventReceived SerialEventType = "received"
+	SerialEventSold     SerialEventType = "sold"
+	SerialEventReturned SerialEventType = "returned"
+)
+
+// Serial is one unit of a serialized product.
+type Serial struct {
+	SerialNumber string       `json:"serial_number"`
+	ProductID    string       `json:"product_id"`
+	Status       SerialStatus `json:"status"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// SerialEvent is one step in a serial's history. ReferenceID is the receipt
+// line for received events and the order for sold and returned events.
+type SerialEvent struct {
+	ID           string          `json:"id"`
+	SerialNumber string          `json:"serial_number"`
+	ProductID    string          `json:"product_id"`
+	Event        SerialEventType `json:"event"`
+	ReferenceID  string          `json:"reference_id"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+type SerialHistory struct {
+	Serial
+	Events []SerialEvent `json:"events"`
+}
@@ -0,0 +1,28 @@
+package domain
+
+// StockBucket is a status a product's units can be in. Only available units
+// can be sold; Product.Stock is the available bucket.
+type StockBucket string
+
+const (
+	BucketAvailable   StockBucket = "available"
+	BucketReserved    StockBucket = "reserved"
+	BucketDamaged     StockBucket = "damaged"
+	BucketQuarantined StockBucket = "quarantined"
+)
+
+func (b StockBucket) Valid() bool {
+	switch b {
+	case BucketAvailable, BucketReserved, BucketDamaged, BucketQuarantined:
+		return true
+	}
+	return false
+}
+
+// StockMove moves units of a product from one bucket to another, e.g. to
+// quarantine stock that failed QC or to release it after inspection.
+type StockMove struct {
+	From     StockBucket `json:"from"`
+	To       StockBucket `json:"to"`
+	Quantity int         `json:"quantity"`
+}
@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+type StockMovementReason string
+
+const (
+	MovementInitial    StockMovementReason = "initial"
+	MovementOrder      StockMovementReason = "order"
+	MovementReceipt    StockMovementReason = "receipt"
+	MovementAdjustment StockMovementReason = "adjustment"
+	MovementReturn     StockMovementReason = "return"
+	// MovementTransfer moves units into or out of the available bucket.
+	MovementTransfer StockMovementReason = "transfer"
+	// MovementReconciliation books stock drift found by the consistency check.
+	MovementReconciliation StockMovementReason = "reconciliation"
+)
+
+// StockMovement is one change of a product's stock. Quantity is signed:
+// positive movements add stock, negative ones remove it.
+type StockMovement struct {
+	ID          string              `json:"id"`
+	ProductID   string              `json:"product_id"`
+	Quantity    int                 `json:"quantity"`
+	Reason      StockMovementReason `json:"reason"`
+	ReferenceID string              `json:"reference_id"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// StockLevel is the stock of a product at a point in time.
+type StockLevel struct {
+	ProductID string    `json:"product_id"`
+	Stock     int       `json:"stock"`
+	AsOf      time.Time `json:"as_of"`
+}
+
+// StockDiscrepancy is a product whose stock differs from what its history explains.
+type StockDiscrepancy struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Expected    int    `json:"expected"`
+	Actual      int    `json:"actual"`
+	Difference  int    `json:"difference"`
+}
+
+type StockCheckReport struct {
+	CheckedAt     time.Time          `json:"checked_at"`
+	Products      int                `json:"products"`
+	Applied       bool               `json:"applied"`
+	Discrepancies []StockDiscrepancy `json:"discrepancies"`
+}
@@ -0,0 +1,16 @@
+package domain
+
+type Supplier struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+// SupplierProduct links a product to a supplier that can deliver it.
+type SupplierProduct struct {
+	SupplierID   string  `json:"supplier_id"`
+	ProductID    string  `json:"product_id"`
+	UnitCost     float64 `json:"unit_cost"`
+	LeadTimeDays int     `json:"lead_time_days"`
+}
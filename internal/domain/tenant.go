@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"context"
+	"regexp"
+)
+
+// DefaultTenant owns the data from before tenants existed and the requests
+// that name no tenant.
+const DefaultTenant = "default"
+
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ValidTenant reports whether id can name a tenant: lowercase letters,
+// digits, dashes and underscores, up to 63 characters.
+func ValidTenant(id string) bool {
+	return tenantPattern.MatchString(id)
+}
+
+type tenantContextKey struct{}
+
+// WithTenant scopes the repository calls made with the returned context to
+// the tenant.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant of ctx, or DefaultTenant when none was set.
+func TenantFromContext(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(tenantContextKey{}).(string); ok && tenantID != "" {
+		return tenantID
+	}
+	return DefaultTenant
+}
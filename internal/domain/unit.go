@@ -0,0 +1,27 @@
+package domain
+
+type UnitOfMeasure string
+
+const (
+	// UnitEach is the base unit stock is kept in.
+	UnitEach   UnitOfMeasure = "each"
+	UnitPack   UnitOfMeasure = "pack"
+	UnitCase   UnitOfMeasure = "case"
+	UnitPallet UnitOfMeasure = "pallet"
+)
+
+func (u UnitOfMeasure) Valid() bool {
+	switch u {
+	case UnitEach, UnitPack, UnitCase, UnitPallet:
+		return true
+	}
+	return false
+}
+
+// ProductUnit is a unit a product is bought or sold in, with the number of
+// base units it holds.
+type ProductUnit struct {
+	ProductID string        `json:"product_id"`
+	Unit      UnitOfMeasure `json:"unit"`
+	Factor    int           `json:"factor"`
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidCredentials is returned when a login's email or password is wrong.
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrInvalidToken is returned for malformed, forged or expired tokens.
+	ErrInvalidToken = errors.New("invalid or expired token")
+)
+
+// Role is a user's job, which decides what they may do.
+type Role string
+
+const (
+	// RoleViewer may only read.
+	RoleViewer Role = "viewer"
+	// RoleClerk may also book orders.
+	RoleClerk Role = "clerk"
+	// RoleManager may also change products, stock, suppliers and purchase orders.
+	RoleManager Role = "manager"
+	// RoleAdmin may do everything, including managing users, keys and webhooks.
+	RoleAdmin Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleViewer, RoleClerk, RoleManager, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// Scopes returns the permissions of the role, in the scopes API keys use.
+func (r Role) Scopes() []Scope {
+	switch r {
+	case RoleViewer:
+		return []Scope{ScopeProductsRead}
+	case RoleClerk:
+		return []Scope{ScopeProductsRead, ScopeOrdersWrite}
+	case RoleManager:
+		return []Scope{ScopeProductsRead, ScopeOrdersWrite, ScopeProductsWrite}
+	case RoleAdmin:
+		return []Scope{ScopeAdmin}
+	}
+	return nil
+}
+
+// Allows reports whether the role grants the scope.
+func (r Role) Allows(scope Scope) bool {
+	for _, s := range r.Scopes() {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// User is a staff member who logs in with email and password. Emails are
+// unique across tenants, so a login finds the user's tenant.
+type User struct {
+	ID           string    `json:"id"`
+	TenantID     string    `json:"tenant_id"`
+	Email        string    `json:"email"`
+	Name         string    `json:"name"`
+	PasswordHash string    `json:"-"`
+	Role         Role      `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TokenPair is issued on login. The access token authenticates requests; the
+// longer-lived refresh token is exchanged for a new pair.
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
@@ -0,0 +1,62 @@
+package domain
+
+import "time"
+
+type CostingMethod string
+
+const (
+	CostingFIFO            CostingMethod = "fifo"
+	CostingWeightedAverage CostingMethod = "average"
+)
+
+func (m CostingMethod) Valid() bool {
+	return m == CostingFIFO || m == CostingWeightedAverage
+}
+
+// CostLayer is a quantity of a product received at one unit cost.
+type CostLayer struct {
+	ID            string    `json:"id"`
+	ProductID     string    `json:"product_id"`
+	ReceiptLineID string    `json:"receipt_line_id"`
+	Quantity      int       `json:"quantity"`
+	Remaining     int       `json:"remaining"`
+	UnitCost      float64   `json:"unit_cost"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// CostConsumption records units taken out of a cost layer by an order, at the
+// unit cost the costing method assigned to them.
+type CostConsumption struct {
+	ID        string    `json:"id"`
+	LayerID   string    `json:"layer_id"`
+	ProductID string    `json:"product_id"`
+	OrderID   string    `json:"order_id"`
+	Quantity  int       `json:"quantity"`
+	UnitCost  float64   `json:"unit_cost"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CostTotals sums the received and consumed cost layers of a product up to a point in time.
+type CostTotals struct {
+	ProductID        string
+	ProductName      string
+	ReceivedQuantity int
+	ReceivedValue    float64
+	ConsumedQuantity int
+	ConsumedValue    float64
+}
+
+type ProductValuation struct {
+	ProductID   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Quantity    int     `json:"quantity"`
+	UnitCost    float64 `json:"unit_cost"`
+	TotalValue  float64 `json:"total_value"`
+}
+
+type ValuationReport struct {
+	Method     CostingMethod      `json:"method"`
+	AsOf       time.Time          `json:"as_of"`
+	Products   []ProductValuation `json:"products"`
+	TotalValue float64            `json:"total_value"`
+}
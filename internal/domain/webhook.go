@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrWebhookNotFound and ErrWebhookDeliveryNotFound are returned for webhooks
+// and deliveries that do not exist, e.g. because the webhook was deleted.
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// Webhook is an endpoint that is sent the events of the subscribed types.
+// Deliveries are signed with Secret.
+type Webhook struct {
+	ID         string      `json:"id"`
+	TenantID   string      `json:"tenant_id"`
+	URL        string      `json:"url"`
+	EventTypes []EventType `json:"event_types"`
+	Secret     string      `json:"-"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// Subscribed reports whether the webhook wants events of the given type.
+func (w Webhook) Subscribed(eventType EventType) bool {
+	for _, t := range w.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"
+	DeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// DeliveryDead deliveries ran out of attempts and are only retried on request.
+	DeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+func (s WebhookDeliveryStatus) Valid() bool {
+	switch s {
+	case DeliveryPending, DeliveryDelivered, DeliveryDead:
+		return true
+	}
+	return false
+}
+
+// WebhookDelivery is one event queued for one webhook, with the outcome of
+// its latest attempt.
+type WebhookDelivery struct {
+	ID             string                `json:"id"`
+	TenantID       string                `json:"tenant_id"`
+	WebhookID      string                `json:"webhook_id"`
+	EventType      EventType             `json:"event_type"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	LastError      string                `json:"last_error,omitempty"`
+	ResponseStatus int                   `json:"response_status,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+type requestIDContextKey struct{}
+
+// WithRequestID returns a copy of ctx that carries the ID of the request it
+// belongs to.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID of ctx, or "" outside a request.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// ContextHandler adds the request ID of the context to every record, so the
+// lines logged with slog.InfoContext and friends while serving a request can
+// be tied to it. The request ID stays at the top level of the record: groups
+// and attributes added to the handler are replayed on top of it.
+type ContextHandler struct {
+	slog.Handler
+	root slog.Handler
+	ops  []func(slog.Handler) slog.Handler
+}
+
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: handler, root: handler}
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	id := RequestIDFromContext(ctx)
+	if id == "" {
+		return h.Handler.Handle(ctx, record)
+	}
+	handler := h.root.WithAttrs([]slog.Attr{slog.String("request_id", id)})
+	for _, op := range h.ops {
+		handler = op(handler)
+	}
+	return handler.Handle(ctx, record)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithAttrs(attrs) })
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(handler slog.Handler) slog.Handler { return handler.WithGroup(name) })
+}
+
+// with returns a handler that applies op after the operations of h.
+func (h *ContextHandler) with(op func(slog.Handler) slog.Handler) *ContextHandler {
+	ops := make([]func(slog.Handler) slog.Handler, 0, len(h.ops)+1)
+	ops = append(append(ops, h.ops...), op)
+	return &ContextHandler{Handler: op(h.Handler), root: h.root, ops: ops}
+}
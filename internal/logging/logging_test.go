@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"testing"
+)
+
+// logLine logs msg through the logger built by fn over a ContextHandler and
+// returns the decoded JSON line.
+func logLine(t *testing.T, ctx context.Context, fn func(logger *slog.Logger) *slog.Logger) map[string]any {
+	t.Helper()
+	var buf bytes.Buffer
+	handler := NewContextHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey) {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	fn(slog.New(handler)).InfoContext(ctx, "msg", "key", "value")
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("expected a JSON line, got %v (%v)", buf.String(), err)
+	}
+	return line
+}
+
+// TESTS
+func TestRequestIDFromContext(t *testing.T) {
+	if id := RequestIDFromContext(context.Background()); id != "" {
+		t.Errorf("expected no request ID, got %v", id)
+	}
+	if id := RequestIDFromContext(WithRequestID(context.Background(), "req-1")); id != "req-1" {
+		t.Errorf("expected request ID req-1, got %v", id)
+	}
+}
+
+func TestContextHandler(t *testing.T) {
+	withID := WithRequestID(context.Background(), "req-1")
+	same := func(logger *slog.Logger) *slog.Logger { return logger }
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		fn   func(logger *slog.Logger) *slog.Logger
+		line map[string]any
+	}{
+		{
+			name: "without request ID",
+			ctx:  context.Background(),
+			fn:   same,
+			line: map[string]any{"msg": "msg", "key": "value"},
+		},
+		{
+			name: "with request ID",
+			ctx:  withID,
+			fn:   same,
+			line: map[string]any{"msg": "msg", "key": "value", "request_id": "req-1"},
+		},
+		{
+			name: "with attributes",
+			ctx:  withID,
+			fn:   func(logger *slog.Logger) *slog.Logger { return logger.With("component", "api") },
+			line: map[string]any{"msg": "msg", "key": "value", "component": "api", "request_id": "req-1"},
+		},
+		{
+			name: "with group",
+			ctx:  withID,
+			fn:   func(logger *slog.Logger) *slog.Logger { return logger.WithGroup("http") },
+			line: map[string]any{"msg": "msg", "request_id": "req-1", "http": map[string]any{"key": "value"}},
+		},
+		{
+			name: "with group and attributes",
+			ctx:  withID,
+			fn: func(logger *slog.Logger) *slog.Logger {
+				return logger.With("component", "api").WithGroup("http").With("method", "GET")
+			},
+			line: map[string]any{
+				"msg":        "msg",
+				"component":  "api",
+				"request_id": "req-1",
+				"http":       map[string]any{"method": "GET", "key": "value"},
+			},
+		},
+		{
+			name: "with group without request ID",
+			ctx:  context.Background(),
+			fn:   func(logger *slog.Logger) *slog.Logger { return logger.WithGroup("http") },
+			line: map[string]any{"msg": "msg", "http": map[string]any{"key": "value"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if line := logLine(t, tt.ctx, tt.fn); !reflect.DeepEqual(line, tt.line) {
+				t.Errorf("expected %v, got %v", tt.line, line)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+type mockAPIKeyRepo struct {
+	fakeKeys []domain.APIKey
+	lastUsed int
+}
+
+func (m *mockAPIKeyRepo) Save(apiKey *domain.APIKey, ctx context.Context) error {
+	m.fakeKeys = append(m.fakeKeys, *apiKey)
+	return nil
+}
+
+func (m *mockAPIKeyRepo) FindAll(ctx context.Context) ([]domain.APIKey, error) {
+	return m.fakeKeys, nil
+}
+
+func (m *mockAPIKeyRepo) FindByHash(hash string, ctx context.Context) (*domain.APIKey, error) {
+	for i := range m.fakeKeys {
+		if m.fakeKeys[i].Hash == hash {
+			apiKey := m.fakeKeys[i]
+			return &apiKey, nil
+		}
+	}
+	return nil, domain.ErrInvalidAPIKey
+}
+
+func (m *mockAPIKeyRepo) Revoke(id string, revokedAt time.Time, ctx context.Context) error {
+	for i := range m.fakeKeys {
+		if m.fakeKeys[i].ID == id && m.fakeKeys[i].RevokedAt == nil {
+			m.fakeKeys[i].RevokedAt = &revokedAt
+			return nil
+		}
+	}
+	return errors.New("api key not found or already revoked")
+}
+
+func (m *mockAPIKeyRepo) UpdateLastUsed(id string, lastUsedAt time.Time, ctx context.Context) error {
+	m.lastUsed++
+	for i := range m.fakeKeys {
+		if m.fakeKeys[i].ID == id {
+			m.fakeKeys[i].LastUsedAt = &lastUsedAt
+		}
+	}
+	return nil
+}
+
+// TESTS
+func TestCreateKey_StoresOnlyTheHash(t *testing.T) {
+	mockKRepo := &mockAPIKeyRepo{}
+	svc := NewAPIKeyService(mockKRepo)
+	apiKey := &domain.APIKey{Name: "warehouse scanner", Scopes: []domain.Scope{domain.ScopeProductsRead}}
+	key, err := svc.CreateKey(apiKey, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	stored := mockKRepo.fakeKeys[0]
+	if stored.Hash == "" || strings.Contains(stored.Hash, key) || stored.Hash == key {
+		t.Errorf("expected only a hash of the key to be stored, got %v", stored.Hash)
+	}
+	if !strings.HasPrefix(key, stored.Prefix) || len(stored.Prefix) >= len(key) {
+		t.Errorf("expected prefix %v to be the start of the key", stored.Prefix)
+	}
+
+	invalid := []*domain.APIKey{
+		{Name: " ", Scopes: []domain.Scope{domain.ScopeAdmin}},
+		{Name: "no scopes"},
+		{Name: "bad scope", Scopes: []domain.Scope{"orders:delete"}},
+	}
+	for _, apiKey := range invalid {
+		if _, err := svc.CreateKey(apiKey, context.Background()); err == nil {
+			t.Errorf("expected error for %+v, got nil", apiKey)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	mockKRepo := &mockAPIKeyRepo{}
+	svc := NewAPIKeyService(mockKRepo)
+	apiKey := &domain.APIKey{Name: "erp", Scopes: []domain.Scope{domain.ScopeOrdersWrite}}
+	key, err := svc.CreateKey(apiKey, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	now := time.Now().UTC()
+	found, err := svc.Authenticate(key, now, context.Background())
+	if err != nil || found.ID != apiKey.ID {
+		t.Fatalf("expected key %v, got %+v (%v)", apiKey.ID, found, err)
+	}
+	if !found.Allows(domain.ScopeOrdersWrite) || found.Allows(domain.ScopeProductsWrite) {
+		t.Errorf("expected only orders:write to be allowed, got %v", found.Scopes)
+	}
+	if _, err := svc.Authenticate(key, now.Add(time.Second), context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if mockKRepo.lastUsed != 1 {
+		t.Errorf("expected the last use to be written once within a minute, got %v", mockKRepo.lastUsed)
+	}
+
+	if _, err := svc.Authenticate("ism_unknown", now, context.Background()); !errors.Is(err, domain.ErrInvalidAPIKey) {
+		t.Errorf("expected ErrInvalidAPIKey for an unknown key, got %v", err)
+	}
+	if err := svc.Revoke(apiKey.ID, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := svc.Authenticate(key, now, context.Background()); !errors.Is(err, domain.ErrInvalidAPIKey) {
+		t.Errorf("expected ErrInvalidAPIKey for a revoked key, got %v", err)
+	}
+}
+
+func TestAPIKeyAllows_AdminGrantsEverything(t *testing.T) {
+	apiKey := domain.APIKey{Scopes: []domain.Scope{domain.ScopeAdmin}}
+	for _, scope := range []domain.Scope{domain.ScopeProductsRead, domain.ScopeProductsWrite, domain.ScopeOrdersWrite, domain.ScopeAdmin} {
+		if !apiKey.Allows(scope) {
+			t.Errorf("expected admin to allow %v", scope)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/pkg/helpers"
+)
+
+type ATPService struct {
+	productRepository         domain.ProductRepository
+	expectedReceiptRepository domain.ExpectedReceiptRepository
+}
+
+func NewATPService(productRepository domain.ProductRepository, expectedReceiptRepository domain.ExpectedReceiptRepository) *ATPService {
+	return &ATPService{
+		productRepository:         productRepository,
+		expectedReceiptRepository: expectedReceiptRepository,
+	}
+}
+
+// CreateExpectedReceipt registers inbound stock expected on a date.
+func (s *ATPService) CreateExpectedReceipt(expectedReceipt *domain.ExpectedReceipt, ctx context.Context) error {
+	if expectedReceipt.Quantity < 1 {
+		return errors.New("quantity must be greater than 0")
+	}
+	if expectedReceipt.ExpectedAt.IsZero() {
+		return errors.New("expected_at is required")
+	}
+	if _, err := s.productRepository.FindByID(expectedReceipt.ProductID, ctx); err != nil {
+		return err
+	}
+	expectedReceipt.ID = helpers.GenerateUUID()
+	expectedReceipt.Received = 0
+	expectedReceipt.ExpectedAt = expectedReceipt.ExpectedAt.UTC()
+	expectedReceipt.CreatedAt = time.Now().UTC()
+	return s.expectedReceiptRepository.Save(expectedReceipt, ctx)
+}
+
+func (s *ATPService) FindExpectedReceipts(productID string, ctx context.Context) ([]domain.ExpectedReceipt, error) {
+	return s.expectedReceiptRepository.FindOpen(productID, ctx)
+}
+
+// CloseExpectedReceipt stops waiting for the rest of an expected receipt, e.g.
+// one without a purchase order whose receipts would close it.
+func (s *ATPService) CloseExpectedReceipt(id string, ctx context.Context) (*domain.ExpectedReceipt, error) {
+	return s.expectedReceiptRepository.Close(id, time.Now().UTC(), ctx)
+}
+
+// BookReceipt closes expected receipts of a purchase order as its units
+// arrive, so they are not promised twice.
+func (s *ATPService) BookReceipt(purchaseOrderID string, productID string, quantity int, ctx context.Context) error {
+	return s.expectedReceiptRepository.Receive(purchaseOrderID, productID, quantity, ctx)
+}
+
+// AvailableToPromise finds the earliest date the quantity can be fulfilled
+// from the available stock plus open expected receipts. Orders take their
+// stock when they are created, so there are no open orders to hold stock
+// back; the units in the reserved bucket are not available. Expected receipts
+// that are overdue are not counted, as they may not arrive at all.
+func (s *ATPService) AvailableToPromise(productID string, quantity int, now time.Time, ctx context.Context) (*domain.AvailableToPromise, error) {
+	if quantity < 1 {
+		return nil, errors.New("quantity must be greater than 0")
+	}
+	product, err := s.productRepository.FindByID(productID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	expectedReceipts, err := s.expectedReceiptRepository.FindOpen(productID, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	atp := &domain.AvailableToPromise{
+		ProductID: productID,
+		Quantity:  quantity,
+		OnHand:    product.Stock + product.Reserved,
+		Reserved:  product.Reserved,
+	}
+	atp.Schedule, atp.Overdue = atpSchedule(product.Stock, expectedReceipts, now)
+	for _, point := range atp.Schedule {
+		if point.Available >= quantity {
+			date := point.Date
+			atp.Promisable = true
+			atp.PromiseDate = &date
+			return atp, nil
+		}
+	}
+	atp.Shortfall = quantity - atp.Schedule[len(atp.Schedule)-1].Available
+	return atp, nil
+}
+
+// atpSchedule accumulates the available stock and expected receipts, which
+// must be sorted by expected date, into one point per date. Receipts expected
+// before now are returned as the overdue quantity instead.
+func atpSchedule(available int, expectedReceipts []domain.ExpectedReceipt, now time.Time) ([]domain.ATPPoint, int) {
+	schedule := []domain.ATPPoint{{Date: now, Available: available}}
+	overdue := 0
+	for _, e := range expectedReceipts {
+		if e.ExpectedAt.Before(now) {
+			overdue += e.Open()
+			continue
+		}
+		last := &schedule[len(schedule)-1]
+		if e.ExpectedAt.Equal(last.Date) {
+			last.Available += e.Open()
+			continue
+		}
+		schedule = append(schedule, domain.ATPPoint{Date: e.ExpectedAt, Available: last.Available + e.Open()})
+	}
+	return schedule, overdue
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+type mockExpectedReceiptRepo struct {
+	fakeOpen []domain.ExpectedReceipt
+	saved    []*domain.ExpectedReceipt
+	received map[string]int
+}
+
+func (m *mockExpectedReceiptRepo) Save(expectedReceipt *domain.ExpectedReceipt, ctx context.Context) error {
+	m.saved = append(m.saved, expectedReceipt)
+	return nil
+}
+
+func (m *mockExpectedReceiptRepo) FindOpen(productID string, ctx context.Context) ([]domain.ExpectedReceipt, error) {
+	return m.fakeOpen, nil
+}
+
+func (m *mockExpectedReceiptRepo) Receive(purchaseOrderID string, productID string, quantity int, ctx context.Context) error {
+	if m.received == nil {
+		m.received = make(map[string]int)
+	}
+	m.received[purchaseOrderID+"/"+productID] += quantity
+	return nil
+}
+
+func (m *mockExpectedReceiptRepo) Close(id string, closedAt time.Time, ctx context.Context) (*domain.ExpectedReceipt, error) {
+	for i := range m.fakeOpen {
+		if m.fakeOpen[i].ID == id {
+			closed := m.fakeOpen[i]
+			closed.ClosedAt = &closedAt
+			m.fakeOpen = append(m.fakeOpen[:i], m.fakeOpen[i+1:]...)
+			return &closed, nil
+		}
+	}
+	return nil, errors.New("open expected receipt not found")
+}
+
+// TESTS
+func TestAvailableToPromise(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	mockERepo := &mockExpectedReceiptRepo{fakeOpen: []domain.ExpectedReceipt{
+		{Quantity: 5, Received: 3, ExpectedAt: now.AddDate(0, 0, -2)},
+		{Quantity: 10, ExpectedAt: now.AddDate(0, 0, 3)},
+		{Quantity: 20, ExpectedAt: now.AddDate(0, 0, 7)},
+	}}
+	svc := NewATPService(&mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1", Stock: 4, Reserved: 50}}, mockERepo)
+
+	tests := []struct {
+		quantity int
+		date     time.Time
+	}{
+		{4, now},
+		{14, now.AddDate(0, 0, 3)},
+		{34, now.AddDate(0, 0, 7)},
+	}
+	for _, tt := range tests {
+		atp, err := svc.AvailableToPromise("prod-1", tt.quantity, now, context.Background())
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		if !atp.Promisable || !atp.PromiseDate.Equal(tt.date) {
+			t.Errorf("quantity %v: expected promise on %v, got %+v", tt.quantity, tt.date, atp)
+		}
+	}
+
+	atp, err := svc.AvailableToPromise("prod-1", 40, now, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if atp.Promisable || atp.PromiseDate != nil || atp.Shortfall != 6 {
+		t.Errorf("expected a shortfall of 6, got %+v", atp)
+	}
+	if len(atp.Schedule) != 3 {
+		t.Errorf("expected 3 schedule points, got %+v", atp.Schedule)
+	}
+	if atp.OnHand != 54 || atp.Reserved != 50 {
+		t.Errorf("expected 54 on hand of which 50 reserved, got %+v", atp)
+	}
+	if atp.Overdue != 2 {
+		t.Errorf("expected 2 overdue units left out of the schedule, got %v", atp.Overdue)
+	}
+}
+
+func TestCloseExpectedReceipt(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	mockERepo := &mockExpectedReceiptRepo{fakeOpen: []domain.ExpectedReceipt{
+		{ID: "er-1", ProductID: "prod-1", Quantity: 10, ExpectedAt: now.AddDate(0, 0, 3)},
+	}}
+	svc := NewATPService(&mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1", Stock: 4}}, mockERepo)
+
+	closed, err := svc.CloseExpectedReceipt("er-1", context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if closed.ClosedAt == nil || closed.Open() != 0 {
+		t.Errorf("expected a closed receipt with nothing open, got %+v", closed)
+	}
+	atp, err := svc.AvailableToPromise("prod-1", 10, now, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if atp.Promisable || atp.Shortfall != 6 {
+		t.Errorf("expected the closed receipt not to count, got %+v", atp)
+	}
+	if _, err := svc.CloseExpectedReceipt("er-1", context.Background()); err == nil {
+		t.Errorf("expected error closing a closed receipt, got nil")
+	}
+}
+
+func TestCreateExpectedReceipt_Invalid(t *testing.T) {
+	svc := NewATPService(&mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1"}}, &mockExpectedReceiptRepo{})
+	if err := svc.CreateExpectedReceipt(&domain.ExpectedReceipt{ProductID: "prod-1", Quantity: 0, ExpectedAt: time.Now()}, context.Background()); err == nil {
+		t.Errorf("expected error for zero quantity, got nil")
+	}
+	if err := svc.CreateExpectedReceipt(&domain.ExpectedReceipt{ProductID: "prod-1", Quantity: 5}, context.Background()); err == nil {
+		t.Errorf("expected error for missing date, got nil")
+	}
+}
+
+func TestReceivePurchaseOrder_BooksExpectedReceipt(t *testing.T) {
+	mockERepo := &mockExpectedReceiptRepo{}
+	svc := NewPurchaseOrderService(PurchaseOrderServiceDeps{
+		Transactor:     &mockTransactor{},
+		PurchaseOrders: &mockPurchaseOrderRepo{fakePurchaseOrder: sentPurchaseOrder()},
+		Suppliers:      &mockSupplierRepo{},
+		Products:       &mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1"}},
+		Valuation:      NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+		StockHistory:   NewStockHistoryService(&mockStockHistoryRepo{}),
+		Lots:           NewLotService(LotServiceDeps{Lots: &mockLotRepo{}}),
+		Serials:        newTestSerialService(&mockSerialRepo{}, &mockProductRepo{}, &mockCostLayerRepo{}, &mockStockHistoryRepo{}),
+		ATP:            NewATPService(&mockProductRepo{}, mockERepo),
+		Events:         NewEventService(10),
+	})
+
+	receipt := &domain.Receipt{Lines: []domain.ReceiptLine{{PurchaseOrderLineID: "line-1", Quantity: 4}}}
+	if _, err := svc.ReceivePurchaseOrder("po-1", receipt, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if mockERepo.received["po-1/prod-1"] != 4 {
+		t.Errorf("expected 4 units booked against po-1, got %v", mockERepo.received)
+	}
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before it
+// is dropped. A dropped client reconnects and resumes from the event buffer.
+const subscriberBuffer = 64
+
+// EventListener handles every staged event within the transaction of the
+// change that caused it, so what it stores commits or rolls back with the
+// change. An error fails the change.
+type EventListener func(event domain.Event, ctx context.Context) error
+
+// EventService fans stock and order changes out to event stream subscribers
+// and listeners, and keeps the most recent events in memory so clients can
+// resume after a reconnect.
+type EventService struct {
+	mu          sync.Mutex
+	buffer      []domain.Event
+	start       int
+	lastID      uint64
+	subscribers map[*EventSubscription]struct{}
+	listeners   []EventListener
+	closed      bool
+}
+
+// EventSubscription receives the events published after it was created.
+// Replay holds the buffered events after the requested last event ID, and
+// Reset is set when some of those events are no longer buffered. Events is
+// closed when the subscriber falls behind or the service is closed.
+type EventSubscription struct {
+	Replay []domain.Event
+	Reset  bool
+	Events <-chan domain.Event
+
+	events     chan domain.Event
+	tenantID   string
+	productIDs map[string]bool
+}
+
+func (sub *EventSubscription) matches(event domain.Event) bool {
+	return event.TenantID == sub.tenantID && (len(sub.productIDs) == 0 || sub.productIDs[event.ProductID])
+}
+
+// NewEventService keeps up to bufferSize events for resuming subscribers.
+func NewEventService(bufferSize int) *EventService {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	return &EventService{
+		buffer:      make([]domain.Event, 0, bufferSize),
+		subscribers: make(map[*EventSubscription]struct{}),
+	}
+}
+
+// AddListener registers a listener for all events staged from now on.
+func (s *EventService) AddListener(listener EventListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// StageStock hands a product's stock change to the listeners within the
+// transaction of ctx. PublishStock announces it once the transaction has
+// committed.
+func (s *EventService) StageStock(product *domain.Product, ctx context.Context) error {
+	return s.stage(domain.EventStockChanged, product.ID, newStockChangedEvent(product), ctx)
+}
+
+// PublishStock announces a product's stock after a change.
+func (s *EventService) PublishStock(product *domain.Product, ctx context.Context) {
+	s.broadcast(domain.TenantFromContext(ctx), domain.EventStockChanged, product.ID, newStockChangedEvent(product))
+}
+
+// StageOrder is StageStock for a new order.
+func (s *EventService) StageOrder(order *domain.Order, ctx context.Context) error {
+	return s.stage(domain.EventOrderCreated, order.ProductID, newOrderCreatedEvent(order), ctx)
+}
+
+// PublishOrder announces a new order.
+func (s *EventService) PublishOrder(order *domain.Order, ctx context.Context) {
+	s.broadcast(domain.TenantFromContext(ctx), domain.EventOrderCreated, order.ProductID, newOrderCreatedEvent(order))
+}
+
+func newStockChangedEvent(product *domain.Product) domain.StockChangedEvent {
+	return domain.StockChangedEvent{
+		ProductID:   product.ID,
+		Stock:       product.Stock,
+		Reserved:    product.Reserved,
+		Damaged:     product.Damaged,
+		Quarantined: product.Quarantined,
+		Version:     product.Version,
+	}
+}
+
+func newOrderCreatedEvent(order *domain.Order) domain.OrderCreatedEvent {
+	return domain.OrderCreatedEvent{
+		OrderID:   order.ID,
+		ProductID: order.ProductID,
+		Quantity:  order.Quantity,
+		CreatedAt: order.CreatedAt,
+	}
+}
+
+// stage hands the event to the listeners even after Close, so requests that
+// are still finishing during shutdown are not lost to them. The event belongs
+// to the tenant of ctx; it has no ID, as IDs number the published events.
+func (s *EventService) stage(eventType domain.EventType, productID string, data interface{}, ctx context.Context) error {
+	s.mu.Lock()
+	listeners := s.listeners
+	s.mu.Unlock()
+	event := domain.Event{
+		TenantID:  domain.TenantFromContext(ctx),
+		Type:      eventType,
+		ProductID: productID,
+		Data:      data,
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, listener := range listeners {
+		if err := listener(event, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *EventService) broadcast(tenantID string, eventType domain.EventType, productID string, data interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	event := domain.Event{
+		ID:        s.lastID,
+		TenantID:  tenantID,
+		Type:      eventType,
+		ProductID: productID,
+		Data:      data,
+		CreatedAt: time.Now().UTC(),
+	}
+	if s.closed {
+		return
+	}
+	if len(s.buffer) < cap(s.buffer) {
+		s.buffer = append(s.buffer, event)
+	} else {
+		s.buffer[s.start] = event
+		s.start = (s.start + 1) % len(s.buffer)
+	}
+
+	for sub := range s.subscribers {
+		if !sub.matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			s.drop(sub)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for the given products of the tenant, or
+// all its products when none are given. With a lastEventID above 0 the
+// buffered events after it are replayed first.
+func (s *EventService) Subscribe(tenantID string, productIDs []string, lastEventID uint64) *EventSubscription {
+	events := make(chan domain.Event, subscriberBuffer)
+	sub := &EventSubscription{Events: events, events: events, tenantID: tenantID, productIDs: make(map[string]bool)}
+	for _, productID := range productIDs {
+		sub.productIDs[productID] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		close(events)
+		return sub
+	}
+	switch {
+	case lastEventID > s.lastID:
+		// The ID is from before a restart.
+		sub.Reset = true
+	case lastEventID > 0 && lastEventID < s.lastID:
+		buffered := s.buffered()
+		sub.Reset = len(buffered) == 0 || buffered[0].ID > lastEventID+1
+		for _, event := range buffered {
+			if event.ID > lastEventID && sub.matches(event) {
+				sub.Replay = append(sub.Replay, event)
+			}
+		}
+	}
+	s.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe removes a subscriber. It is safe to call more than once.
+func (s *EventService) Unsubscribe(sub *EventSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drop(sub)
+}
+
+// Close ends all subscriptions, so open event streams finish when the server
+// shuts down. Listeners keep receiving events.
+func (s *EventService) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for sub := range s.subscribers {
+		s.drop(sub)
+	}
+}
+
+// drop must be called with the lock held.
+func (s *EventService) drop(sub *EventSubscription) {
+	if _, ok := s.subscribers[sub]; !ok {
+		return
+	}
+	delete(s.subscribers, sub)
+	close(sub.events)
+}
+
+// buffered returns the buffered events oldest first. It must be called with
+// the lock held.
+func (s *EventService) buffered() []domain.Event {
+	events := make([]domain.Event, 0, len(s.buffer))
+	events = append(events, s.buffer[s.start:]...)
+	return append(events, s.buffer[:s.start]...)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+// TESTS
+func TestEventService_FiltersByProduct(t *testing.T) {
+	svc := NewEventService(10)
+	sub := svc.Subscribe(domain.DefaultTenant, []string{"prod-1"}, 0)
+
+	svc.PublishStock(&domain.Product{ID: "prod-2", Stock: 3}, context.Background())
+	svc.PublishStock(&domain.Product{ID: "prod-1", Stock: 7}, context.Background())
+
+	event := <-sub.Events
+	if event.ProductID != "prod-1" || event.Type != domain.EventStockChanged {
+		t.Fatalf("expected a stock event for prod-1, got %+v", event)
+	}
+	if data := event.Data.(domain.StockChangedEvent); data.Stock != 7 {
+		t.Errorf("expected stock 7, got %v", data.Stock)
+	}
+	if event.ID != 2 {
+		t.Errorf("expected event ID 2, got %v", event.ID)
+	}
+	if len(sub.Events) != 0 {
+		t.Errorf("expected no more events, got %v", len(sub.Events))
+	}
+}
+
+func TestEventService_ResumesFromLastEventID(t *testing.T) {
+	svc := NewEventService(10)
+	for i := 0; i < 4; i++ {
+		svc.PublishOrder(&domain.Order{ID: "order", ProductID: "prod-1", Quantity: 1}, context.Background())
+	}
+
+	sub := svc.Subscribe(domain.DefaultTenant, nil, 2)
+	if sub.Reset {
+		t.Errorf("expected no reset")
+	}
+	if len(sub.Replay) != 2 || sub.Replay[0].ID != 3 || sub.Replay[1].ID != 4 {
+		t.Errorf("expected events 3 and 4 to be replayed, got %+v", sub.Replay)
+	}
+}
+
+func TestEventService_ResetWhenEventsWereEvicted(t *testing.T) {
+	svc := NewEventService(2)
+	for i := 0; i < 5; i++ {
+		svc.PublishStock(&domain.Product{ID: "prod-1", Stock: i}, context.Background())
+	}
+
+	sub := svc.Subscribe(domain.DefaultTenant, nil, 1)
+	if !sub.Reset {
+		t.Errorf("expected a reset after events 2 and 3 were evicted")
+	}
+	if len(sub.Replay) != 2 || sub.Replay[0].ID != 4 || sub.Replay[1].ID != 5 {
+		t.Errorf("expected events 4 and 5 to be replayed, got %+v", sub.Replay)
+	}
+
+	if sub := svc.Subscribe(domain.DefaultTenant, nil, 99); !sub.Reset || len(sub.Replay) != 0 {
+		t.Errorf("expected a reset without replay for an unknown event ID, got %+v", sub)
+	}
+}
+
+func TestEventService_DropsSlowSubscriber(t *testing.T) {
+	svc := NewEventService(10)
+	sub := svc.Subscribe(domain.DefaultTenant, nil, 0)
+	for i := 0; i <= subscriberBuffer; i++ {
+		svc.PublishStock(&domain.Product{ID: "prod-1"}, context.Background())
+	}
+
+	count := 0
+	for range sub.Events {
+		count++
+	}
+	if count != subscriberBuffer {
+		t.Errorf("expected %v events before the subscription closed, got %v", subscriberBuffer, count)
+	}
+	svc.Unsubscribe(sub)
+}
+
+func TestEventService_CloseEndsSubscriptions(t *testing.T) {
+	svc := NewEventService(10)
+	sub := svc.Subscribe(domain.DefaultTenant, nil, 0)
+	svc.Close()
+
+	if _, ok := <-sub.Events; ok {
+		t.Errorf("expected the subscription to be closed")
+	}
+	svc.PublishStock(&domain.Product{ID: "prod-1"}, context.Background())
+	if _, ok := <-svc.Subscribe(domain.DefaultTenant, nil, 0).Events; ok {
+		t.Errorf("expected subscriptions after close to be closed")
+	}
+}
+
+func TestEventService_OnlyDeliversToSameTenant(t *testing.T) {
+	svc := NewEventService(10)
+	globex := svc.Subscribe("globex", nil, 0)
+	acme := svc.Subscribe("acme", nil, 0)
+
+	svc.PublishStock(&domain.Product{ID: "prod-1", Stock: 5}, domain.WithTenant(context.Background(), "acme"))
+	svc.PublishStock(&domain.Product{ID: "prod-1", Stock: 4}, domain.WithTenant(context.Background(), "acme"))
+
+	if event := <-acme.Events; event.ProductID != "prod-1" {
+		t.Errorf("expected acme to receive the stock event, got %+v", event)
+	}
+	if len(globex.Events) != 0 {
+		t.Errorf("expected globex to receive no events, got %v", len(globex.Events))
+	}
+	if sub := svc.Subscribe("globex", nil, 1); len(sub.Replay) != 0 {
+		t.Errorf("expected no replay for globex, got %+v", sub.Replay)
+	}
+}
+
+func TestEventService_StageHandsEventsToListeners(t *testing.T) {
+	svc := NewEventService(10)
+	sub := svc.Subscribe("acme", nil, 0)
+	var staged []domain.Event
+	svc.AddListener(func(event domain.Event, ctx context.Context) error {
+		staged = append(staged, event)
+		return nil
+	})
+	ctx := domain.WithTenant(context.Background(), "acme")
+
+	if err := svc.StageStock(&domain.Product{ID: "prod-1", Stock: 5}, ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(staged) != 1 || staged[0].TenantID != "acme" || staged[0].Type != domain.EventStockChanged {
+		t.Fatalf("expected the listener to get the acme stock event, got %+v", staged)
+	}
+	if len(sub.Events) != 0 {
+		t.Errorf("expected a staged event not to be published, got %v", len(sub.Events))
+	}
+
+	svc.PublishStock(&domain.Product{ID: "prod-1", Stock: 5}, ctx)
+	if len(staged) != 1 || len(sub.Events) != 1 {
+		t.Errorf("expected publishing to reach only subscribers, got %v staged and %v published", len(staged), len(sub.Events))
+	}
+
+	svc.AddListener(func(event domain.Event, ctx context.Context) error {
+		return errors.New("outbox unavailable")
+	})
+	if err := svc.StageOrder(&domain.Order{ID: "order-1", ProductID: "prod-1"}, ctx); err == nil {
+		t.Errorf("expected the listener error, got nil")
+	}
+}
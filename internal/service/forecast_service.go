@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+type ForecastService struct {
+	productRepository domain.ProductRepository
+	orderRepository   domain.OrderRepository
+}
+
+func NewForecastService(productRepository domain.ProductRepository, orderRepository domain.OrderRepository) *ForecastService {
+	return &ForecastService{
+		productRepository: productRepository,
+		orderRepository:   orderRepository,
+	}
+}
+
+// Forecast projects a product's daily demand from its order history and the
+// date on which current stock is expected to run out.
+func (s *ForecastService) Forecast(productID string, params domain.ForecastParams, ctx context.Context) (*domain.Forecast, error) {
+	if err := validateForecastParams(params); err != nil {
+		return nil, err
+	}
+	product, err := s.productRepository.FindByID(productID, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -params.HistoryDays)
+	sales, err := s.orderRepository.DailySales(productID, from, today, ctx)
+	if err != nil {
+		return nil, err
+	}
+	history := dailySeries(sales, from, params.HistoryDays)
+
+	indices := [7]float64{1, 1, 1, 1, 1, 1, 1}
+	if params.WeeklySeasonality {
+		indices = weeklyIndices(history, from)
+		history = deseasonalize(history, from, indices)
+	}
+
+	var level float64
+	switch params.Method {
+	case domain.ForecastMovingAverage:
+		level = movingAverage(history, params.Window)
+	case domain.ForecastExponentialSmoothing:
+		level = exponentialSmoothing(history, params.Alpha)
+	}
+
+	forecast := &domain.Forecast{
+		ProductID:          productID,
+		Method:             params.Method,
+		WeeklySeasonality:  params.WeeklySeasonality,
+		Stock:              product.Stock,
+		AverageDailyDemand: level,
+		Points:             make([]domain.ForecastPoint, 0, params.Days),
+	}
+	if product.Stock <= 0 {
+		forecast.StockoutDate = today.Format(time.DateOnly)
+	}
+	for i := 0; i < params.Days; i++ {
+		day := today.AddDate(0, 0, i)
+		quantity := level * indices[day.Weekday()]
+		forecast.TotalDemand += quantity
+		forecast.Points = append(forecast.Points, domain.ForecastPoint{Date: day.Format(time.DateOnly), Quantity: quantity})
+		if forecast.StockoutDate == "" && forecast.TotalDemand >= float64(product.Stock) {
+			forecast.StockoutDate = day.Format(time.DateOnly)
+		}
+	}
+	return forecast, nil
+}
+
+func validateForecastParams(params domain.ForecastParams) error {
+	if params.Method != domain.ForecastMovingAverage && params.Method != domain.ForecastExponentialSmoothing {
+		return errors.New("method must be moving_average or exponential_smoothing")
+	}
+	if params.Days < 1 || params.Days > 365 {
+		return errors.New("days must be between 1 and 365")
+	}
+	if params.HistoryDays < 7 {
+		return errors.New("history days must be at least 7")
+	}
+	if params.WeeklySeasonality && params.HistoryDays < 14 {
+		return errors.New("weekly seasonality needs at least 14 history days")
+	}
+	if params.Method == domain.ForecastMovingAverage && (params.Window < 1 || params.Window > params.HistoryDays) {
+		return errors.New("window must be between 1 and history days")
+	}
+	if params.Method == domain.ForecastExponentialSmoothing && (params.Alpha <= 0 || params.Alpha > 1) {
+		return errors.New("alpha must be greater than 0 and at most 1")
+	}
+	return nil
+}
+
+// dailySeries spreads sales over days consecutive days starting at from,
+// with zero for days without orders.
+func dailySeries(sales []domain.DailySales, from time.Time, days int) []float64 {
+	series := make([]float64, days)
+	for _, day := range sales {
+		i := int(day.Day.Sub(from).Hours() / 24)
+		if i >= 0 && i < days {
+			series[i] += float64(day.Quantity)
+		}
+	}
+	return series
+}
+
+func movingAverage(series []float64, window int) float64 {
+	if len(series) == 0 {
+		return 0
+	}
+	window = min(window, len(series))
+	var sum float64
+	for _, v := range series[len(series)-window:] {
+		sum += v
+	}
+	return sum / float64(window)
+}
+
+func exponentialSmoothing(series []float64, alpha float64) float64 {
+	if len(series) == 0 {
+		return 0
+	}
+	level := series[0]
+	for _, v := range series[1:] {
+		level = alpha*v + (1-alpha)*level
+	}
+	return level
+}
+
+// weeklyIndices returns each weekday's average demand relative to the overall
+// average; 1 everywhere when there is no demand at all.
+func weeklyIndices(series []float64, from time.Time) [7]float64 {
+	var sums [7]float64
+	var counts [7]int
+	var total float64
+	for i, v := range series {
+		weekday := from.AddDate(0, 0, i).Weekday()
+		sums[weekday] += v
+		counts[weekday]++
+		total += v
+	}
+	indices := [7]float64{1, 1, 1, 1, 1, 1, 1}
+	if total == 0 {
+		return indices
+	}
+	overall := total / float64(len(series))
+	for weekday := range indices {
+		if counts[weekday] > 0 {
+			indices[weekday] = sums[weekday] / float64(counts[weekday]) / overall
+		}
+	}
+	return indices
+}
+
+func deseasonalize(series []float64, from time.Time, indices [7]float64) []float64 {
+	adjusted := make([]float64, len(series))
+	for i, v := range series {
+		if index := indices[from.AddDate(0, 0, i).Weekday()]; index > 0 {
+			adjusted[i] = v / index
+		}
+	}
+	return adjusted
+}
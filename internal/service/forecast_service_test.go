@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+// TESTS
+func TestMovingAverage(t *testing.T) {
+	series := []float64{10, 0, 0, 2, 4, 6}
+	if got := movingAverage(series, 3); got != 4 {
+		t.Errorf("expected 4, got %v", got)
+	}
+	if got := movingAverage(series, 100); math.Abs(got-22.0/6.0) > 1e-9 {
+		t.Errorf("expected window to be capped at series length, got %v", got)
+	}
+}
+
+func TestExponentialSmoothing(t *testing.T) {
+	// 10 -> 0.5*0 + 0.5*10 = 5 -> 0.5*20 + 0.5*5 = 12.5
+	if got := exponentialSmoothing([]float64{10, 0, 20}, 0.5); got != 12.5 {
+		t.Errorf("expected 12.5, got %v", got)
+	}
+}
+
+func TestWeeklyIndices(t *testing.T) {
+	// Two weeks starting on a Monday, selling 8 on Mondays and nothing else.
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	series := make([]float64, 14)
+	series[0], series[7] = 8, 8
+	indices := weeklyIndices(series, from)
+	if indices[time.Monday] != 7 {
+		t.Errorf("expected Monday index 7, got %v", indices[time.Monday])
+	}
+	if indices[time.Tuesday] != 0 {
+		t.Errorf("expected Tuesday index 0, got %v", indices[time.Tuesday])
+	}
+}
+
+func TestForecast_StockoutDate(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	var daily []domain.DailySales
+	for i := 1; i <= 14; i++ {
+		daily = append(daily, domain.DailySales{ProductID: "prod-1", Day: today.AddDate(0, 0, -i), Quantity: 2})
+	}
+	mockPRepo := &mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1", Stock: 9}}
+	svc := NewForecastService(mockPRepo, &mockOrderRepo{fakeDaily: daily})
+
+	params := domain.ForecastParams{Method: domain.ForecastMovingAverage, Days: 30, HistoryDays: 14, Window: 7}
+	forecast, err := svc.Forecast("prod-1", params, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if forecast.AverageDailyDemand != 2 {
+		t.Errorf("expected average daily demand 2, got %v", forecast.AverageDailyDemand)
+	}
+	if len(forecast.Points) != 30 || forecast.TotalDemand != 60 {
+		t.Errorf("expected 30 points totalling 60, got %v points totalling %v", len(forecast.Points), forecast.TotalDemand)
+	}
+	// 9 units at 2 per day run out on the fifth day.
+	expected := today.AddDate(0, 0, 4).Format(time.DateOnly)
+	if forecast.StockoutDate != expected {
+		t.Errorf("expected stockout on %v, got %v", expected, forecast.StockoutDate)
+	}
+}
+
+func TestForecast_NoStockout(t *testing.T) {
+	mockPRepo := &mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1", Stock: 5}}
+	svc := NewForecastService(mockPRepo, &mockOrderRepo{})
+
+	params := domain.ForecastParams{Method: domain.ForecastExponentialSmoothing, Days: 30, HistoryDays: 28, Alpha: 0.3, WeeklySeasonality: true}
+	forecast, err := svc.Forecast("prod-1", params, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if forecast.StockoutDate != "" {
+		t.Errorf("expected no stockout without demand, got %v", forecast.StockoutDate)
+	}
+}
+
+func TestForecast_InvalidParams(t *testing.T) {
+	svc := NewForecastService(&mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1"}}, &mockOrderRepo{})
+	params := domain.ForecastParams{Method: domain.ForecastExponentialSmoothing, Days: 30, HistoryDays: 28, Alpha: 1.5}
+	if _, err := svc.Forecast("prod-1", params, context.Background()); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/pkg/helpers"
+)
+
+type LotService struct {
+	transactor          domain.Transactor
+	lotRepository       domain.LotRepository
+	productRepository   domain.ProductRepository
+	stockHistoryService *StockHistoryService
+	eventService        *EventService
+}
+
+type LotServiceDeps struct {
+	Transactor   domain.Transactor
+	Lots         domain.LotRepository
+	Products     domain.ProductRepository
+	StockHistory *StockHistoryService
+	Events       *EventService
+}
+
+func NewLotService(deps LotServiceDeps) *LotService {
+	return &LotService{
+		transactor:          deps.Transactor,
+		lotRepository:       deps.Lots,
+		productRepository:   deps.Products,
+		stockHistoryService: deps.StockHistory,
+		eventService:        deps.Events,
+	}
+}
+
+// CheckReceivable makes sure a receipt line names a lot and that a lot received
+// before under the same number does not have other dates; a lot has one
+// manufacture and expiry date, so different ones are a different lot number.
+// Quarantined lots cannot be topped up until they are released.
+func (s *LotService) CheckReceivable(receiptLine domain.ReceiptLine, ctx context.Context) error {
+	if err := validateLotDetails(receiptLine); err != nil {
+		return err
+	}
+	lots, err := s.lotRepository.FindByNumber(receiptLine.LotNumber, receiptLine.ProductID, ctx)
+	if err != nil {
+		return err
+	}
+	for _, lot := range lots {
+		if datesDiffer(lot.ManufacturedAt, receiptLine.ManufacturedAt) || datesDiffer(lot.ExpiresAt, receiptLine.ExpiresAt) {
+			return errors.New("lot " + receiptLine.LotNumber + " was received before with other dates")
+		}
+		if lot.Quarantined {
+			return errors.New("lot " + receiptLine.LotNumber + " is quarantined")
+		}
+	}
+	return nil
+}
+
+// Receive books a received receipt line into the product's lot with the
+// line's lot number.
+func (s *LotService) Receive(receiptLine domain.ReceiptLine, receivedAt time.Time, ctx context.Context) (*domain.Lot, error) {
+	if err := validateLotDetails(receiptLine); err != nil {
+		return nil, err
+	}
+	lot := &domain.Lot{
+		ID:             helpers.GenerateUUID(),
+		ProductID:      receiptLine.ProductID,
+		LotNumber:      receiptLine.LotNumber,
+		ManufacturedAt: receiptLine.ManufacturedAt,
+		ExpiresAt:      receiptLine.ExpiresAt,
+		Quantity:       receiptLine.Quantity,
+		Remaining:      receiptLine.Quantity,
+		ReceivedAt:     receivedAt,
+	}
+	return s.lotRepository.Receive(lot, ctx)
+}
+
+// PlanAllocation picks lots for the quantity first-expiry-first-out. Lots that
+// have expired at the given time or are quarantined are never picked. The lots
+// stay locked until the transaction in ctx ends, so plan and allocate in one.
+func (s *LotService) PlanAllocation(productID string, quantity int, at time.Time, ctx context.Context) ([]domain.LotAllocation, error) {
+	lots, err := s.lotRepository.FindAllocatable(productID, at, ctx)
+	if err != nil {
+		return nil, err
+	}
+	allocations := planLotAllocation(lots, quantity, at)
+	allocated := 0
+	for _, a := range allocations {
+		allocated += a.Quantity
+	}
+	if allocated < quantity {
+		return nil, errors.New("not enough unexpired stock in lots")
+	}
+	return allocations, nil
+}
+
+// Allocate stores planned allocations against the order.
+func (s *LotService) Allocate(orderID string, allocations []domain.LotAllocation, ctx context.Context) error {
+	for i := range allocations {
+		allocations[i].OrderID = orderID
+	}
+	return s.lotRepository.Allocate(allocations, ctx)
+}
+
+func (s *LotService) FindByProduct(productID string, ctx context.Context) ([]domain.Lot, error) {
+	return s.lotRepository.FindByProduct(productID, ctx)
+}
+
+func (s *LotService) FindAllocations(orderID string, ctx context.Context) ([]domain.LotAllocation, error) {
+	return s.lotRepository.FindAllocations(orderID, ctx)
+}
+
+// Trace finds every order that shipped units of the lot number, with the
+// customers and quantities, and what is left of the lot on hand.
+func (s *LotService) Trace(lotNumber string, productID string, ctx context.Context) (*domain.LotTrace, error) {
+	lots, err := s.findLots(lotNumber, productID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.lotRepository.FindOrders(lotIDs(lots), ctx)
+	if err != nil {
+		return nil, err
+	}
+	trace := &domain.LotTrace{
+		LotNumber: lotNumber,
+		Lots:      lots,
+		Orders:    orders,
+	}
+	if trace.Orders == nil {
+		trace.Orders = []domain.LotTraceOrder{}
+	}
+	for _, lot := range lots {
+		trace.OnHand += lot.Remaining
+	}
+	for _, order := range orders {
+		trace.Shipped += order.Quantity
+	}
+	return trace, nil
+}
+
+// Quarantine keeps the lot number out of order allocation, e.g. during a
+// supplier recall, until it is released. The remaining units of the lots move
+// from the available to the quarantined stock bucket, and back on release.
+func (s *LotService) Quarantine(lotNumber string, productID string, ctx context.Context) ([]domain.Lot, error) {
+	return s.setQuarantined(lotNumber, productID, true, ctx)
+}
+
+func (s *LotService) Release(lotNumber string, productID string, ctx context.Context) ([]domain.Lot, error) {
+	return s.setQuarantined(lotNumber, productID, false, ctx)
+}
+
+func (s *LotService) setQuarantined(lotNumber string, productID string, quarantined bool, ctx context.Context) ([]domain.Lot, error) {
+	lots, err := s.findLots(lotNumber, productID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	from, to, sign := domain.BucketAvailable, domain.BucketQuarantined, -1
+	if !quarantined {
+		from, to, sign = to, from, 1
+	}
+	var products []*domain.Product
+	err = s.transactor.WithinTx(func(ctx context.Context) error {
+		changed, err := s.lotRepository.SetQuarantined(lotIDs(lots), quarantined, ctx)
+		if err != nil {
+			return err
+		}
+		remaining := make(map[string]int)
+		for _, lot := range changed {
+			remaining[lot.ProductID] += lot.Remaining
+		}
+		productIDs := make([]string, 0, len(remaining))
+		for productID, quantity := range remaining {
+			if quantity > 0 {
+				productIDs = append(productIDs, productID)
+			}
+		}
+		sort.Strings(productIDs)
+		for _, productID := range productIDs {
+			product, err := s.productRepository.MoveStock(productID, from, to, remaining[productID], 0, ctx)
+			if err != nil {
+				return err
+			}
+			if err := s.stockHistoryService.Record(productID, sign*remaining[productID], domain.MovementTransfer, string(domain.BucketQuarantined), ctx); err != nil {
+				return err
+			}
+			if err := s.eventService.StageStock(product, ctx); err != nil {
+				return err
+			}
+			products = append(products, product)
+		}
+		return nil
+	}, ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, product := range products {
+		s.eventService.PublishStock(product, ctx)
+	}
+	for i := range lots {
+		lots[i].Quarantined = quarantined
+	}
+	return lots, nil
+}
+
+func (s *LotService) findLots(lotNumber string, productID string, ctx context.Context) ([]domain.Lot, error) {
+	lots, err := s.lotRepository.FindByNumber(lotNumber, productID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(lots) == 0 {
+		return nil, errors.New("lot not found")
+	}
+	return lots, nil
+}
+
+func lotIDs(lots []domain.Lot) []string {
+	ids := make([]string, len(lots))
+	for i, lot := range lots {
+		ids[i] = lot.ID
+	}
+	return ids
+}
+
+func validateLotDetails(receiptLine domain.ReceiptLine) error {
+	if receiptLine.LotNumber == "" {
+		return errors.New("lot number is required for lot-tracked products")
+	}
+	if receiptLine.ManufacturedAt != nil && receiptLine.ExpiresAt != nil && !receiptLine.ExpiresAt.After(*receiptLine.ManufacturedAt) {
+		return errors.New("expiry date must be after manufacture date")
+	}
+	return nil
+}
+
+// datesDiffer reports whether two dates are both known and not the same.
+func datesDiffer(a *time.Time, b *time.Time) bool {
+	return a != nil && b != nil && !a.Equal(*b)
+}
+
+// planLotAllocation takes the quantity from the lots in order of expiry; lots
+// without an expiry date go last.
+func planLotAllocation(lots []domain.Lot, quantity int, at time.Time) []domain.LotAllocation {
+	candidates := make([]domain.Lot, 0, len(lots))
+	for _, lot := range lots {
+		if lot.Remaining > 0 && !lot.Quarantined && !lot.Expired(at) {
+			candidates = append(candidates, lot)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i].ExpiresAt, candidates[j].ExpiresAt
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return a.Before(*b)
+	})
+
+	var allocations []domain.LotAllocation
+	for _, lot := range candidates {
+		if quantity == 0 {
+			break
+		}
+		take := min(lot.Remaining, quantity)
+		allocations = append(allocations, domain.LotAllocation{
+			LotID:     lot.ID,
+			LotNumber: lot.LotNumber,
+			Quantity:  take,
+			ExpiresAt: lot.ExpiresAt,
+		})
+		quantity -= take
+	}
+	return allocations
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+type mockLotRepo struct {
+	fakeLots    []domain.Lot
+	fakeOrders  []domain.LotTraceOrder
+	received    []*domain.Lot
+	allocations []domain.LotAllocation
+	quarantined map[string]bool
+}
+
+func (m *mockLotRepo) Receive(lot *domain.Lot, ctx context.Context) (*domain.Lot, error) {
+	m.received = append(m.received, lot)
+	return lot, nil
+}
+
+func (m *mockLotRepo) FindByProduct(productID string, ctx context.Context) ([]domain.Lot, error) {
+	return m.fakeLots, nil
+}
+
+func (m *mockLotRepo) FindAllocatable(productID string, at time.Time, ctx context.Context) ([]domain.Lot, error) {
+	return m.fakeLots, nil
+}
+
+func (m *mockLotRepo) Allocate(allocations []domain.LotAllocation, ctx context.Context) error {
+	m.allocations = append(m.allocations, allocations...)
+	return nil
+}
+
+func (m *mockLotRepo) FindAllocations(orderID string, ctx context.Context) ([]domain.LotAllocation, error) {
+	return m.allocations, nil
+}
+
+func (m *mockLotRepo) FindByNumber(lotNumber string, productID string, ctx context.Context) ([]domain.Lot, error) {
+	var lots []domain.Lot
+	for _, lot := range m.fakeLots {
+		if lot.LotNumber == lotNumber && (productID == "" || lot.ProductID == productID) {
+			lots = append(lots, lot)
+		}
+	}
+	return lots, nil
+}
+
+func (m *mockLotRepo) FindOrders(lotIDs []string, ctx context.Context) ([]domain.LotTraceOrder, error) {
+	return m.fakeOrders, nil
+}
+
+func (m *mockLotRepo) SetQuarantined(lotIDs []string, quarantined bool, ctx context.Context) ([]domain.Lot, error) {
+	if m.quarantined == nil {
+		m.quarantined = make(map[string]bool)
+	}
+	var changed []domain.Lot
+	for _, id := range lotIDs {
+		for _, lot := range m.fakeLots {
+			if lot.ID == id && m.quarantined[id] != quarantined {
+				changed = append(changed, lot)
+			}
+		}
+		m.quarantined[id] = quarantined
+	}
+	return changed, nil
+}
+
+func threeLots(now time.Time) []domain.Lot {
+	expired := now.AddDate(0, 0, -1)
+	soon := now.AddDate(0, 0, 5)
+	later := now.AddDate(0, 0, 30)
+	return []domain.Lot{
+		{ID: "lot-later", LotNumber: "L3", Remaining: 10, ExpiresAt: &later},
+		{ID: "lot-none", LotNumber: "L4", Remaining: 10},
+		{ID: "lot-expired", LotNumber: "L1", Remaining: 10, ExpiresAt: &expired},
+		{ID: "lot-soon", LotNumber: "L2", Remaining: 3, ExpiresAt: &soon},
+	}
+}
+
+// TESTS
+func TestPlanAllocation_FirstExpiryFirstOut(t *testing.T) {
+	now := time.Now().UTC()
+	svc := NewLotService(LotServiceDeps{Lots: &mockLotRepo{fakeLots: threeLots(now)}})
+
+	allocations, err := svc.PlanAllocation("prod-1", 15, now, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(allocations) != 3 {
+		t.Fatalf("expected 3 allocations, got %+v", allocations)
+	}
+	expected := []struct {
+		lotID    string
+		quantity int
+	}{{"lot-soon", 3}, {"lot-later", 10}, {"lot-none", 2}}
+	for i, e := range expected {
+		if allocations[i].LotID != e.lotID || allocations[i].Quantity != e.quantity {
+			t.Errorf("expected %v x%v, got %+v", e.lotID, e.quantity, allocations[i])
+		}
+	}
+}
+
+func TestPlanAllocation_ExpiredLotsBlocked(t *testing.T) {
+	now := time.Now().UTC()
+	expired := now.AddDate(0, 0, -1)
+	svc := NewLotService(LotServiceDeps{Lots: &mockLotRepo{fakeLots: []domain.Lot{
+		{ID: "lot-expired", Remaining: 10, ExpiresAt: &expired},
+	}}})
+
+	if _, err := svc.PlanAllocation("prod-1", 1, now, context.Background()); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func TestCreateOrder_LotTrackedAllocates(t *testing.T) {
+	now := time.Now().UTC()
+	product := &domain.Product{ID: "prod-1", Price: 2, Stock: 33, LotTracked: true}
+	mockLRepo := &mockLotRepo{fakeLots: threeLots(now)}
+	svc := NewOrderService(OrderServiceDeps{
+		Transactor:   &mockTransactor{},
+		Orders:       &mockOrderRepo{},
+		Products:     &mockProductRepo{fakeProduct: product},
+		Valuation:    NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+		StockHistory: NewStockHistoryService(&mockStockHistoryRepo{}),
+		Lots:         NewLotService(LotServiceDeps{Lots: mockLRepo}),
+		Serials:      newTestSerialService(&mockSerialRepo{}, &mockProductRepo{}, &mockCostLayerRepo{}, &mockStockHistoryRepo{}),
+		Events:       NewEventService(10),
+	})
+
+	order := &domain.Order{ProductID: "prod-1", Quantity: 4}
+	if err := svc.CreateOrder(order, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(order.Allocations) != 2 || order.Allocations[0].LotNumber != "L2" || order.Allocations[1].Quantity != 1 {
+		t.Errorf("unexpected allocations %+v", order.Allocations)
+	}
+	for _, a := range mockLRepo.allocations {
+		if a.OrderID != order.ID {
+			t.Errorf("expected allocation for order %v, got %v", order.ID, a.OrderID)
+		}
+	}
+}
+
+func TestCreateOrder_LotTrackedNotEnoughInLots(t *testing.T) {
+	now := time.Now().UTC()
+	product := &domain.Product{ID: "prod-1", Price: 2, Stock: 33, LotTracked: true}
+	mockORRepo := &mockOrderRepo{}
+	svc := NewOrderService(OrderServiceDeps{
+		Transactor:   &mockTransactor{},
+		Orders:       mockORRepo,
+		Products:     &mockProductRepo{fakeProduct: product},
+		Valuation:    NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+		StockHistory: NewStockHistoryService(&mockStockHistoryRepo{}),
+		Lots:         NewLotService(LotServiceDeps{Lots: &mockLotRepo{fakeLots: threeLots(now)}}),
+		Serials:      newTestSerialService(&mockSerialRepo{}, &mockProductRepo{}, &mockCostLayerRepo{}, &mockStockHistoryRepo{}),
+		Events:       NewEventService(10),
+	})
+
+	order := &domain.Order{ProductID: "prod-1", Quantity: 30}
+	if err := svc.CreateOrder(order, context.Background()); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if mockORRepo.saveCalled {
+		t.Errorf("expected order not to be saved")
+	}
+	if product.Stock != 33 {
+		t.Errorf("expected stock 33, got %v", product.Stock)
+	}
+}
+
+func TestReceivePurchaseOrder_LotTracked(t *testing.T) {
+	product := &domain.Product{ID: "prod-1", LotTracked: true}
+	mockLRepo := &mockLotRepo{}
+	svc := NewPurchaseOrderService(PurchaseOrderServiceDeps{
+		Transactor:     &mockTransactor{},
+		PurchaseOrders: &mockPurchaseOrderRepo{fakePurchaseOrder: sentPurchaseOrder()},
+		Suppliers:      &mockSupplierRepo{},
+		Products:       &mockProductRepo{fakeProduct: product},
+		Valuation:      NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+		StockHistory:   NewStockHistoryService(&mockStockHistoryRepo{}),
+		Lots:           NewLotService(LotServiceDeps{Lots: mockLRepo}),
+		Serials:        newTestSerialService(&mockSerialRepo{}, &mockProductRepo{}, &mockCostLayerRepo{}, &mockStockHistoryRepo{}),
+		ATP:            NewATPService(&mockProductRepo{}, &mockExpectedReceiptRepo{}),
+		Events:         NewEventService(10),
+	})
+
+	missing := &domain.Receipt{Lines: []domain.ReceiptLine{{PurchaseOrderLineID: "line-1", Quantity: 4}}}
+	if _, err := svc.ReceivePurchaseOrder("po-1", missing, context.Background()); err == nil {
+		t.Fatalf("expected error for missing lot number, got nil")
+	}
+
+	expiresAt := time.Now().UTC().AddDate(0, 1, 0)
+	receipt := &domain.Receipt{Lines: []domain.ReceiptLine{{PurchaseOrderLineID: "line-1", Quantity: 4, LotNumber: "B-7", ExpiresAt: &expiresAt}}}
+	if _, err := svc.ReceivePurchaseOrder("po-1", receipt, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(mockLRepo.received) != 1 || mockLRepo.received[0].LotNumber != "B-7" || mockLRepo.received[0].Quantity != 4 {
+		t.Errorf("expected lot B-7 with 4 units, got %+v", mockLRepo.received)
+	}
+}
+
+func TestReceivePurchaseOrder_LotDatesMismatch(t *testing.T) {
+	product := &domain.Product{ID: "prod-1", LotTracked: true}
+	expiresAt := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	mockLRepo := &mockLotRepo{fakeLots: []domain.Lot{{ID: "lot-1", ProductID: "prod-1", LotNumber: "B-7", ExpiresAt: &expiresAt, Quantity: 5, Remaining: 5}}}
+	svc := NewPurchaseOrderService(PurchaseOrderServiceDeps{
+		Transactor:     &mockTransactor{},
+		PurchaseOrders: &mockPurchaseOrderRepo{fakePurchaseOrder: sentPurchaseOrder()},
+		Suppliers:      &mockSupplierRepo{},
+		Products:       &mockProductRepo{fakeProduct: product},
+		Valuation:      NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+		StockHistory:   NewStockHistoryService(&mockStockHistoryRepo{}),
+		Lots:           NewLotService(LotServiceDeps{Lots: mockLRepo}),
+		Serials:        newTestSerialService(&mockSerialRepo{}, &mockProductRepo{}, &mockCostLayerRepo{}, &mockStockHistoryRepo{}),
+		ATP:            NewATPService(&mockProductRepo{}, &mockExpectedReceiptRepo{}),
+		Events:         NewEventService(10),
+	})
+
+	later := expiresAt.AddDate(0, 1, 0)
+	mismatch := &domain.Receipt{Lines: []domain.ReceiptLine{{PurchaseOrderLineID: "line-1", Quantity: 4, LotNumber: "B-7", ExpiresAt: &later}}}
+	if _, err := svc.ReceivePurchaseOrder("po-1", mismatch, context.Background()); err == nil {
+		t.Fatalf("expected error for a lot re-received with another expiry, got nil")
+	}
+	if len(mockLRepo.received) != 0 || product.Stock != 0 {
+		t.Errorf("expected nothing to be received, got %+v and stock %v", mockLRepo.received, product.Stock)
+	}
+
+	same := &domain.Receipt{Lines: []domain.ReceiptLine{{PurchaseOrderLineID: "line-1", Quantity: 4, LotNumber: "B-7", ExpiresAt: &expiresAt}}}
+	if _, err := svc.ReceivePurchaseOrder("po-1", same, context.Background()); err != nil {
+		t.Fatalf("expected nil error for the same expiry, got %v", err)
+	}
+
+	mockLRepo.fakeLots[0].Quarantined = true
+	if _, err := svc.ReceivePurchaseOrder("po-1", same, context.Background()); err == nil {
+		t.Errorf("expected error for a quarantined lot, got nil")
+	}
+}
+
+func TestPlanAllocation_QuarantinedLotsBlocked(t *testing.T) {
+	now := time.Now().UTC()
+	lots := threeLots(now)
+	lots[3].Quarantined = true
+	svc := NewLotService(LotServiceDeps{Lots: &mockLotRepo{fakeLots: lots}})
+
+	allocations, err := svc.PlanAllocation("prod-1", 1, now, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if allocations[0].LotID != "lot-later" {
+		t.Errorf("expected quarantined lot to be skipped, got %+v", allocations[0])
+	}
+}
+
+func TestTrace(t *testing.T) {
+	mockLRepo := &mockLotRepo{
+		fakeLots: []domain.Lot{
+			{ID: "lot-1", ProductID: "prod-1", LotNumber: "B-7", Quantity: 10, Remaining: 4},
+			{ID: "lot-2", ProductID: "prod-2", LotNumber: "B-7", Quantity: 5, Remaining: 5},
+			{ID: "lot-3", ProductID: "prod-1", LotNumber: "B-8", Quantity: 5, Remaining: 5},
+		},
+		fakeOrders: []domain.LotTraceOrder{
+			{OrderID: "order-1", LotID: "lot-1", Customer: "ACME", Quantity: 2},
+			{OrderID: "order-2", LotID: "lot-1", Customer: "Globex", Quantity: 4},
+		},
+	}
+	svc := NewLotService(LotServiceDeps{Lots: mockLRepo})
+
+	trace, err := svc.Trace("B-7", "", context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(trace.Lots) != 2 || trace.OnHand != 9 || trace.Shipped != 6 {
+		t.Errorf("unexpected trace %+v", trace)
+	}
+	if _, err := svc.Trace("B-9", "", context.Background()); err == nil {
+		t.Errorf("expected error for unknown lot, got nil")
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	mockLRepo := &mockLotRepo{fakeLots: []domain.Lot{
+		{ID: "lot-1", ProductID: "prod-1", LotNumber: "B-7", Remaining: 5},
+		{ID: "lot-2", ProductID: "prod-2", LotNumber: "B-7", Remaining: 4},
+	}}
+	product := &domain.Product{ID: "prod-2", Stock: 10}
+	mockHRepo := &mockStockHistoryRepo{}
+	svc := NewLotService(LotServiceDeps{
+		Transactor:   &mockTransactor{},
+		Lots:         mockLRepo,
+		Products:     &mockProductRepo{fakeProduct: product},
+		StockHistory: NewStockHistoryService(mockHRepo),
+		Events:       NewEventService(10),
+	})
+
+	lots, err := svc.Quarantine("B-7", "prod-2", context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(lots) != 1 || !lots[0].Quarantined {
+		t.Errorf("expected one quarantined lot, got %+v", lots)
+	}
+	if !mockLRepo.quarantined["lot-2"] || mockLRepo.quarantined["lot-1"] {
+		t.Errorf("expected only lot-2 to be quarantined, got %v", mockLRepo.quarantined)
+	}
+	if product.Stock != 6 || product.Quarantined != 4 {
+		t.Errorf("expected 4 units moved to quarantined, got stock %v and quarantined %v", product.Stock, product.Quarantined)
+	}
+	if len(mockHRepo.movements) != 1 || mockHRepo.movements[0].Quantity != -4 || mockHRepo.movements[0].Reason != domain.MovementTransfer {
+		t.Errorf("expected a transfer movement of -4, got %+v", mockHRepo.movements)
+	}
+
+	if _, err := svc.Quarantine("B-7", "prod-2", context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if product.Quarantined != 4 || len(mockHRepo.movements) != 1 {
+		t.Errorf("expected quarantining twice to move nothing, got quarantined %v", product.Quarantined)
+	}
+
+	if _, err := svc.Release("B-7", "prod-2", context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if product.Stock != 10 || product.Quarantined != 0 {
+		t.Errorf("expected the units back in available, got stock %v and quarantined %v", product.Stock, product.Quarantined)
+	}
+	if len(mockHRepo.movements) != 2 || mockHRepo.movements[1].Quantity != 4 {
+		t.Errorf("expected a transfer movement of 4, got %+v", mockHRepo.movements)
+	}
+}
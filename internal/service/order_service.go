@@ -3,38 +3,153 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/iamtbay/is-management/internal/domain"
 	"github.com/iamtbay/is-management/pkg/helpers"
 )
 
 type OrderService struct {
-	orderRepository   domain.OrderRepository
-	productRepository domain.ProductRepository
+	transactor          domain.Transactor
+	orderRepository     domain.OrderRepository
+	productRepository   domain.ProductRepository
+	valuationService    *ValuationService
+	stockHistoryService *StockHistoryService
+	lotService          *LotService
+	serialService       *SerialService
+	eventService        *EventService
 }
 
-func NewOrderService(orderRepository domain.OrderRepository, productRepository domain.ProductRepository) *OrderService {
+// OrderServiceDeps are the repositories and services an OrderService works
+// with.
+type OrderServiceDeps struct {
+	Transactor   domain.Transactor
+	Orders       domain.OrderRepository
+	Products     domain.ProductRepository
+	Valuation    *ValuationService
+	StockHistory *StockHistoryService
+	Lots         *LotService
+	Serials      *SerialService
+	Events       *EventService
+}
+
+func NewOrderService(deps OrderServiceDeps) *OrderService {
 	return &OrderService{
-		orderRepository:   orderRepository,
-		productRepository: productRepository,
+		transactor:          deps.Transactor,
+		orderRepository:     deps.Orders,
+		productRepository:   deps.Products,
+		valuationService:    deps.Valuation,
+		stockHistoryService: deps.StockHistory,
+		lotService:          deps.Lots,
+		serialService:       deps.Serials,
+		eventService:        deps.Events,
 	}
 }
 
+// CreateOrder takes the ordered quantity off the available stock bucket;
+// reserved, damaged and quarantined units are never sold. Orders of lot-tracked
+// products are allocated to unexpired lots first-expiry-first-out and fail
+// when those lots cannot cover the quantity. Orders of serialized products
+// must name an in-stock serial for every unit. Orders placed in a unit of
+// measure are converted to base units first. The product's inventory policy
+// decides whether stock limits the order: allow_negative products may go
+// below zero and untracked products keep no stock at all. The stock, lots,
+// serials, history and cost layers of the order change in one transaction.
 func (s *OrderService) CreateOrder(order *domain.Order, ctx context.Context) error {
-	checkStock, err := s.productRepository.FindByID(order.ProductID, ctx)
+	var product *domain.Product
+	err := s.transactor.WithinTx(func(ctx context.Context) error {
+		var err error
+		product, err = s.createOrder(order, ctx)
+		if err != nil {
+			return err
+		}
+		if err := s.eventService.StageOrder(order, ctx); err != nil {
+			return err
+		}
+		if product != nil {
+			return s.eventService.StageStock(product, ctx)
+		}
+		return nil
+	}, ctx)
 	if err != nil {
 		return err
 	}
-	if checkStock.Stock < order.Quantity {
-		return errors.New("stock is not enough")
+	s.eventService.PublishOrder(order, ctx)
+	if product != nil {
+		s.eventService.PublishStock(product, ctx)
 	}
-	_, err = s.productRepository.UpdateStock(order.ProductID, order.Quantity, ctx)
+	return nil
+}
+
+// createOrder books order and returns the product with its new stock, or nil
+// for untracked products.
+func (s *OrderService) createOrder(order *domain.Order, ctx context.Context) (*domain.Product, error) {
+	checkStock, err := s.productRepository.FindByID(order.ProductID, ctx)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if order.Unit != "" {
+		order.Quantity, err = toBaseQuantity(s.productRepository, order.ProductID, order.Unit, order.UnitQuantity, ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if order.Quantity < 1 {
+		return nil, errors.New("quantity must be greater than 0")
+	}
+	tracksStock := checkStock.InventoryPolicy != domain.PolicyUntracked
+	if checkStock.Stock < order.Quantity && tracksStock && checkStock.InventoryPolicy != domain.PolicyAllowNegative {
+		return nil, errors.New("stock is not enough")
+	}
+	now := time.Now().UTC()
+	var allocations []domain.LotAllocation
+	if checkStock.LotTracked {
+		allocations, err = s.lotService.PlanAllocation(order.ProductID, order.Quantity, now, ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if checkStock.Serialized {
+		if err := s.serialService.CheckSellable(order.ProductID, order.Serials, order.Quantity, ctx); err != nil {
+			return nil, err
+		}
+	} else if len(order.Serials) > 0 {
+		return nil, errors.New("product is not serialized")
+	}
+	var product *domain.Product
+	if tracksStock {
+		product, err = s.productRepository.UpdateStock(order.ProductID, order.Quantity, 0, ctx)
+		if err != nil {
+			return nil, err
+		}
 	}
 	order.TotalPrice = checkStock.Price * float64(order.Quantity)
 	order.ID = helpers.GenerateUUID()
-	return s.orderRepository.Save(order, ctx)
+	order.CreatedAt = now
+	if err := s.orderRepository.Save(order, ctx); err != nil {
+		return nil, err
+	}
+	if len(allocations) > 0 {
+		if err := s.lotService.Allocate(order.ID, allocations, ctx); err != nil {
+			return nil, err
+		}
+		order.Allocations = allocations
+	}
+	if len(order.Serials) > 0 {
+		if err := s.serialService.Sell(order.ID, order.ProductID, order.Serials, now, ctx); err != nil {
+			return nil, err
+		}
+	}
+	if !tracksStock {
+		return nil, nil
+	}
+	if err := s.stockHistoryService.Record(order.ProductID, -order.Quantity, domain.MovementOrder, order.ID, ctx); err != nil {
+		return nil, err
+	}
+	if err := s.valuationService.Consume(order.ID, order.ProductID, order.Quantity, ctx); err != nil {
+		return nil, err
+	}
+	return product, nil
 }
 
 func (s *OrderService) FindAll(ctx context.Context) ([]domain.Order, error) {
@@ -42,5 +157,17 @@ func (s *OrderService) FindAll(ctx context.Context) ([]domain.Order, error) {
 }
 
 func (s *OrderService) FindByID(id string, ctx context.Context) (*domain.Order, error) {
-	return s.orderRepository.FindByID(id, ctx)
+	order, err := s.orderRepository.FindByID(id, ctx)
+	if err != nil {
+		return nil, err
+	}
+	order.Allocations, err = s.lotService.FindAllocations(order.ID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	order.Serials, err = s.serialService.FindByOrder(order.ID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
 }
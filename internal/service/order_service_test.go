@@ -3,12 +3,16 @@ package service
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/iamtbay/is-management/internal/domain"
 )
 
 type mockOrderRepo struct {
 	saveCalled bool
+	fakeSales  map[string]int
+	fakeDaily  []domain.DailySales
+	fakeLast   map[string]time.Time
 }
 
 func (m *mockOrderRepo) Save(order *domain.Order, ctx context.Context) error {
@@ -24,6 +28,39 @@ func (m *mockOrderRepo) FindByID(id string, ctx context.Context) (*domain.Order,
 	return nil, nil
 }
 
+func (m *mockOrderRepo) SalesByProduct(since time.Time, ctx context.Context) (map[string]int, error) {
+	return m.fakeSales, nil
+}
+
+func (m *mockOrderRepo) DailySales(productID string, from time.Time, to time.Time, ctx context.Context) ([]domain.DailySales, error) {
+	return m.fakeDaily, nil
+}
+
+func (m *mockOrderRepo) DailySalesAll(from time.Time, to time.Time, ctx context.Context) ([]domain.DailySales, error) {
+	return m.fakeDaily, nil
+}
+
+func (m *mockOrderRepo) LastSaleDates(before time.Time, ctx context.Context) (map[string]time.Time, error) {
+	return m.fakeLast, nil
+}
+
+// mockTransactor runs the work without a transaction, as the mock
+// repositories have nothing to roll back, and counts how it was asked to.
+type mockTransactor struct {
+	txs       int
+	snapshots int
+}
+
+func (m *mockTransactor) WithinTx(fn func(ctx context.Context) error, ctx context.Context) error {
+	m.txs++
+	return fn(ctx)
+}
+
+func (m *mockTransactor) WithinSnapshot(fn func(ctx context.Context) error, ctx context.Context) error {
+	m.snapshots++
+	return fn(ctx)
+}
+
 // TESTS
 func TestCreateOrder_Success(t *testing.T) {
 	existingProduct := &domain.Product{
@@ -37,8 +74,16 @@ func TestCreateOrder_Success(t *testing.T) {
 		fakeProduct: existingProduct,
 	}
 	mockORRepo := &mockOrderRepo{}
+	mockHRepo := &mockStockHistoryRepo{}
 
-	svc := NewOrderService(mockORRepo, mockPRepo)
+	svc := NewOrderService(OrderServiceDeps{
+		Transactor:   &mockTransactor{},
+		Orders:       mockORRepo,
+		Products:     mockPRepo,
+		Valuation:    NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+		StockHistory: NewStockHistoryService(mockHRepo),
+		Events:       NewEventService(10),
+	})
 
 	order := &domain.Order{
 		ProductID: "prod-1",
@@ -57,6 +102,9 @@ func TestCreateOrder_Success(t *testing.T) {
 	if existingProduct.Stock != 8 {
 		t.Errorf("expected stock 8, got %v", existingProduct.Stock)
 	}
+	if len(mockHRepo.movements) != 1 || mockHRepo.movements[0].Quantity != -2 || mockHRepo.movements[0].ReferenceID != order.ID {
+		t.Errorf("expected an order movement of -2, got %+v", mockHRepo.movements)
+	}
 }
 
 func TestCreateOrder_InsufficientStock(t *testing.T) {
@@ -69,7 +117,14 @@ func TestCreateOrder_InsufficientStock(t *testing.T) {
 		fakeProduct: existingProduct,
 	}
 	mockORRepo := &mockOrderRepo{}
-	svc := NewOrderService(mockORRepo, mockPRepo)
+	svc := NewOrderService(OrderServiceDeps{
+		Transactor:   &mockTransactor{},
+		Orders:       mockORRepo,
+		Products:     mockPRepo,
+		Valuation:    NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+		StockHistory: NewStockHistoryService(&mockStockHistoryRepo{}),
+		Events:       NewEventService(10),
+	})
 
 	order := &domain.Order{
 		ProductID: "prod-1",
@@ -83,3 +138,66 @@ func TestCreateOrder_InsufficientStock(t *testing.T) {
 		t.Errorf("expected error message 'stock is not enough', got %v", err.Error())
 	}
 }
+
+func TestCreateOrder_InCases(t *testing.T) {
+	product := &domain.Product{ID: "prod-1", Price: 2, Stock: 30}
+	mockPRepo := &mockProductRepo{
+		fakeProduct: product,
+		fakeUnits:   []domain.ProductUnit{{ProductID: "prod-1", Unit: domain.UnitCase, Factor: 24}},
+	}
+	svc := NewOrderService(OrderServiceDeps{
+		Transactor:   &mockTransactor{},
+		Orders:       &mockOrderRepo{},
+		Products:     mockPRepo,
+		Valuation:    NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+		StockHistory: NewStockHistoryService(&mockStockHistoryRepo{}),
+		Events:       NewEventService(10),
+	})
+
+	order := &domain.Order{ProductID: "prod-1", Unit: domain.UnitCase, UnitQuantity: 1}
+	if err := svc.CreateOrder(order, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if order.Quantity != 24 || order.TotalPrice != 48 || product.Stock != 6 {
+		t.Errorf("expected 24 units for 48, got %+v and stock %v", order, product.Stock)
+	}
+	if err := svc.CreateOrder(&domain.Order{ProductID: "prod-1", Unit: domain.UnitCase, UnitQuantity: 0.1}, context.Background()); err == nil {
+		t.Errorf("expected error for fractional base units, got nil")
+	}
+}
+
+func TestCreateOrder_InventoryPolicy(t *testing.T) {
+	tests := []struct {
+		policy    domain.InventoryPolicy
+		wantErr   bool
+		wantStock int
+		movements int
+	}{
+		{domain.PolicyTracked, true, 2, 0},
+		{domain.PolicyAllowNegative, false, -3, 1},
+		{domain.PolicyUntracked, false, 2, 0},
+	}
+	for _, tt := range tests {
+		product := &domain.Product{ID: "prod-1", Price: 1, Stock: 2, InventoryPolicy: tt.policy}
+		mockHRepo := &mockStockHistoryRepo{}
+		svc := NewOrderService(OrderServiceDeps{
+			Transactor:   &mockTransactor{},
+			Orders:       &mockOrderRepo{},
+			Products:     &mockProductRepo{fakeProduct: product},
+			Valuation:    NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+			StockHistory: NewStockHistoryService(mockHRepo),
+			Events:       NewEventService(10),
+		})
+
+		err := svc.CreateOrder(&domain.Order{ProductID: "prod-1", Quantity: 5}, context.Background())
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: expected error %v, got %v", tt.policy, tt.wantErr, err)
+		}
+		if product.Stock != tt.wantStock {
+			t.Errorf("%v: expected stock %v, got %v", tt.policy, tt.wantStock, product.Stock)
+		}
+		if len(mockHRepo.movements) != tt.movements {
+			t.Errorf("%v: expected %v movements, got %v", tt.policy, tt.movements, len(mockHRepo.movements))
+		}
+	}
+}
@@ -2,35 +2,241 @@ package service
 
 import (
 	"context"
+	"errors"
+	"math"
 
 	"github.com/iamtbay/is-management/internal/domain"
 	"github.com/iamtbay/is-management/pkg/helpers"
 )
 
 type ProductService struct {
-	productRepository domain.ProductRepository
+	transactor          domain.Transactor
+	productRepository   domain.ProductRepository
+	valuationService    *ValuationService
+	stockHistoryService *StockHistoryService
+	eventService        *EventService
 }
 
-func NewProductService(productRepository domain.ProductRepository) *ProductService {
+// ProductServiceDeps are the repositories and services a ProductService works
+// with.
+type ProductServiceDeps struct {
+	Transactor   domain.Transactor
+	Products     domain.ProductRepository
+	Valuation    *ValuationService
+	StockHistory *StockHistoryService
+	Events       *EventService
+}
+
+func NewProductService(deps ProductServiceDeps) *ProductService {
 	return &ProductService{
-		productRepository: productRepository,
+		transactor:          deps.Transactor,
+		productRepository:   deps.Products,
+		valuationService:    deps.Valuation,
+		stockHistoryService: deps.StockHistory,
+		eventService:        deps.Events,
 	}
 }
 
 // PRODUCTS
-func (p *ProductService) CreateProduct(product *domain.Product, ctx context.Context) error {
+// CreateProduct values the product's initial stock at unitCost in an opening
+// cost layer.
+func (p *ProductService) CreateProduct(product *domain.Product, unitCost float64, ctx context.Context) error {
+	if product.InventoryPolicy == "" {
+		product.InventoryPolicy = domain.PolicyTracked
+	}
+	if !product.InventoryPolicy.Valid() {
+		return errors.New("inventory policy must be tracked, untracked or allow_negative")
+	}
+	if product.InventoryPolicy == domain.PolicyUntracked && (product.LotTracked || product.Serialized) {
+		return errors.New("untracked products cannot be lot-tracked or serialized")
+	}
+	if unitCost < 0 {
+		return errors.New("unit cost cannot be negative")
+	}
 	product.ID = helpers.GenerateUUID()
-	return p.productRepository.Save(product, ctx)
+	product.Version = 1
+	err := p.transactor.WithinTx(func(ctx context.Context) error {
+		if err := p.productRepository.Save(product, ctx); err != nil {
+			return err
+		}
+		if product.Stock > 0 && product.InventoryPolicy != domain.PolicyUntracked {
+			if err := p.valuationService.AddLayer(product.ID, "", product.Stock, unitCost, ctx); err != nil {
+				return err
+			}
+		}
+		if err := p.stockHistoryService.Record(product.ID, product.Stock, domain.MovementInitial, "", ctx); err != nil {
+			return err
+		}
+		return p.eventService.StageStock(product, ctx)
+	}, ctx)
+	if err != nil {
+		return err
+	}
+	p.eventService.PublishStock(product, ctx)
+	return nil
 }
 
 func (p *ProductService) FindProductByID(id string, ctx context.Context) (*domain.Product, error) {
 	return p.productRepository.FindByID(id, ctx)
 }
 
-func (p *ProductService) UpdateStock(id string, stockQuantity int, ctx context.Context) (*domain.Product, error) {
-	return p.productRepository.UpdateStock(id, stockQuantity, ctx)
+// UpdateStock, UpdateReorderSettings and MoveStock fail with
+// domain.ErrVersionConflict when the product is no longer at version; version
+// 0 skips the check. UpdateStock cannot say which lots or serials the units
+// came from, so lot-tracked and serialized products are rejected.
+func (p *ProductService) UpdateStock(id string, stockQuantity int, version int, ctx context.Context) (*domain.Product, error) {
+	if stockQuantity < 1 {
+		return nil, errors.New("quantity must be greater than 0")
+	}
+	current, err := p.productRepository.FindByID(id, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if current.LotTracked || current.Serialized {
+		return nil, errors.New("stock of lot-tracked or serialized products cannot be adjusted without its lots or serials")
+	}
+	var product *domain.Product
+	err = p.transactor.WithinTx(func(ctx context.Context) error {
+		var err error
+		product, err = p.productRepository.UpdateStock(id, stockQuantity, version, ctx)
+		if err != nil {
+			return err
+		}
+		if err := p.valuationService.Adjust(id, -stockQuantity, ctx); err != nil {
+			return err
+		}
+		if err := p.stockHistoryService.Record(id, -stockQuantity, domain.MovementAdjustment, "", ctx); err != nil {
+			return err
+		}
+		return p.eventService.StageStock(product, ctx)
+	}, ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.eventService.PublishStock(product, ctx)
+	return product, nil
+}
+
+func (p *ProductService) UpdateReorderSettings(id string, reorderPoint int, reorderQuantity int, version int, ctx context.Context) (*domain.Product, error) {
+	if reorderPoint < 0 || reorderQuantity < 0 {
+		return nil, errors.New("reorder settings cannot be negative")
+	}
+	return p.productRepository.UpdateReorderSettings(id, reorderPoint, reorderQuantity, version, ctx)
+}
+
+// MoveStock moves units between stock buckets. Moves into or out of the
+// available bucket are recorded in the stock history as transfers. The lots
+// and serials of tracked products know nothing of buckets, so their units
+// cannot be moved to damaged or quarantined here; lots are quarantined as a
+// whole instead.
+func (p *ProductService) MoveStock(id string, move domain.StockMove, version int, ctx context.Context) (*domain.Product, error) {
+	if !move.From.Valid() || !move.To.Valid() {
+		return nil, errors.New("stock bucket must be available, reserved, damaged or quarantined")
+	}
+	if move.From == move.To {
+		return nil, errors.New("stock must move between different buckets")
+	}
+	if move.Quantity < 1 {
+		return nil, errors.New("quantity must be greater than 0")
+	}
+	if move.To == domain.BucketDamaged || move.To == domain.BucketQuarantined {
+		current, err := p.productRepository.FindByID(id, ctx)
+		if err != nil {
+			return nil, err
+		}
+		if current.LotTracked || current.Serialized {
+			return nil, errors.New("stock of lot-tracked or serialized products cannot be moved to damaged or quarantined")
+		}
+	}
+	var product *domain.Product
+	err := p.transactor.WithinTx(func(ctx context.Context) error {
+		var err error
+		product, err = p.productRepository.MoveStock(id, move.From, move.To, move.Quantity, version, ctx)
+		if err != nil {
+			return err
+		}
+		switch {
+		case move.From == domain.BucketAvailable:
+			err = p.stockHistoryService.Record(id, -move.Quantity, domain.MovementTransfer, string(move.To), ctx)
+		case move.To == domain.BucketAvailable:
+			err = p.stockHistoryService.Record(id, move.Quantity, domain.MovementTransfer, string(move.From), ctx)
+		}
+		if err != nil {
+			return err
+		}
+		return p.eventService.StageStock(product, ctx)
+	}, ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.eventService.PublishStock(product, ctx)
+	return product, nil
+}
+
+// SetUnit defines how many base units a unit of measure holds for the
+// product. The units are part of the product, so the product must still be at
+// version, unless it is 0, and moves to the next one.
+func (p *ProductService) SetUnit(unit *domain.ProductUnit, version int, ctx context.Context) (*domain.Product, error) {
+	if !unit.Unit.Valid() {
+		return nil, errors.New("unit must be each, pack, case or pallet")
+	}
+	if unit.Factor < 1 {
+		return nil, errors.New("factor must be greater than 0")
+	}
+	if unit.Unit == domain.UnitEach && unit.Factor != 1 {
+		return nil, errors.New("each is the base unit and always has factor 1")
+	}
+	return p.productRepository.SaveUnit(unit, version, ctx)
+}
+
+// FindUnits lists the product's units, including the base unit.
+func (p *ProductService) FindUnits(productID string, ctx context.Context) ([]domain.ProductUnit, error) {
+	if _, err := p.productRepository.FindByID(productID, ctx); err != nil {
+		return nil, err
+	}
+	units, err := p.productRepository.FindUnits(productID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, unit := range units {
+		if unit.Unit == domain.UnitEach {
+			return units, nil
+		}
+	}
+	return append([]domain.ProductUnit{{ProductID: productID, Unit: domain.UnitEach, Factor: 1}}, units...), nil
 }
 
 func (p *ProductService) FindAll(ctx context.Context) ([]domain.Product, error) {
 	return p.productRepository.FindAll(ctx)
 }
+
+// toBaseQuantity converts a quantity in one of the product's units to base
+// units. Quantities that do not come to a whole number of base units are
+// rejected.
+func toBaseQuantity(productRepository domain.ProductRepository, productID string, unit domain.UnitOfMeasure, quantity float64, ctx context.Context) (int, error) {
+	factor := 1
+	if unit != domain.UnitEach {
+		units, err := productRepository.FindUnits(productID, ctx)
+		if err != nil {
+			return 0, err
+		}
+		factor = 0
+		for _, u := range units {
+			if u.Unit == unit {
+				factor = u.Factor
+			}
+		}
+		if factor == 0 {
+			return 0, errors.New("unit " + string(unit) + " is not defined for this product")
+		}
+	}
+	base := quantity * float64(factor)
+	rounded := math.Round(base)
+	if math.Abs(base-rounded) > 1e-9 {
+		return 0, errors.New("quantity must come to a whole number of base units")
+	}
+	if rounded < 1 {
+		return 0, errors.New("quantity must be greater than 0")
+	}
+	return int(rounded), nil
+}
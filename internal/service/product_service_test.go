@@ -2,29 +2,76 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/iamtbay/is-management/internal/domain"
 )
 
 type mockProductRepo struct {
-	fakeProduct *domain.Product
-	fakeError   error
+	fakeProduct  *domain.Product
+	fakeProducts []domain.Product
+	fakeUnits    []domain.ProductUnit
+	fakeError    error
 }
 
 func (m *mockProductRepo) FindByID(id string, ctx context.Context) (*domain.Product, error) {
 	return m.fakeProduct, m.fakeError
 }
 
-func (m *mockProductRepo) UpdateStock(id string, stockQuantity int, ctx context.Context) (*domain.Product, error) {
+func (m *mockProductRepo) UpdateStock(id string, stockQuantity int, version int, ctx context.Context) (*domain.Product, error) {
+	if m.fakeProduct != nil && version != 0 && version != m.fakeProduct.Version {
+		return nil, domain.ErrVersionConflict
+	}
 	if m.fakeProduct != nil {
 		m.fakeProduct.Stock -= stockQuantity
 	}
 	return m.fakeProduct, m.fakeError
 }
 
+func (m *mockProductRepo) IncreaseStock(id string, stockQuantity int, ctx context.Context) (*domain.Product, error) {
+	if m.fakeProduct != nil {
+		m.fakeProduct.Stock += stockQuantity
+	}
+	return m.fakeProduct, m.fakeError
+}
+
+func (m *mockProductRepo) UpdateReorderSettings(id string, reorderPoint int, reorderQuantity int, version int, ctx context.Context) (*domain.Product, error) {
+	if m.fakeProduct != nil {
+		m.fakeProduct.ReorderPoint = reorderPoint
+		m.fakeProduct.ReorderQuantity = reorderQuantity
+	}
+	return m.fakeProduct, m.fakeError
+}
+
+func (m *mockProductRepo) MoveStock(id string, from domain.StockBucket, to domain.StockBucket, quantity int, version int, ctx context.Context) (*domain.Product, error) {
+	if m.fakeProduct != nil {
+		buckets := map[domain.StockBucket]*int{
+			domain.BucketAvailable:   &m.fakeProduct.Stock,
+			domain.BucketReserved:    &m.fakeProduct.Reserved,
+			domain.BucketDamaged:     &m.fakeProduct.Damaged,
+			domain.BucketQuarantined: &m.fakeProduct.Quarantined,
+		}
+		if *buckets[from] < quantity {
+			return nil, errors.New("not enough stock in " + string(from))
+		}
+		*buckets[from] -= quantity
+		*buckets[to] += quantity
+	}
+	return m.fakeProduct, m.fakeError
+}
+
+func (m *mockProductRepo) SaveUnit(unit *domain.ProductUnit, version int, ctx context.Context) (*domain.Product, error) {
+	m.fakeUnits = append(m.fakeUnits, *unit)
+	return m.fakeProduct, nil
+}
+
+func (m *mockProductRepo) FindUnits(productID string, ctx context.Context) ([]domain.ProductUnit, error) {
+	return m.fakeUnits, nil
+}
+
 func (m *mockProductRepo) FindAll(ctx context.Context) ([]domain.Product, error) {
-	return nil, nil
+	return m.fakeProducts, nil
 }
 
 func (m *mockProductRepo) Save(product *domain.Product, ctx context.Context) error {
@@ -32,9 +79,29 @@ func (m *mockProductRepo) Save(product *domain.Product, ctx context.Context) err
 }
 
 // TESTS
+func TestCreateProduct_RecordsInitialStock(t *testing.T) {
+	mockHRepo := &mockStockHistoryRepo{}
+	mockCRepo := &mockCostLayerRepo{}
+	svc := NewProductService(ProductServiceDeps{Transactor: &mockTransactor{}, Products: &mockProductRepo{}, Valuation: NewValuationService(mockCRepo, domain.CostingFIFO), StockHistory: NewStockHistoryService(mockHRepo), Events: NewEventService(10)})
+	product := &domain.Product{Name: "Laptop", Stock: 12}
+	if err := svc.CreateProduct(product, 4.5, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(mockHRepo.movements) != 1 {
+		t.Fatalf("expected 1 movement, got %v", len(mockHRepo.movements))
+	}
+	movement := mockHRepo.movements[0]
+	if movement.ProductID != product.ID || movement.Quantity != 12 || movement.Reason != domain.MovementInitial {
+		t.Errorf("unexpected movement %+v", movement)
+	}
+	if len(mockCRepo.savedLayers) != 1 || mockCRepo.savedLayers[0].Quantity != 12 || mockCRepo.savedLayers[0].UnitCost != 4.5 {
+		t.Errorf("expected an opening layer of 12 at 4.5, got %+v", mockCRepo.savedLayers)
+	}
+}
+
 func TestFindByID(t *testing.T) {
 	mockPRepo := &mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1", Name: "Laptop", Price: 100.0, Stock: 10}}
-	svc := NewProductService(mockPRepo)
+	svc := NewProductService(ProductServiceDeps{Products: mockPRepo})
 	product, err := svc.FindProductByID("prod-1", context.Background())
 	if err != nil {
 		t.Errorf("expected nil error, got %v", err)
@@ -46,8 +113,8 @@ func TestFindByID(t *testing.T) {
 
 func TestUpdateStock(t *testing.T) {
 	mockPRepo := &mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1", Name: "Laptop", Price: 100.0, Stock: 10}}
-	svc := NewProductService(mockPRepo)
-	product, err := svc.UpdateStock("prod-1", 2, context.Background())
+	svc := NewProductService(ProductServiceDeps{Transactor: &mockTransactor{}, Products: mockPRepo, Valuation: NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO), StockHistory: NewStockHistoryService(&mockStockHistoryRepo{}), Events: NewEventService(10)})
+	product, err := svc.UpdateStock("prod-1", 2, 0, context.Background())
 	if err != nil {
 		t.Errorf("expected nil error, got %v", err)
 	}
@@ -56,3 +123,189 @@ func TestUpdateStock(t *testing.T) {
 	}
 }
 
+func TestUpdateStock_ConsumesCostLayers(t *testing.T) {
+	mockCRepo := &mockCostLayerRepo{fakeLayers: twoCostLayers()}
+	svc := NewProductService(ProductServiceDeps{Transactor: &mockTransactor{}, Products: &mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1", Stock: 14}}, Valuation: NewValuationService(mockCRepo, domain.CostingFIFO), StockHistory: NewStockHistoryService(&mockStockHistoryRepo{}), Events: NewEventService(10)})
+	if _, err := svc.UpdateStock("prod-1", 6, 0, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	consumed := 0
+	for _, c := range mockCRepo.consumptions {
+		if c.OrderID != "" {
+			t.Errorf("expected an adjustment without an order, got %+v", c)
+		}
+		consumed += c.Quantity
+	}
+	if consumed != 6 {
+		t.Errorf("expected 6 units consumed from the layers, got %v", consumed)
+	}
+}
+
+func TestUpdateStock_NonPositive(t *testing.T) {
+	product := &domain.Product{ID: "prod-1", Stock: 10}
+	svc := NewProductService(ProductServiceDeps{Transactor: &mockTransactor{}, Products: &mockProductRepo{fakeProduct: product}, StockHistory: NewStockHistoryService(&mockStockHistoryRepo{}), Events: NewEventService(10)})
+	for _, quantity := range []int{0, -5} {
+		if _, err := svc.UpdateStock("prod-1", quantity, 0, context.Background()); err == nil {
+			t.Errorf("expected error for quantity %v, got nil", quantity)
+		}
+	}
+	if product.Stock != 10 {
+		t.Errorf("expected stock 10, got %v", product.Stock)
+	}
+}
+
+func TestUpdateStock_VersionConflict(t *testing.T) {
+	product := &domain.Product{ID: "prod-1", Stock: 10, Version: 3}
+	mockHRepo := &mockStockHistoryRepo{}
+	svc := NewProductService(ProductServiceDeps{Transactor: &mockTransactor{}, Products: &mockProductRepo{fakeProduct: product}, Valuation: NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO), StockHistory: NewStockHistoryService(mockHRepo), Events: NewEventService(10)})
+	if _, err := svc.UpdateStock("prod-1", 2, 2, context.Background()); !errors.Is(err, domain.ErrVersionConflict) {
+		t.Fatalf("expected version conflict, got %v", err)
+	}
+	if product.Stock != 10 {
+		t.Errorf("expected stock 10, got %v", product.Stock)
+	}
+	if len(mockHRepo.movements) != 0 {
+		t.Errorf("expected no movement to be recorded, got %v", len(mockHRepo.movements))
+	}
+	if _, err := svc.UpdateStock("prod-1", 2, 3, context.Background()); err != nil {
+		t.Errorf("expected nil error for the current version, got %v", err)
+	}
+}
+
+func TestUpdateStock_TrackedProducts(t *testing.T) {
+	for _, product := range []*domain.Product{
+		{ID: "prod-1", Stock: 10, LotTracked: true},
+		{ID: "prod-1", Stock: 10, Serialized: true},
+	} {
+		mockHRepo := &mockStockHistoryRepo{}
+		svc := NewProductService(ProductServiceDeps{Transactor: &mockTransactor{}, Products: &mockProductRepo{fakeProduct: product}, StockHistory: NewStockHistoryService(mockHRepo), Events: NewEventService(10)})
+		if _, err := svc.UpdateStock("prod-1", 2, 0, context.Background()); err == nil {
+			t.Errorf("expected error adjusting %+v, got nil", product)
+		}
+		if product.Stock != 10 || len(mockHRepo.movements) != 0 {
+			t.Errorf("expected %+v to be unchanged, got %v movements", product, len(mockHRepo.movements))
+		}
+	}
+}
+
+func TestUpdateReorderSettings_Negative(t *testing.T) {
+	mockPRepo := &mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1"}}
+	svc := NewProductService(ProductServiceDeps{Transactor: &mockTransactor{}, Products: mockPRepo, StockHistory: NewStockHistoryService(&mockStockHistoryRepo{}), Events: NewEventService(10)})
+	if _, err := svc.UpdateReorderSettings("prod-1", -1, 10, 0, context.Background()); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func TestMoveStock(t *testing.T) {
+	product := &domain.Product{ID: "prod-1", Stock: 10}
+	mockHRepo := &mockStockHistoryRepo{}
+	svc := NewProductService(ProductServiceDeps{Transactor: &mockTransactor{}, Products: &mockProductRepo{fakeProduct: product}, StockHistory: NewStockHistoryService(mockHRepo), Events: NewEventService(10)})
+
+	if _, err := svc.MoveStock("prod-1", domain.StockMove{From: domain.BucketAvailable, To: domain.BucketQuarantined, Quantity: 4}, 0, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := svc.MoveStock("prod-1", domain.StockMove{From: domain.BucketQuarantined, To: domain.BucketDamaged, Quantity: 1}, 0, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := svc.MoveStock("prod-1", domain.StockMove{From: domain.BucketQuarantined, To: domain.BucketAvailable, Quantity: 3}, 0, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if product.Stock != 9 || product.Quarantined != 0 || product.Damaged != 1 {
+		t.Errorf("unexpected buckets %+v", product)
+	}
+	if len(mockHRepo.movements) != 2 || mockHRepo.movements[0].Quantity != -4 || mockHRepo.movements[1].Quantity != 3 {
+		t.Errorf("expected two transfer movements, got %+v", mockHRepo.movements)
+	}
+}
+
+func TestMoveStock_Invalid(t *testing.T) {
+	svc := NewProductService(ProductServiceDeps{Transactor: &mockTransactor{}, Products: &mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1", Stock: 10}}, StockHistory: NewStockHistoryService(&mockStockHistoryRepo{}), Events: NewEventService(10)})
+	moves := []domain.StockMove{
+		{From: domain.BucketAvailable, To: domain.BucketAvailable, Quantity: 1},
+		{From: domain.BucketAvailable, To: "lost", Quantity: 1},
+		{From: domain.BucketAvailable, To: domain.BucketDamaged, Quantity: 0},
+		{From: domain.BucketReserved, To: domain.BucketAvailable, Quantity: 1},
+	}
+	for _, move := range moves {
+		if _, err := svc.MoveStock("prod-1", move, 0, context.Background()); err == nil {
+			t.Errorf("expected error for %+v, got nil", move)
+		}
+	}
+}
+
+func TestMoveStock_TrackedProducts(t *testing.T) {
+	for _, product := range []*domain.Product{
+		{ID: "prod-1", Stock: 10, LotTracked: true},
+		{ID: "prod-1", Stock: 10, Serialized: true},
+	} {
+		svc := NewProductService(ProductServiceDeps{Transactor: &mockTransactor{}, Products: &mockProductRepo{fakeProduct: product}, StockHistory: NewStockHistoryService(&mockStockHistoryRepo{}), Events: NewEventService(10)})
+		for _, to := range []domain.StockBucket{domain.BucketDamaged, domain.BucketQuarantined} {
+			if _, err := svc.MoveStock("prod-1", domain.StockMove{From: domain.BucketAvailable, To: to, Quantity: 1}, 0, context.Background()); err == nil {
+				t.Errorf("expected error moving %+v to %v, got nil", product, to)
+			}
+		}
+		if _, err := svc.MoveStock("prod-1", domain.StockMove{From: domain.BucketAvailable, To: domain.BucketReserved, Quantity: 1}, 0, context.Background()); err != nil {
+			t.Errorf("expected reserving %+v to work, got %v", product, err)
+		}
+		if product.Stock != 9 || product.Damaged != 0 || product.Quarantined != 0 {
+			t.Errorf("unexpected buckets %+v", product)
+		}
+	}
+}
+
+func TestSetUnit(t *testing.T) {
+	mockPRepo := &mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1"}}
+	svc := NewProductService(ProductServiceDeps{Transactor: &mockTransactor{}, Products: mockPRepo, StockHistory: NewStockHistoryService(&mockStockHistoryRepo{}), Events: NewEventService(10)})
+
+	invalid := []domain.ProductUnit{
+		{ProductID: "prod-1", Unit: "crate", Factor: 12},
+		{ProductID: "prod-1", Unit: domain.UnitCase, Factor: 0},
+		{ProductID: "prod-1", Unit: domain.UnitEach, Factor: 2},
+	}
+	for _, unit := range invalid {
+		if _, err := svc.SetUnit(&unit, 0, context.Background()); err == nil {
+			t.Errorf("expected error for %+v, got nil", unit)
+		}
+	}
+	if _, err := svc.SetUnit(&domain.ProductUnit{ProductID: "prod-1", Unit: domain.UnitCase, Factor: 24}, 0, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	units, err := svc.FindUnits("prod-1", context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(units) != 2 || units[0].Unit != domain.UnitEach || units[1].Factor != 24 {
+		t.Errorf("expected each and case units, got %+v", units)
+	}
+}
+
+func TestToBaseQuantity(t *testing.T) {
+	mockPRepo := &mockProductRepo{fakeUnits: []domain.ProductUnit{
+		{ProductID: "prod-1", Unit: domain.UnitPack, Factor: 6},
+		{ProductID: "prod-1", Unit: domain.UnitCase, Factor: 24},
+	}}
+	tests := []struct {
+		unit     domain.UnitOfMeasure
+		quantity float64
+		base     int
+		wantErr  bool
+	}{
+		{domain.UnitEach, 3, 3, false},
+		{domain.UnitCase, 2, 48, false},
+		{domain.UnitCase, 0.5, 12, false},
+		{domain.UnitPack, 0.5, 3, false},
+		{domain.UnitPack, 0.25, 0, true},
+		{domain.UnitEach, 1.5, 0, true},
+		{domain.UnitPallet, 1, 0, true},
+		{domain.UnitCase, 0, 0, true},
+	}
+	for _, tt := range tests {
+		base, err := toBaseQuantity(mockPRepo, "prod-1", tt.unit, tt.quantity, context.Background())
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v %v: expected error %v, got %v", tt.quantity, tt.unit, tt.wantErr, err)
+		}
+		if base != tt.base {
+			t.Errorf("%v %v: expected %v base units, got %v", tt.quantity, tt.unit, tt.base, base)
+		}
+	}
+}
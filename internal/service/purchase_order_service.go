@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/pkg/helpers"
+)
+
+type PurchaseOrderService struct {
+	transactor              domain.Transactor
+	purchaseOrderRepository domain.PurchaseOrderRepository
+	supplierRepository      domain.SupplierRepository
+	productRepository       domain.ProductRepository
+	valuationService        *ValuationService
+	stockHistoryService     *StockHistoryService
+	lotService              *LotService
+	serialService           *SerialService
+	atpService              *ATPService
+	eventService            *EventService
+}
+
+// PurchaseOrderServiceDeps are the repositories and services a
+// PurchaseOrderService works with.
+type PurchaseOrderServiceDeps struct {
+	Transactor     domain.Transactor
+	PurchaseOrders domain.PurchaseOrderRepository
+	Suppliers      domain.SupplierRepository
+	Products       domain.ProductRepository
+	Valuation      *ValuationService
+	StockHistory   *StockHistoryService
+	Lots           *LotService
+	Serials        *SerialService
+	ATP            *ATPService
+	Events         *EventService
+}
+
+func NewPurchaseOrderService(deps PurchaseOrderServiceDeps) *PurchaseOrderService {
+	return &PurchaseOrderService{
+		transactor:              deps.Transactor,
+		purchaseOrderRepository: deps.PurchaseOrders,
+		supplierRepository:      deps.Suppliers,
+		productRepository:       deps.Products,
+		valuationService:        deps.Valuation,
+		stockHistoryService:     deps.StockHistory,
+		lotService:              deps.Lots,
+		serialService:           deps.Serials,
+		atpService:              deps.ATP,
+		eventService:            deps.Events,
+	}
+}
+
+// CreatePurchaseOrder stores a new draft purchase order. Lines without a unit
+// cost take the cost agreed with the supplier for that product.
+func (s *PurchaseOrderService) CreatePurchaseOrder(purchaseOrder *domain.PurchaseOrder, ctx context.Context) error {
+	if len(purchaseOrder.Lines) == 0 {
+		return errors.New("purchase order must have at least one line")
+	}
+	if _, err := s.supplierRepository.FindByID(purchaseOrder.SupplierID, ctx); err != nil {
+		return err
+	}
+	links, err := s.supplierRepository.FindProducts(purchaseOrder.SupplierID, ctx)
+	if err != nil {
+		return err
+	}
+	costs := make(map[string]float64, len(links))
+	for _, link := range links {
+		costs[link.ProductID] = link.UnitCost
+	}
+
+	purchaseOrder.ID = helpers.GenerateUUID()
+	for i := range purchaseOrder.Lines {
+		line := &purchaseOrder.Lines[i]
+		if line.Quantity < 1 {
+			return errors.New("quantity must be greater than 0")
+		}
+		if line.UnitCost < 0 {
+			return errors.New("unit cost cannot be negative")
+		}
+		if _, err := s.productRepository.FindByID(line.ProductID, ctx); err != nil {
+			return err
+		}
+		if line.UnitCost == 0 {
+			line.UnitCost = costs[line.ProductID]
+		}
+		line.ID = helpers.GenerateUUID()
+		line.PurchaseOrderID = purchaseOrder.ID
+		line.ReceivedQuantity = 0
+	}
+	purchaseOrder.Status = domain.PurchaseOrderDraft
+	purchaseOrder.CreatedAt = time.Now().UTC()
+	return s.purchaseOrderRepository.Save(purchaseOrder, ctx)
+}
+
+func (s *PurchaseOrderService) FindAll(ctx context.Context) ([]domain.PurchaseOrder, error) {
+	return s.purchaseOrderRepository.FindAll(ctx)
+}
+
+func (s *PurchaseOrderService) FindByID(id string, ctx context.Context) (*domain.PurchaseOrder, error) {
+	return s.purchaseOrderRepository.FindByID(id, ctx)
+}
+
+// SendPurchaseOrder marks a draft purchase order as sent to the supplier.
+func (s *PurchaseOrderService) SendPurchaseOrder(id string, ctx context.Context) (*domain.PurchaseOrder, error) {
+	purchaseOrder, err := s.purchaseOrderRepository.FindByID(id, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if purchaseOrder.Status != domain.PurchaseOrderDraft {
+		return nil, errors.New("only draft purchase orders can be sent")
+	}
+	if err := s.purchaseOrderRepository.UpdateStatus(id, domain.PurchaseOrderSent, ctx); err != nil {
+		return nil, err
+	}
+	purchaseOrder.Status = domain.PurchaseOrderSent
+	return purchaseOrder, nil
+}
+
+// ClosePurchaseOrder closes a purchase order, cancelling anything not received yet.
+func (s *PurchaseOrderService) ClosePurchaseOrder(id string, ctx context.Context) (*domain.PurchaseOrder, error) {
+	purchaseOrder, err := s.purchaseOrderRepository.FindByID(id, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if purchaseOrder.Status == domain.PurchaseOrderClosed {
+		return nil, errors.New("purchase order is already closed")
+	}
+	if err := s.purchaseOrderRepository.UpdateStatus(id, domain.PurchaseOrderClosed, ctx); err != nil {
+		return nil, err
+	}
+	purchaseOrder.Status = domain.PurchaseOrderClosed
+	return purchaseOrder, nil
+}
+
+// ReceivePurchaseOrder books a (possibly partial) delivery against a sent
+// purchase order and adds the received quantities to product stock. Receipt
+// lines refer to an order line by line_id, or by product_id when the order
+// has a single open line for that product. Lines of lot-tracked products must
+// carry a lot number and go into that lot; lines of serialized products must
+// list one new serial per unit. Lines received in a unit of measure are
+// converted to base units.
+func (s *PurchaseOrderService) ReceivePurchaseOrder(id string, receipt *domain.Receipt, ctx context.Context) (*domain.PurchaseOrder, error) {
+	if len(receipt.Lines) == 0 {
+		return nil, errors.New("receipt must have at least one line")
+	}
+
+	// the purchase order stays locked while the receipt is checked against it,
+	// and the receipt and the stock, history, cost layers, lots, serials and
+	// expected receipts it books are saved together or not at all
+	var purchaseOrder *domain.PurchaseOrder
+	products := make([]*domain.Product, 0, len(receipt.Lines))
+	err := s.transactor.WithinTx(func(ctx context.Context) error {
+		var err error
+		purchaseOrder, err = s.purchaseOrderRepository.FindByIDForUpdate(id, ctx)
+		if err != nil {
+			return err
+		}
+		if purchaseOrder.Status != domain.PurchaseOrderSent && purchaseOrder.Status != domain.PurchaseOrderPartiallyReceived {
+			return errors.New("only sent purchase orders can be received")
+		}
+		unitCosts, lotTracked, err := s.checkReceipt(purchaseOrder, receipt, ctx)
+		if err != nil {
+			return err
+		}
+		purchaseOrder.Status, err = s.purchaseOrderRepository.SaveReceipt(receipt, ctx)
+		if err != nil {
+			return err
+		}
+		for _, receiptLine := range receipt.Lines {
+			product, err := s.productRepository.IncreaseStock(receiptLine.ProductID, receiptLine.Quantity, ctx)
+			if err != nil {
+				return err
+			}
+			products = append(products, product)
+			if err := s.eventService.StageStock(product, ctx); err != nil {
+				return err
+			}
+			if err := s.stockHistoryService.Record(receiptLine.ProductID, receiptLine.Quantity, domain.MovementReceipt, receiptLine.ID, ctx); err != nil {
+				return err
+			}
+			if err := s.valuationService.AddLayer(receiptLine.ProductID, receiptLine.ID, receiptLine.Quantity, unitCosts[receiptLine.ID], ctx); err != nil {
+				return err
+			}
+			if lotTracked[receiptLine.ID] {
+				if _, err := s.lotService.Receive(receiptLine, receipt.ReceivedAt, ctx); err != nil {
+					return err
+				}
+			}
+			if len(receiptLine.Serials) > 0 {
+				if err := s.serialService.Receive(receiptLine.ProductID, receiptLine.ID, receiptLine.Serials, receipt.ReceivedAt, ctx); err != nil {
+					return err
+				}
+			}
+			if err := s.atpService.BookReceipt(purchaseOrder.ID, receiptLine.ProductID, receiptLine.Quantity, ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, product := range products {
+		s.eventService.PublishStock(product, ctx)
+	}
+	return purchaseOrder, nil
+}
+
+// checkReceipt matches the receipt lines to the lines of the purchase order
+// and checks them. It returns the unit cost and whether the product is
+// lot-tracked per receipt line.
+func (s *PurchaseOrderService) checkReceipt(purchaseOrder *domain.PurchaseOrder, receipt *domain.Receipt, ctx context.Context) (map[string]float64, map[string]bool, error) {
+	receipt.ID = helpers.GenerateUUID()
+	receipt.PurchaseOrderID = purchaseOrder.ID
+	receipt.ReceivedAt = time.Now().UTC()
+	unitCosts := make(map[string]float64, len(receipt.Lines))
+	lotTracked := make(map[string]bool, len(receipt.Lines))
+	for i := range receipt.Lines {
+		receiptLine := &receipt.Lines[i]
+		line, err := findReceivableLine(purchaseOrder, receiptLine)
+		if err != nil {
+			return nil, nil, err
+		}
+		if receiptLine.Unit != "" {
+			receiptLine.Quantity, err = toBaseQuantity(s.productRepository, line.ProductID, receiptLine.Unit, receiptLine.UnitQuantity, ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		if receiptLine.Quantity < 1 {
+			return nil, nil, errors.New("quantity must be greater than 0")
+		}
+		if receiptLine.Quantity > line.Remaining() {
+			return nil, nil, errors.New("received quantity exceeds ordered quantity")
+		}
+		receiptLine.ProductID = line.ProductID
+		product, err := s.productRepository.FindByID(line.ProductID, ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if product.LotTracked {
+			if err := s.lotService.CheckReceivable(*receiptLine, ctx); err != nil {
+				return nil, nil, err
+			}
+		}
+		if product.Serialized {
+			if err := s.serialService.CheckReceivable(receiptLine.Serials, receiptLine.Quantity, ctx); err != nil {
+				return nil, nil, err
+			}
+		} else if len(receiptLine.Serials) > 0 {
+			return nil, nil, errors.New("product is not serialized")
+		}
+		line.ReceivedQuantity += receiptLine.Quantity
+		receiptLine.ID = helpers.GenerateUUID()
+		receiptLine.PurchaseOrderLineID = line.ID
+		unitCosts[receiptLine.ID] = line.UnitCost
+		lotTracked[receiptLine.ID] = product.LotTracked
+	}
+
+	return unitCosts, lotTracked, nil
+}
+
+func findReceivableLine(purchaseOrder *domain.PurchaseOrder, receiptLine *domain.ReceiptLine) (*domain.PurchaseOrderLine, error) {
+	var match *domain.PurchaseOrderLine
+	for i := range purchaseOrder.Lines {
+		line := &purchaseOrder.Lines[i]
+		if receiptLine.PurchaseOrderLineID != "" {
+			if line.ID == receiptLine.PurchaseOrderLineID {
+				return line, nil
+			}
+			continue
+		}
+		if line.ProductID == receiptLine.ProductID && line.Remaining() > 0 {
+			if match != nil {
+				return nil, errors.New("product has several open lines, line_id is required")
+			}
+			match = line
+		}
+	}
+	if match == nil {
+		return nil, errors.New("purchase order line not found")
+	}
+	return match, nil
+}
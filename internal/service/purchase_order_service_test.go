@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+type mockPurchaseOrderRepo struct {
+	fakePurchaseOrder *domain.PurchaseOrder
+	savedReceipt      *domain.Receipt
+	savedStatus       domain.PurchaseOrderStatus
+	savedOrders       []*domain.PurchaseOrder
+	fakeOpen          map[string]int
+	lockedReads       int
+}
+
+func (m *mockPurchaseOrderRepo) Save(purchaseOrder *domain.PurchaseOrder, ctx context.Context) error {
+	m.fakePurchaseOrder = purchaseOrder
+	m.savedOrders = append(m.savedOrders, purchaseOrder)
+	return nil
+}
+
+func (m *mockPurchaseOrderRepo) FindAll(ctx context.Context) ([]domain.PurchaseOrder, error) {
+	return nil, nil
+}
+
+func (m *mockPurchaseOrderRepo) FindByID(id string, ctx context.Context) (*domain.PurchaseOrder, error) {
+	return m.fakePurchaseOrder, nil
+}
+
+func (m *mockPurchaseOrderRepo) FindByIDForUpdate(id string, ctx context.Context) (*domain.PurchaseOrder, error) {
+	m.lockedReads++
+	return m.fakePurchaseOrder, nil
+}
+
+func (m *mockPurchaseOrderRepo) UpdateStatus(id string, status domain.PurchaseOrderStatus, ctx context.Context) error {
+	m.savedStatus = status
+	return nil
+}
+
+func (m *mockPurchaseOrderRepo) SaveReceipt(receipt *domain.Receipt, ctx context.Context) (domain.PurchaseOrderStatus, error) {
+	m.savedReceipt = receipt
+	m.savedStatus = domain.PurchaseOrderClosed
+	for _, line := range m.fakePurchaseOrder.Lines {
+		if line.Remaining() > 0 {
+			m.savedStatus = domain.PurchaseOrderPartiallyReceived
+		}
+	}
+	return m.savedStatus, nil
+}
+
+func (m *mockPurchaseOrderRepo) OpenQuantities(ctx context.Context) (map[string]int, error) {
+	return m.fakeOpen, nil
+}
+
+func sentPurchaseOrder() *domain.PurchaseOrder {
+	return &domain.PurchaseOrder{
+		ID:         "po-1",
+		SupplierID: "sup-1",
+		Status:     domain.PurchaseOrderSent,
+		Lines: []domain.PurchaseOrderLine{
+			{ID: "line-1", PurchaseOrderID: "po-1", ProductID: "prod-1", Quantity: 10, UnitCost: 2},
+		},
+	}
+}
+
+// TESTS
+func TestCreatePurchaseOrder_DefaultsToSupplierCost(t *testing.T) {
+	mockPORepo := &mockPurchaseOrderRepo{}
+	mockSRepo := &mockSupplierRepo{
+		fakeSupplier: &domain.Supplier{ID: "sup-1"},
+		fakeLinks:    []domain.SupplierProduct{{SupplierID: "sup-1", ProductID: "prod-1", UnitCost: 3.5}},
+	}
+	mockPRepo := &mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1"}}
+	svc := NewPurchaseOrderService(PurchaseOrderServiceDeps{
+		Transactor:     &mockTransactor{},
+		PurchaseOrders: mockPORepo,
+		Suppliers:      mockSRepo,
+		Products:       mockPRepo,
+		Valuation:      NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+		StockHistory:   NewStockHistoryService(&mockStockHistoryRepo{}),
+		Lots:           NewLotService(LotServiceDeps{Lots: &mockLotRepo{}}),
+		Serials:        newTestSerialService(&mockSerialRepo{}, &mockProductRepo{}, &mockCostLayerRepo{}, &mockStockHistoryRepo{}),
+		ATP:            NewATPService(&mockProductRepo{}, &mockExpectedReceiptRepo{}),
+		Events:         NewEventService(10),
+	})
+
+	purchaseOrder := &domain.PurchaseOrder{
+		SupplierID: "sup-1",
+		Lines:      []domain.PurchaseOrderLine{{ProductID: "prod-1", Quantity: 5}},
+	}
+	if err := svc.CreatePurchaseOrder(purchaseOrder, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if purchaseOrder.Status != domain.PurchaseOrderDraft {
+		t.Errorf("expected status draft, got %v", purchaseOrder.Status)
+	}
+	if purchaseOrder.Lines[0].UnitCost != 3.5 {
+		t.Errorf("expected unit cost 3.5, got %v", purchaseOrder.Lines[0].UnitCost)
+	}
+	if purchaseOrder.Lines[0].PurchaseOrderID != purchaseOrder.ID {
+		t.Errorf("expected line to reference purchase order %v, got %v", purchaseOrder.ID, purchaseOrder.Lines[0].PurchaseOrderID)
+	}
+}
+
+func TestReceivePurchaseOrder_Partial(t *testing.T) {
+	product := &domain.Product{ID: "prod-1", Stock: 1}
+	mockPORepo := &mockPurchaseOrderRepo{fakePurchaseOrder: sentPurchaseOrder()}
+	mockCRepo := &mockCostLayerRepo{}
+	svc := NewPurchaseOrderService(PurchaseOrderServiceDeps{
+		Transactor:     &mockTransactor{},
+		PurchaseOrders: mockPORepo,
+		Suppliers:      &mockSupplierRepo{},
+		Products:       &mockProductRepo{fakeProduct: product},
+		Valuation:      NewValuationService(mockCRepo, domain.CostingFIFO),
+		StockHistory:   NewStockHistoryService(&mockStockHistoryRepo{}),
+		Lots:           NewLotService(LotServiceDeps{Lots: &mockLotRepo{}}),
+		Serials:        newTestSerialService(&mockSerialRepo{}, &mockProductRepo{}, &mockCostLayerRepo{}, &mockStockHistoryRepo{}),
+		ATP:            NewATPService(&mockProductRepo{}, &mockExpectedReceiptRepo{}),
+		Events:         NewEventService(10),
+	})
+
+	receipt := &domain.Receipt{Lines: []domain.ReceiptLine{{ProductID: "prod-1", Quantity: 4}}}
+	purchaseOrder, err := svc.ReceivePurchaseOrder("po-1", receipt, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if purchaseOrder.Status != domain.PurchaseOrderPartiallyReceived {
+		t.Errorf("expected status partially_received, got %v", purchaseOrder.Status)
+	}
+	if mockPORepo.savedStatus != domain.PurchaseOrderPartiallyReceived {
+		t.Errorf("expected saved status partially_received, got %v", mockPORepo.savedStatus)
+	}
+	if mockPORepo.lockedReads != 1 {
+		t.Errorf("expected the purchase order to be read locked once, got %v", mockPORepo.lockedReads)
+	}
+	if receipt.Lines[0].PurchaseOrderLineID != "line-1" {
+		t.Errorf("expected receipt line to reference line-1, got %v", receipt.Lines[0].PurchaseOrderLineID)
+	}
+	if product.Stock != 5 {
+		t.Errorf("expected stock 5, got %v", product.Stock)
+	}
+	if len(mockCRepo.savedLayers) != 1 || mockCRepo.savedLayers[0].Quantity != 4 || mockCRepo.savedLayers[0].UnitCost != 2 {
+		t.Errorf("expected a cost layer of 4 units at 2, got %+v", mockCRepo.savedLayers)
+	}
+}
+
+func TestReceivePurchaseOrder_CompleteClosesOrder(t *testing.T) {
+	product := &domain.Product{ID: "prod-1"}
+	purchaseOrder := sentPurchaseOrder()
+	purchaseOrder.Status = domain.PurchaseOrderPartiallyReceived
+	purchaseOrder.Lines[0].ReceivedQuantity = 6
+	mockPORepo := &mockPurchaseOrderRepo{fakePurchaseOrder: purchaseOrder}
+	svc := NewPurchaseOrderService(PurchaseOrderServiceDeps{
+		Transactor:     &mockTransactor{},
+		PurchaseOrders: mockPORepo,
+		Suppliers:      &mockSupplierRepo{},
+		Products:       &mockProductRepo{fakeProduct: product},
+		Valuation:      NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+		StockHistory:   NewStockHistoryService(&mockStockHistoryRepo{}),
+		Lots:           NewLotService(LotServiceDeps{Lots: &mockLotRepo{}}),
+		Serials:        newTestSerialService(&mockSerialRepo{}, &mockProductRepo{}, &mockCostLayerRepo{}, &mockStockHistoryRepo{}),
+		ATP:            NewATPService(&mockProductRepo{}, &mockExpectedReceiptRepo{}),
+		Events:         NewEventService(10),
+	})
+
+	receipt := &domain.Receipt{Lines: []domain.ReceiptLine{{PurchaseOrderLineID: "line-1", Quantity: 4}}}
+	received, err := svc.ReceivePurchaseOrder("po-1", receipt, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if received.Status != domain.PurchaseOrderClosed {
+		t.Errorf("expected status closed, got %v", received.Status)
+	}
+	if product.Stock != 4 {
+		t.Errorf("expected stock 4, got %v", product.Stock)
+	}
+}
+
+func TestReceivePurchaseOrder_OverReceipt(t *testing.T) {
+	product := &domain.Product{ID: "prod-1"}
+	mockPORepo := &mockPurchaseOrderRepo{fakePurchaseOrder: sentPurchaseOrder()}
+	svc := NewPurchaseOrderService(PurchaseOrderServiceDeps{
+		Transactor:     &mockTransactor{},
+		PurchaseOrders: mockPORepo,
+		Suppliers:      &mockSupplierRepo{},
+		Products:       &mockProductRepo{fakeProduct: product},
+		Valuation:      NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+		StockHistory:   NewStockHistoryService(&mockStockHistoryRepo{}),
+		Lots:           NewLotService(LotServiceDeps{Lots: &mockLotRepo{}}),
+		Serials:        newTestSerialService(&mockSerialRepo{}, &mockProductRepo{}, &mockCostLayerRepo{}, &mockStockHistoryRepo{}),
+		ATP:            NewATPService(&mockProductRepo{}, &mockExpectedReceiptRepo{}),
+		Events:         NewEventService(10),
+	})
+
+	receipt := &domain.Receipt{Lines: []domain.ReceiptLine{{PurchaseOrderLineID: "line-1", Quantity: 11}}}
+	if _, err := svc.ReceivePurchaseOrder("po-1", receipt, context.Background()); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if mockPORepo.savedReceipt != nil {
+		t.Errorf("expected receipt not to be saved")
+	}
+	if product.Stock != 0 {
+		t.Errorf("expected stock 0, got %v", product.Stock)
+	}
+}
+
+func TestReceivePurchaseOrder_DraftRejected(t *testing.T) {
+	purchaseOrder := sentPurchaseOrder()
+	purchaseOrder.Status = domain.PurchaseOrderDraft
+	svc := NewPurchaseOrderService(PurchaseOrderServiceDeps{
+		Transactor:     &mockTransactor{},
+		PurchaseOrders: &mockPurchaseOrderRepo{fakePurchaseOrder: purchaseOrder},
+		Suppliers:      &mockSupplierRepo{},
+		Products:       &mockProductRepo{},
+		Valuation:      NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+		StockHistory:   NewStockHistoryService(&mockStockHistoryRepo{}),
+		Lots:           NewLotService(LotServiceDeps{Lots: &mockLotRepo{}}),
+		Serials:        newTestSerialService(&mockSerialRepo{}, &mockProductRepo{}, &mockCostLayerRepo{}, &mockStockHistoryRepo{}),
+		ATP:            NewATPService(&mockProductRepo{}, &mockExpectedReceiptRepo{}),
+		Events:         NewEventService(10),
+	})
+
+	receipt := &domain.Receipt{Lines: []domain.ReceiptLine{{PurchaseOrderLineID: "line-1", Quantity: 1}}}
+	if _, err := svc.ReceivePurchaseOrder("po-1", receipt, context.Background()); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
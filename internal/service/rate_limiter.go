@@ -0,0 +1,158 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit allows Requests per Per, in bursts of up to Requests.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l RateLimit) Enabled() bool {
+	return l.Requests > 0 && l.Per > 0
+}
+
+// ParseRateLimit reads a limit like "60/1m" or "10/s". An empty spec or "off"
+// is no limit.
+func ParseRateLimit(spec string) (RateLimit, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "off" {
+		return RateLimit{}, nil
+	}
+	requests, per, ok := strings.Cut(spec, "/")
+	if !ok {
+		return RateLimit{}, errors.New("rate limit " + spec + " must look like 60/1m")
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 1 {
+		return RateLimit{}, errors.New("rate limit " + spec + " must allow at least 1 request")
+	}
+	// "10/s" reads as "10/1s"
+	if per != "" && (per[0] < '0' || per[0] > '9') {
+		per = "1" + per
+	}
+	duration, err := time.ParseDuration(per)
+	if err != nil || duration <= 0 {
+		return RateLimit{}, errors.New("rate limit " + spec + " must have a positive period")
+	}
+	return RateLimit{Requests: n, Per: duration}, nil
+}
+
+// ParseRouteRateLimits reads comma separated route limits like
+// "POST /orders=60/1m,GET /reports/valuation=10/1m", keyed by route pattern.
+func ParseRouteRateLimits(spec string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pattern, limitSpec, ok := strings.Cut(entry, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || !strings.Contains(pattern, " /") {
+			return nil, errors.New("route rate limit " + entry + " must look like POST /orders=60/1m")
+		}
+		limit, err := ParseRateLimit(limitSpec)
+		if err != nil {
+			return nil, err
+		}
+		limits[pattern] = limit
+	}
+	return limits, nil
+}
+
+// RateDecision is the outcome of taking a token from a bucket.
+type RateDecision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until the next request is allowed, 0 when it is now.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// rateLimiterSweep is how often buckets that refilled are dropped.
+const rateLimiterSweep = time.Minute
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	limit   RateLimit
+}
+
+// refill adds the tokens earned since the last update.
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(float64(b.limit.Requests), b.tokens+elapsed.Seconds()*b.rate())
+		b.updated = now
+	}
+}
+
+// rate is the number of tokens added per second.
+func (b *tokenBucket) rate() float64 {
+	return float64(b.limit.Requests) / b.limit.Per.Seconds()
+}
+
+// RateLimiter keeps a token bucket per key in memory, so limits apply per
+// instance of the app.
+type RateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+// Allow takes a token from the bucket of key, which starts out full.
+func (l *RateLimiter) Allow(key string, limit RateLimit, now time.Time) RateDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok || bucket.limit != limit {
+		bucket = &tokenBucket{tokens: float64(limit.Requests), updated: now, limit: limit}
+		l.buckets[key] = bucket
+	}
+	bucket.refill(now)
+
+	decision := RateDecision{Limit: limit.Requests}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = seconds((1 - bucket.tokens) / bucket.rate())
+	}
+	decision.Remaining = int(bucket.tokens)
+	decision.Reset = seconds((float64(limit.Requests) - bucket.tokens) / bucket.rate())
+	return decision
+}
+
+// sweep drops the buckets that are full again, as they are the same as new ones.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimiterSweep {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		bucket.refill(now)
+		if bucket.tokens >= float64(bucket.limit.Requests) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
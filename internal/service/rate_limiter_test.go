@@ -0,0 +1,98 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// TESTS
+func TestRateLimiter_AllowsBurstThenRefills(t *testing.T) {
+	limiter := NewRateLimiter()
+	limit := RateLimit{Requests: 3, Per: 3 * time.Second}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		if decision := limiter.Allow("client", limit, now); !decision.Allowed || decision.Remaining != 2-i {
+			t.Fatalf("expected request %v to be allowed with %v remaining, got %+v", i+1, 2-i, decision)
+		}
+	}
+	decision := limiter.Allow("client", limit, now)
+	if decision.Allowed {
+		t.Fatalf("expected the fourth request to be limited")
+	}
+	if decision.RetryAfter != time.Second || decision.Reset != 3*time.Second {
+		t.Errorf("expected retry after 1s and reset after 3s, got %v and %v", decision.RetryAfter, decision.Reset)
+	}
+
+	if decision := limiter.Allow("client", limit, now.Add(time.Second)); !decision.Allowed || decision.Remaining != 0 {
+		t.Errorf("expected a refilled token after 1s, got %+v", decision)
+	}
+}
+
+func TestRateLimiter_KeepsBucketsPerKey(t *testing.T) {
+	limiter := NewRateLimiter()
+	limit := RateLimit{Requests: 1, Per: time.Minute}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	limiter.Allow("acme", limit, now)
+	if limiter.Allow("acme", limit, now).Allowed {
+		t.Errorf("expected acme to be limited")
+	}
+	if !limiter.Allow("globex", limit, now).Allowed {
+		t.Errorf("expected globex to have its own bucket")
+	}
+}
+
+func TestRateLimiter_SweepsFullBuckets(t *testing.T) {
+	limiter := NewRateLimiter()
+	limit := RateLimit{Requests: 10, Per: time.Second}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	limiter.Allow("old", limit, now)
+	limiter.Allow("new", limit, now.Add(2*time.Minute))
+	if _, ok := limiter.buckets["old"]; ok {
+		t.Errorf("expected the refilled bucket to be dropped")
+	}
+	if _, ok := limiter.buckets["new"]; !ok {
+		t.Errorf("expected the used bucket to be kept")
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    RateLimit
+		wantErr bool
+	}{
+		{spec: "60/1m", want: RateLimit{Requests: 60, Per: time.Minute}},
+		{spec: "10/s", want: RateLimit{Requests: 10, Per: time.Second}},
+		{spec: "off", want: RateLimit{}},
+		{spec: "", want: RateLimit{}},
+		{spec: "60", wantErr: true},
+		{spec: "0/1m", wantErr: true},
+		{spec: "5/soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRateLimit(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: expected error %v, got %v", tt.spec, tt.wantErr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: expected %+v, got %+v", tt.spec, tt.want, got)
+		}
+	}
+}
+
+func TestParseRouteRateLimits(t *testing.T) {
+	limits, err := ParseRouteRateLimits("POST /orders=60/1m, GET /reports/valuation=10/1m")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if limits["POST /orders"] != (RateLimit{Requests: 60, Per: time.Minute}) || len(limits) != 2 {
+		t.Errorf("unexpected limits %+v", limits)
+	}
+	if _, err := ParseRouteRateLimits("/orders=60/1m"); err == nil {
+		t.Errorf("expected an error for a route without a method")
+	}
+}
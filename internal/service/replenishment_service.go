@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/pkg/helpers"
+)
+
+type ReplenishmentService struct {
+	transactor              domain.Transactor
+	productRepository       domain.ProductRepository
+	orderRepository         domain.OrderRepository
+	supplierRepository      domain.SupplierRepository
+	purchaseOrderRepository domain.PurchaseOrderRepository
+}
+
+// ReplenishmentServiceDeps are the repositories a ReplenishmentService works
+// with.
+type ReplenishmentServiceDeps struct {
+	Transactor     domain.Transactor
+	Products       domain.ProductRepository
+	Orders         domain.OrderRepository
+	Suppliers      domain.SupplierRepository
+	PurchaseOrders domain.PurchaseOrderRepository
+}
+
+func NewReplenishmentService(deps ReplenishmentServiceDeps) *ReplenishmentService {
+	return &ReplenishmentService{
+		transactor:              deps.Transactor,
+		productRepository:       deps.Products,
+		orderRepository:         deps.Orders,
+		supplierRepository:      deps.Suppliers,
+		purchaseOrderRepository: deps.PurchaseOrders,
+	}
+}
+
+// Suggest proposes purchase quantities for every product whose stock position
+// (on hand plus still expected from open purchase orders) does not cover its
+// reorder point plus the sales expected during the supplier's lead time.
+// The suggested quantity brings the position up to the reorder point plus the
+// sales expected over lead time and coverage days, and is never below the
+// product's reorder quantity.
+func (s *ReplenishmentService) Suggest(params domain.ReplenishmentParams, ctx context.Context) ([]domain.ReplenishmentSuggestion, error) {
+	if params.WindowDays < 1 {
+		return nil, errors.New("window days must be greater than 0")
+	}
+	if params.CoverageDays < 0 {
+		return nil, errors.New("coverage days cannot be negative")
+	}
+	products, err := s.productRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	since := time.Now().UTC().AddDate(0, 0, -params.WindowDays)
+	sales, err := s.orderRepository.SalesByProduct(since, ctx)
+	if err != nil {
+		return nil, err
+	}
+	inbound, err := s.purchaseOrderRepository.OpenQuantities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.supplierRepository.FindAllProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	suppliers := preferredSuppliers(links)
+
+	var suggestions []domain.ReplenishmentSuggestion
+	for _, product := range products {
+		if product.InventoryPolicy == domain.PolicyUntracked {
+			continue
+		}
+		supplier := suppliers[product.ID]
+		dailySales := float64(sales[product.ID]) / float64(params.WindowDays)
+		position := product.Stock + inbound[product.ID]
+
+		trigger := product.ReorderPoint + int(math.Ceil(dailySales*float64(supplier.LeadTimeDays)))
+		if position > trigger {
+			continue
+		}
+		target := product.ReorderPoint + int(math.Ceil(dailySales*float64(supplier.LeadTimeDays+params.CoverageDays)))
+		quantity := max(target-position, product.ReorderQuantity)
+		if quantity <= 0 {
+			continue
+		}
+
+		suggestions = append(suggestions, domain.ReplenishmentSuggestion{
+			ProductID:         product.ID,
+			ProductName:       product.Name,
+			SupplierID:        supplier.SupplierID,
+			Stock:             product.Stock,
+			Inbound:           inbound[product.ID],
+			ReorderPoint:      product.ReorderPoint,
+			DailySales:        dailySales,
+			LeadTimeDays:      supplier.LeadTimeDays,
+			UnitCost:          supplier.UnitCost,
+			SuggestedQuantity: quantity,
+		})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].SupplierID != suggestions[j].SupplierID {
+			return suggestions[i].SupplierID < suggestions[j].SupplierID
+		}
+		return suggestions[i].ProductName < suggestions[j].ProductName
+	})
+	return suggestions, nil
+}
+
+// CreateDraftPurchaseOrders turns the current suggestions into one draft
+// purchase order per supplier. Products without a supplier are skipped. The
+// drafts are saved together or not at all, so a retry after a failure does
+// not order twice from the suppliers that were saved.
+func (s *ReplenishmentService) CreateDraftPurchaseOrders(params domain.ReplenishmentParams, ctx context.Context) ([]domain.PurchaseOrder, error) {
+	suggestions, err := s.Suggest(params, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var purchaseOrders []domain.PurchaseOrder
+	bySupplier := make(map[string]int)
+	now := time.Now().UTC()
+	for _, suggestion := range suggestions {
+		if suggestion.SupplierID == "" {
+			continue
+		}
+		idx, ok := bySupplier[suggestion.SupplierID]
+		if !ok {
+			purchaseOrders = append(purchaseOrders, domain.PurchaseOrder{
+				ID:         helpers.GenerateUUID(),
+				SupplierID: suggestion.SupplierID,
+				Status:     domain.PurchaseOrderDraft,
+				CreatedAt:  now,
+			})
+			idx = len(purchaseOrders) - 1
+			bySupplier[suggestion.SupplierID] = idx
+		}
+		purchaseOrder := &purchaseOrders[idx]
+		purchaseOrder.Lines = append(purchaseOrder.Lines, domain.PurchaseOrderLine{
+			ID:              helpers.GenerateUUID(),
+			PurchaseOrderID: purchaseOrder.ID,
+			ProductID:       suggestion.ProductID,
+			Quantity:        suggestion.SuggestedQuantity,
+			UnitCost:        suggestion.UnitCost,
+		})
+	}
+
+	err = s.transactor.WithinTx(func(ctx context.Context) error {
+		for i := range purchaseOrders {
+			if err := s.purchaseOrderRepository.Save(&purchaseOrders[i], ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return purchaseOrders, nil
+}
+
+// preferredSuppliers picks the cheapest supplier per product, preferring the
+// shorter lead time when costs are equal.
+func preferredSuppliers(links []domain.SupplierProduct) map[string]domain.SupplierProduct {
+	preferred := make(map[string]domain.SupplierProduct)
+	for _, link := range links {
+		current, ok := preferred[link.ProductID]
+		if !ok || link.UnitCost < current.UnitCost ||
+			(link.UnitCost == current.UnitCost && link.LeadTimeDays < current.LeadTimeDays) {
+			preferred[link.ProductID] = link
+		}
+	}
+	return preferred
+}
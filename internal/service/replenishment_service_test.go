@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+func newReplenishmentFixture() (*ReplenishmentService, *mockPurchaseOrderRepo, *mockTransactor) {
+	mockPRepo := &mockProductRepo{fakeProducts: []domain.Product{
+		{ID: "prod-1", Name: "Laptop", Stock: 5, ReorderPoint: 10},
+		{ID: "prod-2", Name: "Mouse", Stock: 100, ReorderPoint: 10},
+		{ID: "prod-3", Name: "Cable", Stock: 0, ReorderPoint: 2, ReorderQuantity: 50},
+	}}
+	mockORRepo := &mockOrderRepo{fakeSales: map[string]int{"prod-1": 30, "prod-2": 30}}
+	mockSRepo := &mockSupplierRepo{fakeLinks: []domain.SupplierProduct{
+		{SupplierID: "sup-expensive", ProductID: "prod-1", UnitCost: 9, LeadTimeDays: 2},
+		{SupplierID: "sup-cheap", ProductID: "prod-1", UnitCost: 8, LeadTimeDays: 5},
+		{SupplierID: "sup-cheap", ProductID: "prod-2", UnitCost: 1, LeadTimeDays: 5},
+	}}
+	mockPORepo := &mockPurchaseOrderRepo{fakeOpen: map[string]int{"prod-1": 2}}
+	mockTx := &mockTransactor{}
+	svc := NewReplenishmentService(ReplenishmentServiceDeps{
+		Transactor:     mockTx,
+		Products:       mockPRepo,
+		Orders:         mockORRepo,
+		Suppliers:      mockSRepo,
+		PurchaseOrders: mockPORepo,
+	})
+	return svc, mockPORepo, mockTx
+}
+
+// TESTS
+func TestSuggest(t *testing.T) {
+	svc, _, _ := newReplenishmentFixture()
+	suggestions, err := svc.Suggest(domain.ReplenishmentParams{WindowDays: 30, CoverageDays: 10}, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(suggestions) != 2 {
+		t.Fatalf("expected 2 suggestions, got %v", len(suggestions))
+	}
+
+	// prod-3 has no supplier so it sorts first.
+	cable := suggestions[0]
+	if cable.ProductID != "prod-3" || cable.SupplierID != "" {
+		t.Errorf("expected unsupplied prod-3 first, got %+v", cable)
+	}
+	if cable.SuggestedQuantity != 50 {
+		t.Errorf("expected reorder quantity 50 to apply, got %v", cable.SuggestedQuantity)
+	}
+
+	// 1 unit/day, cheapest supplier has 5 days lead time: position 7 <= 10+5,
+	// target 10 + 1*(5+10) = 25.
+	laptop := suggestions[1]
+	if laptop.SupplierID != "sup-cheap" {
+		t.Errorf("expected cheapest supplier, got %v", laptop.SupplierID)
+	}
+	if laptop.Inbound != 2 {
+		t.Errorf("expected inbound 2, got %v", laptop.Inbound)
+	}
+	if laptop.SuggestedQuantity != 18 {
+		t.Errorf("expected suggested quantity 18, got %v", laptop.SuggestedQuantity)
+	}
+}
+
+func TestCreateDraftPurchaseOrders(t *testing.T) {
+	svc, mockPORepo, mockTx := newReplenishmentFixture()
+	purchaseOrders, err := svc.CreateDraftPurchaseOrders(domain.ReplenishmentParams{WindowDays: 30, CoverageDays: 10}, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(purchaseOrders) != 1 {
+		t.Fatalf("expected 1 purchase order, got %v", len(purchaseOrders))
+	}
+	purchaseOrder := purchaseOrders[0]
+	if purchaseOrder.SupplierID != "sup-cheap" || purchaseOrder.Status != domain.PurchaseOrderDraft {
+		t.Errorf("expected draft for sup-cheap, got %v %v", purchaseOrder.SupplierID, purchaseOrder.Status)
+	}
+	if len(purchaseOrder.Lines) != 1 || purchaseOrder.Lines[0].Quantity != 18 || purchaseOrder.Lines[0].UnitCost != 8 {
+		t.Errorf("unexpected lines %+v", purchaseOrder.Lines)
+	}
+	if len(mockPORepo.savedOrders) != 1 {
+		t.Errorf("expected 1 saved purchase order, got %v", len(mockPORepo.savedOrders))
+	}
+	if mockTx.txs != 1 {
+		t.Errorf("expected the purchase orders to be saved in one transaction, got %v", mockTx.txs)
+	}
+}
+
+func TestSuggest_InvalidWindow(t *testing.T) {
+	svc, _, _ := newReplenishmentFixture()
+	if _, err := svc.Suggest(domain.ReplenishmentParams{WindowDays: 0}, context.Background()); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+type ReportService struct {
+	productRepository      domain.ProductRepository
+	orderRepository        domain.OrderRepository
+	stockHistoryRepository domain.StockHistoryRepository
+}
+
+func NewReportService(productRepository domain.ProductRepository, orderRepository domain.OrderRepository, stockHistoryRepository domain.StockHistoryRepository) *ReportService {
+	return &ReportService{
+		productRepository:      productRepository,
+		orderRepository:        orderRepository,
+		stockHistoryRepository: stockHistoryRepository,
+	}
+}
+
+// NegativeStock lists the products whose stock is below zero, most oversold
+// first, with the total number of units owed.
+func (s *ReportService) NegativeStock(ctx context.Context) (*domain.NegativeStockReport, error) {
+	products, err := s.productRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &domain.NegativeStockReport{
+		AsOf:     time.Now().UTC(),
+		Products: []domain.NegativeStockItem{},
+	}
+	for _, product := range products {
+		if product.Stock >= 0 || product.InventoryPolicy == domain.PolicyUntracked {
+			continue
+		}
+		report.Products = append(report.Products, domain.NegativeStockItem{
+			ProductID:       product.ID,
+			ProductName:     product.Name,
+			Stock:           product.Stock,
+			InventoryPolicy: product.InventoryPolicy,
+		})
+		report.Shortfall -= product.Stock
+	}
+	sort.SliceStable(report.Products, func(i, j int) bool {
+		return report.Products[i].Stock < report.Products[j].Stock
+	})
+	return report, nil
+}
+
+// Classify ranks products A/B/C by their share of order revenue and X/Y/Z by
+// how much their demand varies between periods.
+func (s *ReportService) Classify(params domain.ClassificationParams, ctx context.Context) (*domain.ClassificationReport, error) {
+	if err := validateClassificationParams(params); err != nil {
+		return nil, err
+	}
+	products, err := s.productRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	from := params.From.UTC().Truncate(24 * time.Hour)
+	sales, err := s.orderRepository.DailySalesAll(from, params.To, ctx)
+	if err != nil {
+		return nil, err
+	}
+	days := int(math.Ceil(params.To.Sub(from).Hours() / 24))
+
+	salesByProduct := make(map[string][]domain.DailySales)
+	for _, day := range sales {
+		salesByProduct[day.ProductID] = append(salesByProduct[day.ProductID], day)
+	}
+
+	report := &domain.ClassificationReport{
+		From:     params.From,
+		To:       params.To,
+		Period:   params.Period,
+		Products: make([]domain.ProductClassification, 0, len(products)),
+	}
+	for _, product := range products {
+		classification := domain.ProductClassification{ProductID: product.ID, ProductName: product.Name}
+		for _, day := range salesByProduct[product.ID] {
+			classification.Revenue += day.Revenue
+		}
+		demand := periodTotals(dailySeries(salesByProduct[product.ID], from, days), params.Period)
+		classification.DemandMean, classification.DemandStdDev = meanAndStdDev(demand)
+		if classification.DemandMean > 0 {
+			classification.CoefficientOfVariation = classification.DemandStdDev / classification.DemandMean
+		}
+		classification.XYZ = xyzClass(classification, params)
+
+		report.TotalRevenue += classification.Revenue
+		report.Products = append(report.Products, classification)
+	}
+
+	sort.SliceStable(report.Products, func(i, j int) bool {
+		return report.Products[i].Revenue > report.Products[j].Revenue
+	})
+	var cumulative float64
+	for i := range report.Products {
+		classification := &report.Products[i]
+		classification.ABC = "C"
+		if report.TotalRevenue > 0 && classification.Revenue > 0 {
+			classification.RevenueShare = classification.Revenue / report.TotalRevenue
+			// A product belongs to the class its revenue starts in, so the
+			// product that crosses a threshold still counts towards it.
+			switch {
+			case cumulative < params.AThreshold:
+				classification.ABC = "A"
+			case cumulative < params.BThreshold:
+				classification.ABC = "B"
+			}
+			cumulative += classification.RevenueShare
+		}
+		classification.CumulativeShare = cumulative
+		classification.Class = classification.ABC + classification.XYZ
+	}
+	return report, nil
+}
+
+// InventoryKPIs computes stock turnover, days of supply and sell-through per
+// product and overall for the range, and lists products with stock at the end
+// of the range that have not sold for DeadStockDays. Like sales and receipts,
+// the range includes From and excludes To: the opening stock is the stock
+// just before From and the closing stock the stock just before To.
+func (s *ReportService) InventoryKPIs(params domain.KPIParams, ctx context.Context) (*domain.InventoryKPIReport, error) {
+	if !params.From.Before(params.To) {
+		return nil, errors.New("from must be before to")
+	}
+	if params.DeadStockDays < 1 {
+		return nil, errors.New("dead stock days must be greater than 0")
+	}
+	products, err := s.productRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sales, err := s.orderRepository.DailySalesAll(params.From, params.To, ctx)
+	if err != nil {
+		return nil, err
+	}
+	opening, err := s.stockHistoryRepository.StockBeforeAll(params.From, ctx)
+	if err != nil {
+		return nil, err
+	}
+	closing, err := s.stockHistoryRepository.StockBeforeAll(params.To, ctx)
+	if err != nil {
+		return nil, err
+	}
+	received, err := s.stockHistoryRepository.MovementTotals(domain.MovementReceipt, params.From, params.To, ctx)
+	if err != nil {
+		return nil, err
+	}
+	lastSales, err := s.orderRepository.LastSaleDates(params.To, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	kpis := make(map[string]*domain.InventoryKPI, len(products))
+	for _, product := range products {
+		kpis[product.ID] = &domain.InventoryKPI{UnitsReceived: received[product.ID]}
+	}
+	for _, day := range sales {
+		if kpi, ok := kpis[day.ProductID]; ok {
+			kpi.UnitsSold += day.Quantity
+			kpi.Revenue += day.Revenue
+		}
+	}
+	for _, level := range opening {
+		if kpi, ok := kpis[level.ProductID]; ok {
+			kpi.OpeningStock = level.Stock
+		}
+	}
+	for _, level := range closing {
+		if kpi, ok := kpis[level.ProductID]; ok {
+			kpi.ClosingStock = level.Stock
+		}
+	}
+
+	days := params.To.Sub(params.From).Hours() / 24
+	deadSince := params.To.AddDate(0, 0, -params.DeadStockDays)
+	report := &domain.InventoryKPIReport{
+		From:          params.From,
+		To:            params.To,
+		DeadStockDays: params.DeadStockDays,
+		Products:      make([]domain.ProductKPI, 0, len(products)),
+		DeadStock:     []domain.DeadStockItem{},
+	}
+	for _, product := range products {
+		kpi := kpis[product.ID]
+		completeKPI(kpi, days)
+		report.Products = append(report.Products, domain.ProductKPI{ProductID: product.ID, ProductName: product.Name, InventoryKPI: *kpi})
+
+		report.Overall.UnitsSold += kpi.UnitsSold
+		report.Overall.Revenue += kpi.Revenue
+		report.Overall.OpeningStock += kpi.OpeningStock
+		report.Overall.ClosingStock += kpi.ClosingStock
+		report.Overall.UnitsReceived += kpi.UnitsReceived
+
+		lastSale, sold := lastSales[product.ID]
+		if kpi.ClosingStock > 0 && (!sold || lastSale.Before(deadSince)) {
+			item := domain.DeadStockItem{ProductID: product.ID, ProductName: product.Name, Stock: kpi.ClosingStock}
+			if sold {
+				item.LastSoldAt = &lastSale
+			}
+			report.DeadStock = append(report.DeadStock, item)
+		}
+	}
+	completeKPI(&report.Overall, days)
+	return report, nil
+}
+
+// completeKPI derives the ratios from the counted quantities over a range of days.
+func completeKPI(kpi *domain.InventoryKPI, days float64) {
+	kpi.AverageStock = float64(kpi.OpeningStock+kpi.ClosingStock) / 2
+	if kpi.AverageStock > 0 {
+		kpi.Turnover = float64(kpi.UnitsSold) / kpi.AverageStock
+	}
+	if kpi.UnitsSold > 0 && days > 0 {
+		daysOfSupply := float64(kpi.ClosingStock) / (float64(kpi.UnitsSold) / days)
+		kpi.DaysOfSupply = &daysOfSupply
+	}
+	if available := kpi.OpeningStock + kpi.UnitsReceived; available > 0 {
+		kpi.SellThroughRate = float64(kpi.UnitsSold) / float64(available)
+	}
+}
+
+func validateClassificationParams(params domain.ClassificationParams) error {
+	if !params.From.Before(params.To) {
+		return errors.New("from must be before to")
+	}
+	if params.AThreshold <= 0 || params.AThreshold >= params.BThreshold || params.BThreshold > 1 {
+		return errors.New("thresholds must satisfy 0 < a < b <= 1")
+	}
+	if params.XThreshold < 0 || params.XThreshold >= params.YThreshold {
+		return errors.New("thresholds must satisfy 0 <= x < y")
+	}
+	if params.Period != domain.PeriodDay && params.Period != domain.PeriodWeek {
+		return errors.New("period must be day or week")
+	}
+	return nil
+}
+
+// periodTotals sums a daily series into weeks when asked to. A trailing
+// partial week is dropped so it does not look like a drop in demand.
+func periodTotals(daily []float64, period domain.DemandPeriod) []float64 {
+	if period == domain.PeriodDay || len(daily) < 7 {
+		return daily
+	}
+	weeks := make([]float64, len(daily)/7)
+	for i := range weeks {
+		for _, v := range daily[i*7 : i*7+7] {
+			weeks[i] += v
+		}
+	}
+	return weeks
+}
+
+func meanAndStdDev(series []float64) (float64, float64) {
+	if len(series) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range series {
+		sum += v
+	}
+	mean := sum / float64(len(series))
+	var squares float64
+	for _, v := range series {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(series)))
+}
+
+func xyzClass(classification domain.ProductClassification, params domain.ClassificationParams) string {
+	switch {
+	case classification.DemandMean == 0:
+		return "Z"
+	case classification.CoefficientOfVariation <= params.XThreshold:
+		return "X"
+	case classification.CoefficientOfVariation <= params.YThreshold:
+		return "Y"
+	default:
+		return "Z"
+	}
+}
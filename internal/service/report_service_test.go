@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+func classificationParams(from time.Time) domain.ClassificationParams {
+	return domain.ClassificationParams{
+		From:       from,
+		To:         from.AddDate(0, 0, 28),
+		AThreshold: 0.8,
+		BThreshold: 0.95,
+		XThreshold: 0.5,
+		YThreshold: 1.0,
+		Period:     domain.PeriodWeek,
+	}
+}
+
+// TESTS
+func TestClassify(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var daily []domain.DailySales
+	// Steady seller: 10 units a week, 85% of revenue.
+	for week := 0; week < 4; week++ {
+		daily = append(daily, domain.DailySales{ProductID: "steady", Day: from.AddDate(0, 0, week*7), Quantity: 10, Revenue: 850})
+	}
+	// Lumpy seller: everything in a single week, 10% of revenue.
+	daily = append(daily, domain.DailySales{ProductID: "lumpy", Day: from.AddDate(0, 0, 8), Quantity: 40, Revenue: 400})
+	// Small seller: 5% of revenue.
+	daily = append(daily, domain.DailySales{ProductID: "small", Day: from.AddDate(0, 0, 1), Quantity: 1, Revenue: 200})
+
+	mockPRepo := &mockProductRepo{fakeProducts: []domain.Product{
+		{ID: "small", Name: "Small"}, {ID: "idle", Name: "Idle"}, {ID: "steady", Name: "Steady"}, {ID: "lumpy", Name: "Lumpy"},
+	}}
+	svc := NewReportService(mockPRepo, &mockOrderRepo{fakeDaily: daily}, &mockStockHistoryRepo{})
+
+	report, err := svc.Classify(classificationParams(from), context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if report.TotalRevenue != 4000 {
+		t.Errorf("expected total revenue 4000, got %v", report.TotalRevenue)
+	}
+	classes := make(map[string]string)
+	for _, p := range report.Products {
+		classes[p.ProductID] = p.Class
+	}
+	expected := map[string]string{"steady": "AX", "lumpy": "BZ", "small": "CZ", "idle": "CZ"}
+	for id, class := range expected {
+		if classes[id] != class {
+			t.Errorf("expected %v to be %v, got %v", id, class, classes[id])
+		}
+	}
+	if report.Products[0].ProductID != "steady" {
+		t.Errorf("expected products sorted by revenue, got %v first", report.Products[0].ProductID)
+	}
+}
+
+func TestClassify_InvalidThresholds(t *testing.T) {
+	svc := NewReportService(&mockProductRepo{}, &mockOrderRepo{}, &mockStockHistoryRepo{})
+	params := classificationParams(time.Now())
+	params.AThreshold = 0.96
+	if _, err := svc.Classify(params, context.Background()); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func TestPeriodTotals_DropsPartialWeek(t *testing.T) {
+	daily := make([]float64, 10)
+	for i := range daily {
+		daily[i] = 1
+	}
+	weeks := periodTotals(daily, domain.PeriodWeek)
+	if len(weeks) != 1 || weeks[0] != 7 {
+		t.Errorf("expected a single week of 7, got %v", weeks)
+	}
+}
+
+func TestInventoryKPIs(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 10)
+	mockPRepo := &mockProductRepo{fakeProducts: []domain.Product{
+		{ID: "fast", Name: "Fast"}, {ID: "dead", Name: "Dead"}, {ID: "empty", Name: "Empty"},
+	}}
+	mockORRepo := &mockOrderRepo{
+		fakeDaily: []domain.DailySales{
+			{ProductID: "fast", Day: from, Quantity: 10, Revenue: 100},
+			{ProductID: "fast", Day: from.AddDate(0, 0, 5), Quantity: 10, Revenue: 100},
+		},
+		fakeLast: map[string]time.Time{
+			"fast": from.AddDate(0, 0, 5),
+			"dead": from.AddDate(0, 0, -100),
+		},
+	}
+	mockHRepo := &mockStockHistoryRepo{
+		// the stock at from already includes the 10 units sold that day
+		fakeLevelsAt: map[time.Time][]domain.StockLevel{
+			from: {{ProductID: "fast", Stock: 10}, {ProductID: "dead", Stock: 5}},
+		},
+		fakeLevelsBefore: map[time.Time][]domain.StockLevel{
+			from: {{ProductID: "fast", Stock: 20}, {ProductID: "dead", Stock: 5}},
+			to:   {{ProductID: "fast", Stock: 20}, {ProductID: "dead", Stock: 5}},
+		},
+		fakeTotals: map[string]int{"fast": 20},
+	}
+	svc := NewReportService(mockPRepo, mockORRepo, mockHRepo)
+
+	report, err := svc.InventoryKPIs(domain.KPIParams{From: from, To: to, DeadStockDays: 30}, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	fast := report.Products[0]
+	if fast.UnitsSold != 20 || fast.Turnover != 1 {
+		t.Errorf("expected 20 units sold and turnover 1, got %+v", fast.InventoryKPI)
+	}
+	// 20 units over 10 days is 2 a day; 20 closing units last 10 days.
+	if fast.DaysOfSupply == nil || *fast.DaysOfSupply != 10 {
+		t.Errorf("expected 10 days of supply, got %v", fast.DaysOfSupply)
+	}
+	// 20 sold out of 20 opening + 20 received.
+	if fast.SellThroughRate != 0.5 {
+		t.Errorf("expected sell-through 0.5, got %v", fast.SellThroughRate)
+	}
+	if report.Products[1].DaysOfSupply != nil {
+		t.Errorf("expected no days of supply without sales, got %v", *report.Products[1].DaysOfSupply)
+	}
+	if len(report.DeadStock) != 1 || report.DeadStock[0].ProductID != "dead" {
+		t.Errorf("expected only dead to be dead stock, got %+v", report.DeadStock)
+	}
+	if report.Overall.UnitsSold != 20 || report.Overall.ClosingStock != 25 {
+		t.Errorf("unexpected overall KPIs %+v", report.Overall)
+	}
+}
+
+func TestNegativeStock(t *testing.T) {
+	mockPRepo := &mockProductRepo{fakeProducts: []domain.Product{
+		{ID: "prod-1", Stock: 5, InventoryPolicy: domain.PolicyTracked},
+		{ID: "prod-2", Stock: -2, InventoryPolicy: domain.PolicyAllowNegative},
+		{ID: "prod-3", Stock: -7, InventoryPolicy: domain.PolicyAllowNegative},
+		{ID: "prod-4", Stock: -1, InventoryPolicy: domain.PolicyUntracked},
+	}}
+	svc := NewReportService(mockPRepo, &mockOrderRepo{}, &mockStockHistoryRepo{})
+
+	report, err := svc.NegativeStock(context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(report.Products) != 2 || report.Products[0].ProductID != "prod-3" || report.Shortfall != 9 {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
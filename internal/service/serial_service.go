@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/pkg/helpers"
+)
+
+type SerialService struct {
+	transactor          domain.Transactor
+	serialRepository    domain.SerialRepository
+	productRepository   domain.ProductRepository
+	valuationService    *ValuationService
+	stockHistoryService *StockHistoryService
+	eventService        *EventService
+}
+
+// SerialServiceDeps are the repositories and services a SerialService works
+// with.
+type SerialServiceDeps struct {
+	Transactor   domain.Transactor
+	Serials      domain.SerialRepository
+	Products     domain.ProductRepository
+	Valuation    *ValuationService
+	StockHistory *StockHistoryService
+	Events       *EventService
+}
+
+func NewSerialService(deps SerialServiceDeps) *SerialService {
+	return &SerialService{
+		transactor:          deps.Transactor,
+		serialRepository:    deps.Serials,
+		productRepository:   deps.Products,
+		valuationService:    deps.Valuation,
+		stockHistoryService: deps.StockHistory,
+		eventService:        deps.Events,
+	}
+}
+
+// CheckReceivable makes sure a receipt brings one new serial per unit.
+func (s *SerialService) CheckReceivable(serials []string, quantity int, ctx context.Context) error {
+	if err := validateSerials(serials, quantity); err != nil {
+		return err
+	}
+	for _, serialNumber := range serials {
+		if _, err := s.serialRepository.FindByNumber(serialNumber, ctx); err == nil {
+			return errors.New("serial " + serialNumber + " already exists")
+		}
+	}
+	return nil
+}
+
+// Receive stores received serials as in stock.
+func (s *SerialService) Receive(productID string, receiptLineID string, serials []string, receivedAt time.Time, ctx context.Context) error {
+	records := make([]domain.Serial, len(serials))
+	events := make([]domain.SerialEvent, len(serials))
+	for i, serialNumber := range serials {
+		records[i] = domain.Serial{
+			SerialNumber: serialNumber,
+			ProductID:    productID,
+			Status:       domain.SerialInStock,
+			UpdatedAt:    receivedAt,
+		}
+		events[i] = newSerialEvent(serialNumber, productID, domain.SerialEventReceived, receiptLineID, receivedAt)
+	}
+	return s.serialRepository.Save(records, events, ctx)
+}
+
+// CheckSellable makes sure an order names one in-stock serial of the product
+// per unit.
+func (s *SerialService) CheckSellable(productID string, serials []string, quantity int, ctx context.Context) error {
+	if err := validateSerials(serials, quantity); err != nil {
+		return err
+	}
+	for _, serialNumber := range serials {
+		serial, err := s.serialRepository.FindByNumber(serialNumber, ctx)
+		if err != nil {
+			return err
+		}
+		if serial.ProductID != productID {
+			return errors.New("serial " + serialNumber + " belongs to another product")
+		}
+		if serial.Status != domain.SerialInStock {
+			return errors.New("serial " + serialNumber + " is not in stock")
+		}
+	}
+	return nil
+}
+
+// Sell marks the serials as shipped on the order.
+func (s *SerialService) Sell(orderID string, productID string, serials []string, soldAt time.Time, ctx context.Context) error {
+	events := make([]domain.SerialEvent, len(serials))
+	for i, serialNumber := range serials {
+		events[i] = newSerialEvent(serialNumber, productID, domain.SerialEventSold, orderID, soldAt)
+	}
+	return s.serialRepository.Transition(events, domain.SerialInStock, domain.SerialSold, ctx)
+}
+
+// Return takes a sold unit back into stock.
+func (s *SerialService) Return(serialNumber string, ctx context.Context) (*domain.Serial, error) {
+	serial, err := s.serialRepository.FindByNumber(serialNumber, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if serial.Status != domain.SerialSold {
+		return nil, errors.New("only sold serials can be returned")
+	}
+	events, err := s.serialRepository.FindEvents(serialNumber, ctx)
+	if err != nil {
+		return nil, err
+	}
+	var orderID string
+	for _, e := range events {
+		if e.Event == domain.SerialEventSold {
+			orderID = e.ReferenceID
+		}
+	}
+
+	now := time.Now().UTC()
+	event := newSerialEvent(serialNumber, serial.ProductID, domain.SerialEventReturned, orderID, now)
+	var product *domain.Product
+	err = s.transactor.WithinTx(func(ctx context.Context) error {
+		if err := s.serialRepository.Transition([]domain.SerialEvent{event}, domain.SerialSold, domain.SerialInStock, ctx); err != nil {
+			return err
+		}
+		var err error
+		product, err = s.productRepository.IncreaseStock(serial.ProductID, 1, ctx)
+		if err != nil {
+			return err
+		}
+		if err := s.stockHistoryService.Record(serial.ProductID, 1, domain.MovementReturn, orderID, ctx); err != nil {
+			return err
+		}
+		if err := s.valuationService.AddReturnLayer(orderID, serial.ProductID, 1, ctx); err != nil {
+			return err
+		}
+		return s.eventService.StageStock(product, ctx)
+	}, ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.eventService.PublishStock(product, ctx)
+	serial.Status = domain.SerialInStock
+	serial.UpdatedAt = now
+	return serial, nil
+}
+
+// History returns the serial with everything that happened to it, oldest first.
+func (s *SerialService) History(serialNumber string, ctx context.Context) (*domain.SerialHistory, error) {
+	serial, err := s.serialRepository.FindByNumber(serialNumber, ctx)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.serialRepository.FindEvents(serialNumber, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []domain.SerialEvent{}
+	}
+	return &domain.SerialHistory{Serial: *serial, Events: events}, nil
+}
+
+func (s *SerialService) FindByOrder(orderID string, ctx context.Context) ([]string, error) {
+	return s.serialRepository.FindByOrder(orderID, ctx)
+}
+
+func validateSerials(serials []string, quantity int) error {
+	if len(serials) != quantity {
+		return errors.New("one serial is required per unit")
+	}
+	seen := make(map[string]bool, len(serials))
+	for _, serialNumber := range serials {
+		if serialNumber == "" {
+			return errors.New("serial cannot be empty")
+		}
+		if seen[serialNumber] {
+			return errors.New("serial " + serialNumber + " is listed twice")
+		}
+		seen[serialNumber] = true
+	}
+	return nil
+}
+
+func newSerialEvent(serialNumber string, productID string, event domain.SerialEventType, referenceID string, at time.Time) domain.SerialEvent {
+	return domain.SerialEvent{
+		ID:           helpers.GenerateUUID(),
+		SerialNumber: serialNumber,
+		ProductID:    productID,
+		Event:        event,
+		ReferenceID:  referenceID,
+		CreatedAt:    at,
+	}
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+type mockSerialRepo struct {
+	fakeSerials map[string]*domain.Serial
+	events      []domain.SerialEvent
+}
+
+func (m *mockSerialRepo) Save(serials []domain.Serial, events []domain.SerialEvent, ctx context.Context) error {
+	if m.fakeSerials == nil {
+		m.fakeSerials = make(map[string]*domain.Serial)
+	}
+	for i := range serials {
+		m.fakeSerials[serials[i].SerialNumber] = &serials[i]
+	}
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func (m *mockSerialRepo) FindByNumber(serialNumber string, ctx context.Context) (*domain.Serial, error) {
+	serial, ok := m.fakeSerials[serialNumber]
+	if !ok {
+		return nil, errors.New("serial not found")
+	}
+	copied := *serial
+	return &copied, nil
+}
+
+func (m *mockSerialRepo) FindEvents(serialNumber string, ctx context.Context) ([]domain.SerialEvent, error) {
+	var events []domain.SerialEvent
+	for _, e := range m.events {
+		if e.SerialNumber == serialNumber {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (m *mockSerialRepo) FindByOrder(orderID string, ctx context.Context) ([]string, error) {
+	var serials []string
+	for _, e := range m.events {
+		if e.ReferenceID == orderID && e.Event == domain.SerialEventSold {
+			serials = append(serials, e.SerialNumber)
+		}
+	}
+	return serials, nil
+}
+
+func (m *mockSerialRepo) Transition(events []domain.SerialEvent, from domain.SerialStatus, to domain.SerialStatus, ctx context.Context) error {
+	for _, e := range events {
+		serial, ok := m.fakeSerials[e.SerialNumber]
+		if !ok || serial.Status != from {
+			return errors.New("serial " + e.SerialNumber + " is not " + string(from))
+		}
+	}
+	for _, e := range events {
+		m.fakeSerials[e.SerialNumber].Status = to
+	}
+	m.events = append(m.events, events...)
+	return nil
+}
+
+func newTestSerialService(mockSRepo *mockSerialRepo, mockPRepo *mockProductRepo, mockCRepo *mockCostLayerRepo, mockHRepo *mockStockHistoryRepo) *SerialService {
+	return NewSerialService(SerialServiceDeps{
+		Transactor:   &mockTransactor{},
+		Serials:      mockSRepo,
+		Products:     mockPRepo,
+		Valuation:    NewValuationService(mockCRepo, domain.CostingFIFO),
+		StockHistory: NewStockHistoryService(mockHRepo),
+		Events:       NewEventService(10),
+	})
+}
+
+// TESTS
+func TestReceivePurchaseOrder_Serialized(t *testing.T) {
+	product := &domain.Product{ID: "prod-1", Serialized: true}
+	mockSRepo := &mockSerialRepo{}
+	svc := NewPurchaseOrderService(PurchaseOrderServiceDeps{
+		Transactor:     &mockTransactor{},
+		PurchaseOrders: &mockPurchaseOrderRepo{fakePurchaseOrder: sentPurchaseOrder()},
+		Suppliers:      &mockSupplierRepo{},
+		Products:       &mockProductRepo{fakeProduct: product},
+		Valuation:      NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+		StockHistory:   NewStockHistoryService(&mockStockHistoryRepo{}),
+		Lots:           NewLotService(LotServiceDeps{Lots: &mockLotRepo{}}),
+		Serials:        newTestSerialService(mockSRepo, &mockProductRepo{}, &mockCostLayerRepo{}, &mockStockHistoryRepo{}),
+		ATP:            NewATPService(&mockProductRepo{}, &mockExpectedReceiptRepo{}),
+		Events:         NewEventService(10),
+	})
+
+	short := &domain.Receipt{Lines: []domain.ReceiptLine{{PurchaseOrderLineID: "line-1", Quantity: 2, Serials: []string{"SN-1"}}}}
+	if _, err := svc.ReceivePurchaseOrder("po-1", short, context.Background()); err == nil {
+		t.Fatalf("expected error for missing serials, got nil")
+	}
+
+	receipt := &domain.Receipt{Lines: []domain.ReceiptLine{{PurchaseOrderLineID: "line-1", Quantity: 2, Serials: []string{"SN-1", "SN-2"}}}}
+	if _, err := svc.ReceivePurchaseOrder("po-1", receipt, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(mockSRepo.fakeSerials) != 2 || mockSRepo.fakeSerials["SN-2"].Status != domain.SerialInStock {
+		t.Errorf("expected 2 serials in stock, got %+v", mockSRepo.fakeSerials)
+	}
+
+	again := &domain.Receipt{Lines: []domain.ReceiptLine{{PurchaseOrderLineID: "line-1", Quantity: 1, Serials: []string{"SN-1"}}}}
+	if _, err := svc.ReceivePurchaseOrder("po-1", again, context.Background()); err == nil {
+		t.Errorf("expected error for duplicate serial, got nil")
+	}
+}
+
+func TestCreateOrder_SerializedAssignsSerials(t *testing.T) {
+	product := &domain.Product{ID: "prod-1", Price: 10, Stock: 2, Serialized: true}
+	mockSRepo := &mockSerialRepo{fakeSerials: map[string]*domain.Serial{
+		"SN-1": {SerialNumber: "SN-1", ProductID: "prod-1", Status: domain.SerialInStock},
+		"SN-2": {SerialNumber: "SN-2", ProductID: "prod-1", Status: domain.SerialSold},
+	}}
+	svc := NewOrderService(OrderServiceDeps{
+		Transactor:   &mockTransactor{},
+		Orders:       &mockOrderRepo{},
+		Products:     &mockProductRepo{fakeProduct: product},
+		Valuation:    NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+		StockHistory: NewStockHistoryService(&mockStockHistoryRepo{}),
+		Lots:         NewLotService(LotServiceDeps{Lots: &mockLotRepo{}}),
+		Serials:      newTestSerialService(mockSRepo, &mockProductRepo{}, &mockCostLayerRepo{}, &mockStockHistoryRepo{}),
+		Events:       NewEventService(10),
+	})
+
+	if err := svc.CreateOrder(&domain.Order{ProductID: "prod-1", Quantity: 1}, context.Background()); err == nil {
+		t.Errorf("expected error for missing serial, got nil")
+	}
+	if err := svc.CreateOrder(&domain.Order{ProductID: "prod-1", Quantity: 1, Serials: []string{"SN-2"}}, context.Background()); err == nil {
+		t.Errorf("expected error for sold serial, got nil")
+	}
+	order := &domain.Order{ProductID: "prod-1", Quantity: 1, Serials: []string{"SN-1"}}
+	if err := svc.CreateOrder(order, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if mockSRepo.fakeSerials["SN-1"].Status != domain.SerialSold {
+		t.Errorf("expected SN-1 to be sold, got %v", mockSRepo.fakeSerials["SN-1"].Status)
+	}
+	if product.Stock != 1 {
+		t.Errorf("expected stock 1, got %v", product.Stock)
+	}
+}
+
+func TestReturnSerial(t *testing.T) {
+	product := &domain.Product{ID: "prod-1", Stock: 0}
+	mockSRepo := &mockSerialRepo{
+		fakeSerials: map[string]*domain.Serial{
+			"SN-1": {SerialNumber: "SN-1", ProductID: "prod-1", Status: domain.SerialSold},
+		},
+		events: []domain.SerialEvent{
+			{SerialNumber: "SN-1", Event: domain.SerialEventReceived, ReferenceID: "receipt-line-1"},
+			{SerialNumber: "SN-1", Event: domain.SerialEventSold, ReferenceID: "order-1"},
+		},
+	}
+	mockCRepo := &mockCostLayerRepo{consumptions: []domain.CostConsumption{
+		{OrderID: "order-1", ProductID: "prod-1", LayerID: "layer-1", Quantity: 1, UnitCost: 7},
+		{OrderID: "order-2", ProductID: "prod-1", LayerID: "layer-1", Quantity: 1, UnitCost: 3},
+	}}
+	mockHRepo := &mockStockHistoryRepo{}
+	svc := newTestSerialService(mockSRepo, &mockProductRepo{fakeProduct: product}, mockCRepo, mockHRepo)
+
+	serial, err := svc.Return("SN-1", context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if serial.Status != domain.SerialInStock || product.Stock != 1 {
+		t.Errorf("expected serial back in stock, got %+v and stock %v", serial, product.Stock)
+	}
+	if len(mockHRepo.movements) != 1 || mockHRepo.movements[0].Reason != domain.MovementReturn {
+		t.Errorf("expected a return movement, got %+v", mockHRepo.movements)
+	}
+	if layers := mockCRepo.savedLayers; len(layers) != 1 || layers[0].Quantity != 1 || layers[0].UnitCost != 7 {
+		t.Errorf("expected the unit back at its consumed cost of 7, got %+v", layers)
+	}
+
+	history, err := svc.History("SN-1", context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(history.Events) != 3 || history.Events[2].Event != domain.SerialEventReturned || history.Events[2].ReferenceID != "order-1" {
+		t.Errorf("unexpected history %+v", history.Events)
+	}
+	if _, err := svc.Return("SN-1", context.Background()); err == nil {
+		t.Errorf("expected error returning an in-stock serial, got nil")
+	}
+}
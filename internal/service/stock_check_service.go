@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+type StockCheckService struct {
+	transactor             domain.Transactor
+	productRepository      domain.ProductRepository
+	orderRepository        domain.OrderRepository
+	stockHistoryRepository domain.StockHistoryRepository
+	stockHistoryService    *StockHistoryService
+	valuationService       *ValuationService
+}
+
+// StockCheckServiceDeps are the repositories and services a StockCheckService
+// works with.
+type StockCheckServiceDeps struct {
+	Transactor          domain.Transactor
+	Products            domain.ProductRepository
+	Orders              domain.OrderRepository
+	StockHistoryRecords domain.StockHistoryRepository
+	StockHistory        *StockHistoryService
+	Valuation           *ValuationService
+}
+
+func NewStockCheckService(deps StockCheckServiceDeps) *StockCheckService {
+	return &StockCheckService{
+		transactor:             deps.Transactor,
+		productRepository:      deps.Products,
+		orderRepository:        deps.Orders,
+		stockHistoryRepository: deps.StockHistoryRecords,
+		stockHistoryService:    deps.StockHistory,
+		valuationService:       deps.Valuation,
+	}
+}
+
+// Check recomputes every product's expected stock as its baseline count plus
+// recorded receipts and adjustments minus the quantities in the orders table,
+// and reports the products whose stock differs. With apply set, each
+// difference is booked as a reconciliation movement so the history explains
+// the stock again, and the cost layers are adjusted by it. Untracked products keep no stock and are skipped. The
+// stock, history and orders are read from one snapshot, so orders placed
+// while checking cannot show up as differences.
+func (s *StockCheckService) Check(apply bool, ctx context.Context) (*domain.StockCheckReport, error) {
+	var report *domain.StockCheckReport
+	err := s.transactor.WithinSnapshot(func(ctx context.Context) error {
+		var err error
+		report, err = s.check(apply, ctx)
+		return err
+	}, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *StockCheckService) check(apply bool, ctx context.Context) (*domain.StockCheckReport, error) {
+	products, err := s.productRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recorded, err := s.stockHistoryRepository.TotalsExcluding(domain.MovementOrder, ctx)
+	if err != nil {
+		return nil, err
+	}
+	ordered, err := s.orderRepository.SalesByProduct(time.Time{}, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.StockCheckReport{
+		CheckedAt:     time.Now().UTC(),
+		Products:      len(products),
+		Applied:       apply,
+		Discrepancies: []domain.StockDiscrepancy{},
+	}
+	for _, product := range products {
+		if product.InventoryPolicy == domain.PolicyUntracked {
+			continue
+		}
+		expected := recorded[product.ID] - ordered[product.ID]
+		if expected == product.Stock {
+			continue
+		}
+		report.Discrepancies = append(report.Discrepancies, domain.StockDiscrepancy{
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			Expected:    expected,
+			Actual:      product.Stock,
+			Difference:  product.Stock - expected,
+		})
+	}
+
+	if apply {
+		for _, d := range report.Discrepancies {
+			if err := s.stockHistoryService.Record(d.ProductID, d.Difference, domain.MovementReconciliation, "", ctx); err != nil {
+				return nil, err
+			}
+			if err := s.valuationService.Adjust(d.ProductID, d.Difference, ctx); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return report, nil
+}
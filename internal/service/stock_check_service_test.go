@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+func newStockCheckFixture() (*StockCheckService, *mockTransactor, *mockStockHistoryRepo) {
+	mockPRepo := &mockProductRepo{fakeProducts: []domain.Product{
+		{ID: "ok", Name: "Consistent", Stock: 7},
+		{ID: "drift", Name: "Edited", Stock: 15},
+	}}
+	mockORRepo := &mockOrderRepo{fakeSales: map[string]int{"ok": 3, "drift": 5}}
+	mockHRepo := &mockStockHistoryRepo{fakeRecorded: map[string]int{"ok": 10, "drift": 10}}
+	mockTx := &mockTransactor{}
+	svc := NewStockCheckService(StockCheckServiceDeps{
+		Transactor:          mockTx,
+		Products:            mockPRepo,
+		Orders:              mockORRepo,
+		StockHistoryRecords: mockHRepo,
+		StockHistory:        NewStockHistoryService(mockHRepo),
+		Valuation:           NewValuationService(&mockCostLayerRepo{}, domain.CostingFIFO),
+	})
+	return svc, mockTx, mockHRepo
+}
+
+// TESTS
+func TestCheck_DryRun(t *testing.T) {
+	svc, mockTx, mockHRepo := newStockCheckFixture()
+	report, err := svc.Check(false, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if report.Products != 2 || len(report.Discrepancies) != 1 {
+		t.Fatalf("expected 1 discrepancy in 2 products, got %+v", report)
+	}
+	d := report.Discrepancies[0]
+	if d.ProductID != "drift" || d.Expected != 5 || d.Actual != 15 || d.Difference != 10 {
+		t.Errorf("unexpected discrepancy %+v", d)
+	}
+	if len(mockHRepo.movements) != 0 {
+		t.Errorf("expected dry run not to write movements, got %v", len(mockHRepo.movements))
+	}
+	if mockTx.snapshots != 1 {
+		t.Errorf("expected the check to read one snapshot, got %v", mockTx.snapshots)
+	}
+}
+
+func TestCheck_Apply(t *testing.T) {
+	svc, _, mockHRepo := newStockCheckFixture()
+	report, err := svc.Check(true, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !report.Applied {
+		t.Errorf("expected report to be applied")
+	}
+	if len(mockHRepo.movements) != 1 {
+		t.Fatalf("expected 1 movement, got %v", len(mockHRepo.movements))
+	}
+	movement := mockHRepo.movements[0]
+	if movement.ProductID != "drift" || movement.Quantity != 10 || movement.Reason != domain.MovementReconciliation {
+		t.Errorf("unexpected movement %+v", movement)
+	}
+}
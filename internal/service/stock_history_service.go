@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/pkg/helpers"
+)
+
+// snapshotLag keeps snapshots a little in the past so movements that are
+// still being written when a snapshot is taken are not left out of it.
+const snapshotLag = time.Minute
+
+type StockHistoryService struct {
+	stockHistoryRepository domain.StockHistoryRepository
+}
+
+func NewStockHistoryService(stockHistoryRepository domain.StockHistoryRepository) *StockHistoryService {
+	return &StockHistoryService{
+		stockHistoryRepository: stockHistoryRepository,
+	}
+}
+
+// Record stores a stock change. Zero quantities are ignored.
+func (s *StockHistoryService) Record(productID string, quantity int, reason domain.StockMovementReason, referenceID string, ctx context.Context) error {
+	if quantity == 0 {
+		return nil
+	}
+	movement := &domain.StockMovement{
+		ID:          helpers.GenerateUUID(),
+		ProductID:   productID,
+		Quantity:    quantity,
+		Reason:      reason,
+		ReferenceID: referenceID,
+		CreatedAt:   time.Now().UTC(),
+	}
+	return s.stockHistoryRepository.SaveMovement(movement, ctx)
+}
+
+func (s *StockHistoryService) StockAt(productID string, asOf time.Time, ctx context.Context) (*domain.StockLevel, error) {
+	stock, err := s.stockHistoryRepository.StockAt(productID, asOf, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.StockLevel{ProductID: productID, Stock: stock, AsOf: asOf}, nil
+}
+
+func (s *StockHistoryService) StockAtAll(asOf time.Time, ctx context.Context) ([]domain.StockLevel, error) {
+	return s.stockHistoryRepository.StockAtAll(asOf, ctx)
+}
+
+// TakeSnapshot stores the current stock of the tenant's products so later
+// as-of queries only have to replay the movements recorded after it.
+func (s *StockHistoryService) TakeSnapshot(ctx context.Context) (time.Time, int, error) {
+	takenAt := time.Now().UTC().Add(-snapshotLag)
+	count, err := s.stockHistoryRepository.SaveSnapshot(takenAt, ctx)
+	return takenAt, count, err
+}
+
+// RunSnapshots takes a snapshot of every tenant's products every interval
+// until ctx is cancelled.
+func (s *StockHistoryService) RunSnapshots(interval time.Duration, ctx context.Context) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			takenAt := time.Now().UTC().Add(-snapshotLag)
+			count, err := s.stockHistoryRepository.SaveSnapshotAll(takenAt, ctx)
+			if err != nil {
+				slog.Error("Error taking stock snapshot", "error", err)
+				continue
+			}
+			slog.Info("Stock snapshot taken", "taken_at", takenAt, "products", count)
+		}
+	}
+}
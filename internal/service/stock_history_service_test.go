@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+type mockStockHistoryRepo struct {
+	movements        []*domain.StockMovement
+	fakeStock        int
+	snapshotTaken    time.Time
+	fakeLevelsAt     map[time.Time][]domain.StockLevel
+	fakeLevelsBefore map[time.Time][]domain.StockLevel
+	fakeTotals       map[string]int
+	fakeRecorded     map[string]int
+}
+
+func (m *mockStockHistoryRepo) SaveMovement(movement *domain.StockMovement, ctx context.Context) error {
+	m.movements = append(m.movements, movement)
+	return nil
+}
+
+func (m *mockStockHistoryRepo) StockAt(productID string, asOf time.Time, ctx context.Context) (int, error) {
+	return m.fakeStock, nil
+}
+
+func (m *mockStockHistoryRepo) StockAtAll(asOf time.Time, ctx context.Context) ([]domain.StockLevel, error) {
+	return m.fakeLevelsAt[asOf], nil
+}
+
+func (m *mockStockHistoryRepo) StockBeforeAll(before time.Time, ctx context.Context) ([]domain.StockLevel, error) {
+	return m.fakeLevelsBefore[before], nil
+}
+
+func (m *mockStockHistoryRepo) SaveSnapshot(takenAt time.Time, ctx context.Context) (int, error) {
+	m.snapshotTaken = takenAt
+	return 1, nil
+}
+
+func (m *mockStockHistoryRepo) SaveSnapshotAll(takenAt time.Time, ctx context.Context) (int, error) {
+	m.snapshotTaken = takenAt
+	return 2, nil
+}
+
+func (m *mockStockHistoryRepo) MovementTotals(reason domain.StockMovementReason, from time.Time, to time.Time, ctx context.Context) (map[string]int, error) {
+	return m.fakeTotals, nil
+}
+
+func (m *mockStockHistoryRepo) TotalsExcluding(reason domain.StockMovementReason, ctx context.Context) (map[string]int, error) {
+	return m.fakeRecorded, nil
+}
+
+// TESTS
+func TestRecord_IgnoresZero(t *testing.T) {
+	mockHRepo := &mockStockHistoryRepo{}
+	svc := NewStockHistoryService(mockHRepo)
+	if err := svc.Record("prod-1", 0, domain.MovementAdjustment, "", context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(mockHRepo.movements) != 0 {
+		t.Errorf("expected no movement, got %v", len(mockHRepo.movements))
+	}
+}
+
+func TestStockAt(t *testing.T) {
+	svc := NewStockHistoryService(&mockStockHistoryRepo{fakeStock: 7})
+	asOf := time.Date(2024, 3, 31, 23, 59, 59, 0, time.UTC)
+	level, err := svc.StockAt("prod-1", asOf, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if level.Stock != 7 || !level.AsOf.Equal(asOf) || level.ProductID != "prod-1" {
+		t.Errorf("unexpected stock level %+v", level)
+	}
+}
+
+func TestTakeSnapshot_LagsBehindNow(t *testing.T) {
+	mockHRepo := &mockStockHistoryRepo{}
+	svc := NewStockHistoryService(mockHRepo)
+	takenAt, _, err := svc.TakeSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if !takenAt.Before(time.Now().Add(-snapshotLag + time.Second)) {
+		t.Errorf("expected snapshot to lag behind now, got %v", takenAt)
+	}
+	if !mockHRepo.snapshotTaken.Equal(takenAt) {
+		t.Errorf("expected repository snapshot at %v, got %v", takenAt, mockHRepo.snapshotTaken)
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/pkg/helpers"
+)
+
+type SupplierService struct {
+	supplierRepository domain.SupplierRepository
+	productRepository  domain.ProductRepository
+}
+
+func NewSupplierService(supplierRepository domain.SupplierRepository, productRepository domain.ProductRepository) *SupplierService {
+	return &SupplierService{
+		supplierRepository: supplierRepository,
+		productRepository:  productRepository,
+	}
+}
+
+func (s *SupplierService) CreateSupplier(supplier *domain.Supplier, ctx context.Context) error {
+	supplier.ID = helpers.GenerateUUID()
+	return s.supplierRepository.Save(supplier, ctx)
+}
+
+func (s *SupplierService) FindAll(ctx context.Context) ([]domain.Supplier, error) {
+	return s.supplierRepository.FindAll(ctx)
+}
+
+func (s *SupplierService) FindByID(id string, ctx context.Context) (*domain.Supplier, error) {
+	return s.supplierRepository.FindByID(id, ctx)
+}
+
+// LinkProduct records that the supplier delivers the product at the given cost and lead time.
+func (s *SupplierService) LinkProduct(link *domain.SupplierProduct, ctx context.Context) error {
+	if link.UnitCost < 0 {
+		return errors.New("unit cost cannot be negative")
+	}
+	if link.LeadTimeDays < 0 {
+		return errors.New("lead time cannot be negative")
+	}
+	if _, err := s.supplierRepository.FindByID(link.SupplierID, ctx); err != nil {
+		return err
+	}
+	if _, err := s.productRepository.FindByID(link.ProductID, ctx); err != nil {
+		return err
+	}
+	return s.supplierRepository.SaveProduct(link, ctx)
+}
+
+func (s *SupplierService) FindProducts(supplierID string, ctx context.Context) ([]domain.SupplierProduct, error) {
+	if _, err := s.supplierRepository.FindByID(supplierID, ctx); err != nil {
+		return nil, err
+	}
+	return s.supplierRepository.FindProducts(supplierID, ctx)
+}
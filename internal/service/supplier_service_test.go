@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+type mockSupplierRepo struct {
+	fakeSupplier *domain.Supplier
+	fakeLinks    []domain.SupplierProduct
+	savedLink    *domain.SupplierProduct
+}
+
+func (m *mockSupplierRepo) Save(supplier *domain.Supplier, ctx context.Context) error {
+	m.fakeSupplier = supplier
+	return nil
+}
+
+func (m *mockSupplierRepo) FindAll(ctx context.Context) ([]domain.Supplier, error) {
+	return nil, nil
+}
+
+func (m *mockSupplierRepo) FindByID(id string, ctx context.Context) (*domain.Supplier, error) {
+	if m.fakeSupplier == nil || m.fakeSupplier.ID != id {
+		return nil, errors.New("supplier not found")
+	}
+	return m.fakeSupplier, nil
+}
+
+func (m *mockSupplierRepo) SaveProduct(link *domain.SupplierProduct, ctx context.Context) error {
+	m.savedLink = link
+	return nil
+}
+
+func (m *mockSupplierRepo) FindProducts(supplierID string, ctx context.Context) ([]domain.SupplierProduct, error) {
+	return m.fakeLinks, nil
+}
+
+func (m *mockSupplierRepo) FindAllProducts(ctx context.Context) ([]domain.SupplierProduct, error) {
+	return m.fakeLinks, nil
+}
+
+// TESTS
+func TestLinkProduct_Success(t *testing.T) {
+	mockSRepo := &mockSupplierRepo{fakeSupplier: &domain.Supplier{ID: "sup-1", Name: "Acme"}}
+	mockPRepo := &mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1"}}
+	svc := NewSupplierService(mockSRepo, mockPRepo)
+
+	link := &domain.SupplierProduct{SupplierID: "sup-1", ProductID: "prod-1", UnitCost: 4.5, LeadTimeDays: 7}
+	if err := svc.LinkProduct(link, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if mockSRepo.savedLink != link {
+		t.Errorf("Supplier repository SaveProduct method was not called")
+	}
+}
+
+func TestLinkProduct_UnknownSupplier(t *testing.T) {
+	mockSRepo := &mockSupplierRepo{}
+	mockPRepo := &mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1"}}
+	svc := NewSupplierService(mockSRepo, mockPRepo)
+
+	err := svc.LinkProduct(&domain.SupplierProduct{SupplierID: "sup-1", ProductID: "prod-1"}, context.Background())
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+	if mockSRepo.savedLink != nil {
+		t.Errorf("expected link not to be saved")
+	}
+}
+
+func TestLinkProduct_NegativeLeadTime(t *testing.T) {
+	mockSRepo := &mockSupplierRepo{fakeSupplier: &domain.Supplier{ID: "sup-1"}}
+	mockPRepo := &mockProductRepo{fakeProduct: &domain.Product{ID: "prod-1"}}
+	svc := NewSupplierService(mockSRepo, mockPRepo)
+
+	err := svc.LinkProduct(&domain.SupplierProduct{SupplierID: "sup-1", ProductID: "prod-1", LeadTimeDays: -1}, context.Background())
+	if err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
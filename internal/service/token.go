@@ -0,0 +1,74 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+const (
+	accessToken  = "access"
+	refreshToken = "refresh"
+)
+
+// tokenHeader is the only JWT header issued and accepted.
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// tokenClaims are the claims of access and refresh tokens. Type keeps a
+// refresh token from being used as an access token and the other way round.
+type tokenClaims struct {
+	Subject   string      `json:"sub"`
+	Tenant    string      `json:"tenant"`
+	Email     string      `json:"email"`
+	Role      domain.Role `json:"role"`
+	Type      string      `json:"typ"`
+	IssuedAt  int64       `json:"iat"`
+	ExpiresAt int64       `json:"exp"`
+}
+
+// signToken returns the claims as a JWT signed with HMAC-SHA256.
+func signToken(claims tokenClaims, secret []byte) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + tokenSignature(unsigned, secret), nil
+}
+
+// parseToken verifies a JWT from signToken and returns its claims. It fails
+// with domain.ErrInvalidToken unless the token is signed with secret, of the
+// given type and not expired at now.
+func parseToken(token string, tokenType string, secret []byte, now time.Time) (*tokenClaims, error) {
+	header, rest, ok := strings.Cut(token, ".")
+	if !ok || header != tokenHeader {
+		return nil, domain.ErrInvalidToken
+	}
+	payload, signature, ok := strings.Cut(rest, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(tokenSignature(header+"."+payload, secret))) {
+		return nil, domain.ErrInvalidToken
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(decoded, &claims); err != nil {
+		return nil, domain.ErrInvalidToken
+	}
+	if claims.Type != tokenType || claims.Subject == "" || now.Unix() >= claims.ExpiresAt {
+		return nil, domain.ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+func tokenSignature(unsigned string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
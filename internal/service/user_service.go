@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/pkg/helpers"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	minPasswordLength = 8
+	// maxPasswordLength is the most bcrypt takes into account.
+	maxPasswordLength = 72
+)
+
+// dummyPasswordHash is compared against when a login's email is unknown, so
+// unknown and known emails take about as long to reject.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+type UserService struct {
+	userRepository domain.UserRepository
+	secret         []byte
+	accessTTL      time.Duration
+	refreshTTL     time.Duration
+}
+
+// NewUserService signs tokens with secret. Access tokens are valid for
+// accessTTL and refresh tokens for refreshTTL.
+func NewUserService(userRepository domain.UserRepository, secret []byte, accessTTL time.Duration, refreshTTL time.Duration) *UserService {
+	return &UserService{
+		userRepository: userRepository,
+		secret:         secret,
+		accessTTL:      accessTTL,
+		refreshTTL:     refreshTTL,
+	}
+}
+
+// CreateUser stores a user with a bcrypt hash of password.
+func (s *UserService) CreateUser(user *domain.User, password string, ctx context.Context) error {
+	user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+	if !strings.Contains(user.Email, "@") {
+		return errors.New("email must be a valid email address")
+	}
+	if strings.TrimSpace(user.Name) == "" {
+		return errors.New("name is required")
+	}
+	if !user.Role.Valid() {
+		return errors.New("role must be viewer, clerk, manager or admin")
+	}
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return errors.New("password must be between 8 and 72 characters")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.ID = helpers.GenerateUUID()
+	user.PasswordHash = string(hash)
+	user.CreatedAt = time.Now().UTC()
+	return s.userRepository.Save(user, ctx)
+}
+
+func (s *UserService) FindAll(ctx context.Context) ([]domain.User, error) {
+	return s.userRepository.FindAll(ctx)
+}
+
+func (s *UserService) FindByID(id string, ctx context.Context) (*domain.User, error) {
+	return s.userRepository.FindByID(id, ctx)
+}
+
+// UpdateRole changes a user's role. Tokens already issued keep the old role
+// until they expire.
+func (s *UserService) UpdateRole(id string, role domain.Role, ctx context.Context) (*domain.User, error) {
+	if !role.Valid() {
+		return nil, errors.New("role must be viewer, clerk, manager or admin")
+	}
+	if err := s.userRepository.UpdateRole(id, role, ctx); err != nil {
+		return nil, err
+	}
+	return s.userRepository.FindByID(id, ctx)
+}
+
+// Login checks the email and password and issues a token pair. It fails with
+// domain.ErrInvalidCredentials without telling which of the two was wrong.
+func (s *UserService) Login(email string, password string, now time.Time, ctx context.Context) (*domain.TokenPair, error) {
+	user, err := s.userRepository.FindByEmail(strings.ToLower(strings.TrimSpace(email)), ctx)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCredentials) {
+			bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		}
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, domain.ErrInvalidCredentials
+	}
+	return s.issueTokens(user, now)
+}
+
+// Refresh exchanges a refresh token for a new pair. The user is read again,
+// so role changes apply from the next refresh on.
+func (s *UserService) Refresh(token string, now time.Time, ctx context.Context) (*domain.TokenPair, error) {
+	claims, err := parseToken(token, refreshToken, s.secret, now)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepository.FindByID(claims.Subject, domain.WithTenant(ctx, claims.Tenant))
+	if err != nil {
+		return nil, err
+	}
+	return s.issueTokens(user, now)
+}
+
+// Authenticate returns the user of a valid access token. It does not read the
+// user from the database; the token's claims, the tenant among them, are
+// trusted until it expires.
+func (s *UserService) Authenticate(token string, now time.Time) (*domain.User, error) {
+	claims, err := parseToken(token, accessToken, s.secret, now)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.User{ID: claims.Subject, TenantID: claims.Tenant, Email: claims.Email, Role: claims.Role}, nil
+}
+
+func (s *UserService) issueTokens(user *domain.User, now time.Time) (*domain.TokenPair, error) {
+	pair := &domain.TokenPair{
+		AccessExpiresAt:  now.Add(s.accessTTL),
+		RefreshExpiresAt: now.Add(s.refreshTTL),
+	}
+	claims := tokenClaims{Subject: user.ID, Tenant: user.TenantID, Email: user.Email, Role: user.Role, Type: accessToken, IssuedAt: now.Unix(), ExpiresAt: pair.AccessExpiresAt.Unix()}
+	var err error
+	if pair.AccessToken, err = signToken(claims, s.secret); err != nil {
+		return nil, err
+	}
+	claims.Type = refreshToken
+	claims.ExpiresAt = pair.RefreshExpiresAt.Unix()
+	if pair.RefreshToken, err = signToken(claims, s.secret); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
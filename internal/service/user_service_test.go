@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+type mockUserRepo struct {
+	fakeUsers []domain.User
+}
+
+func (m *mockUserRepo) Save(user *domain.User, ctx context.Context) error {
+	m.fakeUsers = append(m.fakeUsers, *user)
+	return nil
+}
+
+func (m *mockUserRepo) FindAll(ctx context.Context) ([]domain.User, error) {
+	return m.fakeUsers, nil
+}
+
+func (m *mockUserRepo) FindByID(id string, ctx context.Context) (*domain.User, error) {
+	for i := range m.fakeUsers {
+		if m.fakeUsers[i].ID == id {
+			user := m.fakeUsers[i]
+			return &user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (m *mockUserRepo) FindByEmail(email string, ctx context.Context) (*domain.User, error) {
+	for i := range m.fakeUsers {
+		if m.fakeUsers[i].Email == email {
+			user := m.fakeUsers[i]
+			return &user, nil
+		}
+	}
+	return nil, domain.ErrInvalidCredentials
+}
+
+func (m *mockUserRepo) UpdateRole(id string, role domain.Role, ctx context.Context) error {
+	for i := range m.fakeUsers {
+		if m.fakeUsers[i].ID == id {
+			m.fakeUsers[i].Role = role
+			return nil
+		}
+	}
+	return errors.New("user not found")
+}
+
+func newTestUserService(t *testing.T) (*UserService, *domain.User) {
+	svc := NewUserService(&mockUserRepo{}, []byte("test-secret"), 15*time.Minute, 24*time.Hour)
+	user := &domain.User{Email: " Clerk@Example.com ", Name: "Clerk", Role: domain.RoleClerk}
+	if err := svc.CreateUser(user, "correct horse", context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	return svc, user
+}
+
+// TESTS
+func TestCreateUser(t *testing.T) {
+	svc, user := newTestUserService(t)
+	if user.Email != "clerk@example.com" {
+		t.Errorf("expected a normalized email, got %v", user.Email)
+	}
+	if user.PasswordHash == "" || strings.Contains(user.PasswordHash, "correct horse") {
+		t.Errorf("expected a password hash, got %v", user.PasswordHash)
+	}
+
+	invalid := map[string]*domain.User{
+		"correct horse": {Email: "no-at-sign", Name: "A", Role: domain.RoleViewer},
+		"short":         {Email: "a@example.com", Name: "A", Role: domain.RoleViewer},
+		"also correct":  {Email: "b@example.com", Name: "B", Role: "owner"},
+	}
+	for password, user := range invalid {
+		if err := svc.CreateUser(user, password, context.Background()); err == nil {
+			t.Errorf("expected error for %+v, got nil", user)
+		}
+	}
+}
+
+func TestLogin_IssuesTokensForTheUser(t *testing.T) {
+	svc, user := newTestUserService(t)
+	now := time.Now().UTC()
+
+	if _, err := svc.Login("clerk@example.com", "wrong password", now, context.Background()); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials for a wrong password, got %v", err)
+	}
+	if _, err := svc.Login("nobody@example.com", "correct horse", now, context.Background()); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials for an unknown email, got %v", err)
+	}
+
+	pair, err := svc.Login("CLERK@example.com", "correct horse", now, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	acting, err := svc.Authenticate(pair.AccessToken, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if acting.ID != user.ID || acting.Role != domain.RoleClerk {
+		t.Errorf("expected clerk %v, got %+v", user.ID, acting)
+	}
+	if _, err := svc.Authenticate(pair.AccessToken, pair.AccessExpiresAt); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken for an expired token, got %v", err)
+	}
+	if _, err := svc.Authenticate(pair.RefreshToken, now); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected a refresh token to be rejected as access token, got %v", err)
+	}
+	tampered := strings.Replace(pair.AccessToken, ".", ".x", 1)
+	if _, err := svc.Authenticate(tampered, now); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken for a tampered token, got %v", err)
+	}
+	other := NewUserService(&mockUserRepo{}, []byte("other-secret"), time.Minute, time.Hour)
+	if _, err := other.Authenticate(pair.AccessToken, now); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken for a token signed with another secret, got %v", err)
+	}
+}
+
+func TestRefresh_PicksUpRoleChanges(t *testing.T) {
+	svc, user := newTestUserService(t)
+	now := time.Now().UTC()
+	pair, err := svc.Login("clerk@example.com", "correct horse", now, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if _, err := svc.UpdateRole(user.ID, domain.RoleManager, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if _, err := svc.Refresh(pair.AccessToken, now, context.Background()); !errors.Is(err, domain.ErrInvalidToken) {
+		t.Errorf("expected an access token to be rejected as refresh token, got %v", err)
+	}
+	refreshed, err := svc.Refresh(pair.RefreshToken, now.Add(time.Hour), context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	acting, err := svc.Authenticate(refreshed.AccessToken, now.Add(time.Hour))
+	if err != nil || acting.Role != domain.RoleManager {
+		t.Errorf("expected the manager role after refresh, got %+v (%v)", acting, err)
+	}
+}
+
+func TestRoleAllows(t *testing.T) {
+	cases := []struct {
+		role    domain.Role
+		scope   domain.Scope
+		allowed bool
+	}{
+		{domain.RoleViewer, domain.ScopeProductsRead, true},
+		{domain.RoleViewer, domain.ScopeOrdersWrite, false},
+		{domain.RoleClerk, domain.ScopeOrdersWrite, true},
+		{domain.RoleClerk, domain.ScopeProductsWrite, false},
+		{domain.RoleManager, domain.ScopeProductsWrite, true},
+		{domain.RoleManager, domain.ScopeAdmin, false},
+		{domain.RoleAdmin, domain.ScopeAdmin, true},
+	}
+	for _, c := range cases {
+		if got := c.role.Allows(c.scope); got != c.allowed {
+			t.Errorf("expected %v allows %v to be %v, got %v", c.role, c.scope, c.allowed, got)
+		}
+	}
+}
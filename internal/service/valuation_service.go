@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/pkg/helpers"
+)
+
+type ValuationService struct {
+	costLayerRepository domain.CostLayerRepository
+	method              domain.CostingMethod
+}
+
+func NewValuationService(costLayerRepository domain.CostLayerRepository, method domain.CostingMethod) *ValuationService {
+	return &ValuationService{
+		costLayerRepository: costLayerRepository,
+		method:              method,
+	}
+}
+
+// AddLayer records received units of a product at their unit cost.
+func (s *ValuationService) AddLayer(productID string, receiptLineID string, quantity int, unitCost float64, ctx context.Context) error {
+	layer := &domain.CostLayer{
+		ID:            helpers.GenerateUUID(),
+		ProductID:     productID,
+		ReceiptLineID: receiptLineID,
+		Quantity:      quantity,
+		Remaining:     quantity,
+		UnitCost:      unitCost,
+		CreatedAt:     time.Now().UTC(),
+	}
+	return s.costLayerRepository.SaveLayer(layer, ctx)
+}
+
+// Consume takes the ordered quantity out of the product's cost layers, oldest
+// first. With FIFO the units carry the cost of the layer they came from; with
+// weighted average they all carry the running average cost of the stock on
+// hand, the value received minus the value consumed so far per unit, which is
+// what Valuation reports too. Units beyond what the layers hold (stock that
+// predates cost tracking) are not costed.
+func (s *ValuationService) Consume(orderID string, productID string, quantity int, ctx context.Context) error {
+	layers, err := s.costLayerRepository.FindOpenLayers(productID, ctx)
+	if err != nil {
+		return err
+	}
+	var averageCost float64
+	if s.method == domain.CostingWeightedAverage {
+		totals, err := s.costLayerRepository.ProductTotals(productID, ctx)
+		if err != nil {
+			return err
+		}
+		if units := totals.ReceivedQuantity - totals.ConsumedQuantity; units > 0 {
+			averageCost = (totals.ReceivedValue - totals.ConsumedValue) / float64(units)
+		}
+	}
+	consumptions := planConsumption(layers, quantity, s.method, averageCost)
+	if len(consumptions) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	for i := range consumptions {
+		consumptions[i].ID = helpers.GenerateUUID()
+		consumptions[i].OrderID = orderID
+		consumptions[i].CreatedAt = now
+	}
+	return s.costLayerRepository.Consume(consumptions, ctx)
+}
+
+// AddReturnLayer puts units returned from an order back into the product's
+// layers at the average cost the order consumed them at. Orders that took no
+// cost from the layers return no cost either.
+func (s *ValuationService) AddReturnLayer(orderID string, productID string, quantity int, ctx context.Context) error {
+	consumptions, err := s.costLayerRepository.FindConsumptions(orderID, productID, ctx)
+	if err != nil {
+		return err
+	}
+	var units int
+	var value float64
+	for _, c := range consumptions {
+		units += c.Quantity
+		value += float64(c.Quantity) * c.UnitCost
+	}
+	if units == 0 {
+		return nil
+	}
+	return s.AddLayer(productID, "", quantity, value/float64(units), ctx)
+}
+
+// Adjust keeps the product's cost layers in line with a stock change made
+// outside orders and receipts. Units taken off the stock are consumed from the
+// layers like an order without one; units added carry the average cost of the
+// costed stock on hand, or no cost when there is none.
+func (s *ValuationService) Adjust(productID string, quantity int, ctx context.Context) error {
+	if quantity < 0 {
+		return s.Consume("", productID, -quantity, ctx)
+	}
+	if quantity == 0 {
+		return nil
+	}
+	totals, err := s.costLayerRepository.ProductTotals(productID, ctx)
+	if err != nil {
+		return err
+	}
+	units := totals.ReceivedQuantity - totals.ConsumedQuantity
+	if units <= 0 {
+		return nil
+	}
+	return s.AddLayer(productID, "", quantity, (totals.ReceivedValue-totals.ConsumedValue)/float64(units), ctx)
+}
+
+// Valuation values the stock on hand at asOf as everything received minus
+// everything consumed up to then, both at their recorded unit costs.
+func (s *ValuationService) Valuation(asOf time.Time, ctx context.Context) (*domain.ValuationReport, error) {
+	totals, err := s.costLayerRepository.Totals(asOf, ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &domain.ValuationReport{
+		Method:   s.method,
+		AsOf:     asOf,
+		Products: []domain.ProductValuation{},
+	}
+	for _, t := range totals {
+		valuation := domain.ProductValuation{
+			ProductID:   t.ProductID,
+			ProductName: t.ProductName,
+			Quantity:    t.ReceivedQuantity - t.ConsumedQuantity,
+			TotalValue:  t.ReceivedValue - t.ConsumedValue,
+		}
+		if valuation.Quantity > 0 {
+			valuation.UnitCost = valuation.TotalValue / float64(valuation.Quantity)
+		} else {
+			valuation.TotalValue = 0
+		}
+		report.Products = append(report.Products, valuation)
+		report.TotalValue += valuation.TotalValue
+	}
+	return report, nil
+}
+
+// planConsumption drains the layers in order. With weighted average the units
+// carry averageCost instead of the cost of their layer, as a layer's own cost
+// says nothing about the average once older layers were drained at it.
+func planConsumption(layers []domain.CostLayer, quantity int, method domain.CostingMethod, averageCost float64) []domain.CostConsumption {
+	var consumptions []domain.CostConsumption
+	for _, layer := range layers {
+		if quantity == 0 {
+			break
+		}
+		take := min(layer.Remaining, quantity)
+		unitCost := layer.UnitCost
+		if method == domain.CostingWeightedAverage {
+			unitCost = averageCost
+		}
+		consumptions = append(consumptions, domain.CostConsumption{
+			LayerID:   layer.ID,
+			ProductID: layer.ProductID,
+			Quantity:  take,
+			UnitCost:  unitCost,
+		})
+		quantity -= take
+	}
+	return consumptions
+}
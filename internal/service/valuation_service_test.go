@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+type mockCostLayerRepo struct {
+	fakeLayers   []domain.CostLayer
+	fakeTotals   []domain.CostTotals
+	savedLayers  []*domain.CostLayer
+	consumptions []domain.CostConsumption
+}
+
+func (m *mockCostLayerRepo) SaveLayer(layer *domain.CostLayer, ctx context.Context) error {
+	m.savedLayers = append(m.savedLayers, layer)
+	m.fakeLayers = append(m.fakeLayers, *layer)
+	return nil
+}
+
+func (m *mockCostLayerRepo) FindOpenLayers(productID string, ctx context.Context) ([]domain.CostLayer, error) {
+	var layers []domain.CostLayer
+	for _, layer := range m.fakeLayers {
+		if layer.Remaining > 0 {
+			layers = append(layers, layer)
+		}
+	}
+	return layers, nil
+}
+
+func (m *mockCostLayerRepo) Consume(consumptions []domain.CostConsumption, ctx context.Context) error {
+	for _, c := range consumptions {
+		for i := range m.fakeLayers {
+			if m.fakeLayers[i].ID == c.LayerID {
+				m.fakeLayers[i].Remaining -= c.Quantity
+			}
+		}
+	}
+	m.consumptions = append(m.consumptions, consumptions...)
+	return nil
+}
+
+func (m *mockCostLayerRepo) ProductTotals(productID string, ctx context.Context) (*domain.CostTotals, error) {
+	totals := &domain.CostTotals{ProductID: productID}
+	for _, layer := range m.fakeLayers {
+		if layer.ProductID == productID {
+			totals.ReceivedQuantity += layer.Quantity
+			totals.ReceivedValue += float64(layer.Quantity) * layer.UnitCost
+		}
+	}
+	for _, c := range m.consumptions {
+		if c.ProductID == productID {
+			totals.ConsumedQuantity += c.Quantity
+			totals.ConsumedValue += float64(c.Quantity) * c.UnitCost
+		}
+	}
+	return totals, nil
+}
+
+func (m *mockCostLayerRepo) FindConsumptions(orderID string, productID string, ctx context.Context) ([]domain.CostConsumption, error) {
+	var consumptions []domain.CostConsumption
+	for _, c := range m.consumptions {
+		if c.OrderID == orderID && c.ProductID == productID {
+			consumptions = append(consumptions, c)
+		}
+	}
+	return consumptions, nil
+}
+
+func (m *mockCostLayerRepo) Totals(asOf time.Time, ctx context.Context) ([]domain.CostTotals, error) {
+	return m.fakeTotals, nil
+}
+
+func twoCostLayers() []domain.CostLayer {
+	return []domain.CostLayer{
+		{ID: "layer-1", ProductID: "prod-1", Quantity: 10, Remaining: 4, UnitCost: 2},
+		{ID: "layer-2", ProductID: "prod-1", Quantity: 10, Remaining: 10, UnitCost: 5},
+	}
+}
+
+// TESTS
+func TestConsume_FIFO(t *testing.T) {
+	mockCRepo := &mockCostLayerRepo{fakeLayers: twoCostLayers()}
+	svc := NewValuationService(mockCRepo, domain.CostingFIFO)
+
+	if err := svc.Consume("order-1", "prod-1", 6, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(mockCRepo.consumptions) != 2 {
+		t.Fatalf("expected 2 consumptions, got %v", len(mockCRepo.consumptions))
+	}
+	first, second := mockCRepo.consumptions[0], mockCRepo.consumptions[1]
+	if first.LayerID != "layer-1" || first.Quantity != 4 || first.UnitCost != 2 {
+		t.Errorf("unexpected first consumption %+v", first)
+	}
+	if second.LayerID != "layer-2" || second.Quantity != 2 || second.UnitCost != 5 {
+		t.Errorf("unexpected second consumption %+v", second)
+	}
+	if first.OrderID != "order-1" {
+		t.Errorf("expected order-1, got %v", first.OrderID)
+	}
+}
+
+func TestConsume_WeightedAverage(t *testing.T) {
+	mockCRepo := &mockCostLayerRepo{}
+	svc := NewValuationService(mockCRepo, domain.CostingWeightedAverage)
+	ctx := context.Background()
+
+	// 10@1 and 10@3 average to 2, and selling at the average keeps it at 2
+	// even once the first receipt is drained
+	if err := svc.AddLayer("prod-1", "line-1", 10, 1, ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := svc.AddLayer("prod-1", "line-2", 10, 3, ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := svc.Consume("order-1", "prod-1", 10, ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := svc.Consume("order-2", "prod-1", 4, ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	for _, c := range mockCRepo.consumptions {
+		if math.Abs(c.UnitCost-2) > 1e-9 {
+			t.Errorf("expected unit cost 2, got %+v", c)
+		}
+	}
+	if mockCRepo.consumptions[len(mockCRepo.consumptions)-1].LayerID != mockCRepo.savedLayers[1].ID {
+		t.Errorf("expected the second sale to drain the second receipt, got %+v", mockCRepo.consumptions)
+	}
+
+	// a receipt at 5 moves the average of the 6 units left at 2 to
+	// (6*2 + 6*5) / 12
+	if err := svc.AddLayer("prod-1", "line-3", 6, 5, ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := svc.Consume("order-3", "prod-1", 1, ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if c := mockCRepo.consumptions[len(mockCRepo.consumptions)-1]; math.Abs(c.UnitCost-3.5) > 1e-9 {
+		t.Errorf("expected unit cost 3.5, got %v", c.UnitCost)
+	}
+}
+
+func TestConsume_BeyondLayers(t *testing.T) {
+	mockCRepo := &mockCostLayerRepo{fakeLayers: twoCostLayers()}
+	svc := NewValuationService(mockCRepo, domain.CostingFIFO)
+
+	if err := svc.Consume("order-1", "prod-1", 20, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	var consumed int
+	for _, c := range mockCRepo.consumptions {
+		consumed += c.Quantity
+	}
+	if consumed != 14 {
+		t.Errorf("expected 14 costed units, got %v", consumed)
+	}
+}
+
+func TestAdjust(t *testing.T) {
+	ctx := context.Background()
+	mockCRepo := &mockCostLayerRepo{}
+	svc := NewValuationService(mockCRepo, domain.CostingFIFO)
+	if err := svc.AddLayer("prod-1", "line-1", 10, 2, ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if err := svc.AddLayer("prod-1", "line-2", 10, 4, ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if err := svc.Adjust("prod-1", -12, ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	var consumed int
+	for _, c := range mockCRepo.consumptions {
+		consumed += c.Quantity
+	}
+	if consumed != 12 {
+		t.Errorf("expected 12 units consumed, got %v", consumed)
+	}
+
+	if err := svc.Adjust("prod-1", 3, ctx); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(mockCRepo.savedLayers) != 3 {
+		t.Fatalf("expected a layer for the added units, got %v layers", len(mockCRepo.savedLayers))
+	}
+	if layer := mockCRepo.savedLayers[2]; layer.Quantity != 3 || layer.UnitCost != 4 {
+		t.Errorf("expected 3 units at the average cost 4, got %+v", layer)
+	}
+}
+
+func TestValuation(t *testing.T) {
+	mockCRepo := &mockCostLayerRepo{fakeTotals: []domain.CostTotals{
+		{ProductID: "prod-1", ReceivedQuantity: 20, ReceivedValue: 70, ConsumedQuantity: 6, ConsumedValue: 18},
+		{ProductID: "prod-2", ReceivedQuantity: 5, ReceivedValue: 10, ConsumedQuantity: 5, ConsumedValue: 10},
+	}}
+	svc := NewValuationService(mockCRepo, domain.CostingFIFO)
+
+	report, err := svc.Valuation(time.Now(), context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if report.Products[0].Quantity != 14 || report.Products[0].TotalValue != 52 {
+		t.Errorf("unexpected valuation %+v", report.Products[0])
+	}
+	if report.Products[1].Quantity != 0 || report.Products[1].TotalValue != 0 {
+		t.Errorf("expected empty valuation, got %+v", report.Products[1])
+	}
+	if report.TotalValue != 52 {
+		t.Errorf("expected total value 52, got %v", report.TotalValue)
+	}
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+	"github.com/iamtbay/is-management/pkg/helpers"
+)
+
+const (
+	// webhookMaxAttempts is how often a delivery is tried before it is dead-lettered.
+	webhookMaxAttempts = 8
+	// webhookRetryBase is the wait before the first retry. It doubles with
+	// every failed attempt up to webhookRetryMax.
+	webhookRetryBase = 30 * time.Second
+	webhookRetryMax  = time.Hour
+	// webhookLease keeps a claimed batch from being claimed again while it is
+	// sent. It is added to the time the batch takes when every request runs
+	// into the client timeout.
+	webhookLease     = 2 * time.Minute
+	webhookBatchSize = 50
+)
+
+// Webhook request headers. The signature is the hex HMAC-SHA256 of the
+// timestamp, a dot and the body, keyed with the webhook secret.
+const (
+	WebhookIDHeader        = "X-Webhook-Id"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+type WebhookService struct {
+	webhookRepository domain.WebhookRepository
+	client            *http.Client
+}
+
+func NewWebhookService(webhookRepository domain.WebhookRepository, client *http.Client) *WebhookService {
+	return &WebhookService{
+		webhookRepository: webhookRepository,
+		client:            client,
+	}
+}
+
+// webhookPayload is the body posted to webhooks. ID is the same for every
+// webhook the event goes to, so receivers can drop duplicates.
+type webhookPayload struct {
+	ID        string           `json:"id"`
+	Type      domain.EventType `json:"type"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      interface{}      `json:"data"`
+}
+
+// CreateWebhook stores a webhook. A secret is generated when none is given.
+func (s *WebhookService) CreateWebhook(webhook *domain.Webhook, ctx context.Context) error {
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+	if len(webhook.EventTypes) == 0 {
+		return errors.New("a webhook needs at least one event type")
+	}
+	for _, eventType := range webhook.EventTypes {
+		if !eventType.Valid() {
+			return errors.New("event type must be stock.changed or order.created")
+		}
+	}
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+	webhook.ID = helpers.GenerateUUID()
+	webhook.CreatedAt = time.Now().UTC()
+	return s.webhookRepository.Save(webhook, ctx)
+}
+
+func (s *WebhookService) FindAll(ctx context.Context) ([]domain.Webhook, error) {
+	return s.webhookRepository.FindAll(ctx)
+}
+
+func (s *WebhookService) FindByID(id string, ctx context.Context) (*domain.Webhook, error) {
+	return s.webhookRepository.FindByID(id, ctx)
+}
+
+func (s *WebhookService) Delete(id string, ctx context.Context) error {
+	return s.webhookRepository.Delete(id, ctx)
+}
+
+// Enqueue queues a delivery of the event for every webhook subscribed to its
+// type. It is registered as an event listener, so the deliveries are stored
+// in the transaction of the change that caused the event.
+func (s *WebhookService) Enqueue(event domain.Event, ctx context.Context) error {
+	webhooks, err := s.webhookRepository.FindByEventType(event.Type, ctx)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+	payload, err := json.Marshal(webhookPayload{
+		ID:        helpers.GenerateUUID(),
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Data,
+	})
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	deliveries := make([]domain.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, domain.WebhookDelivery{
+			ID:            helpers.GenerateUUID(),
+			TenantID:      webhook.TenantID,
+			WebhookID:     webhook.ID,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        domain.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+	return s.webhookRepository.SaveDeliveries(deliveries, ctx)
+}
+
+// DeliverDue sends the deliveries that are due and returns how many were
+// claimed. Failed deliveries are retried with exponential backoff and
+// dead-lettered after webhookMaxAttempts attempts. Deliveries of webhooks
+// deleted since they were claimed are gone with their webhook and skipped.
+func (s *WebhookService) DeliverDue(now time.Time, ctx context.Context) (int, error) {
+	deliveries, err := s.webhookRepository.ClaimDue(now, now.Add(s.lease()), webhookBatchSize, ctx)
+	if err != nil {
+		return 0, err
+	}
+	webhooks := make(map[string]*domain.Webhook)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = s.webhookRepository.FindByID(delivery.WebhookID, domain.WithTenant(ctx, delivery.TenantID))
+			if err != nil && !errors.Is(err, domain.ErrWebhookNotFound) {
+				return i, err
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+		if webhook == nil {
+			continue
+		}
+		s.attempt(webhook, delivery, now, ctx)
+		if err := s.webhookRepository.UpdateDelivery(delivery, ctx); err != nil && !errors.Is(err, domain.ErrWebhookDeliveryNotFound) {
+			return i, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// lease is how long a claimed batch stays claimed.
+func (s *WebhookService) lease() time.Duration {
+	return time.Duration(webhookBatchSize)*s.client.Timeout + webhookLease
+}
+
+func (s *WebhookService) attempt(webhook *domain.Webhook, delivery *domain.WebhookDelivery, now time.Time, ctx context.Context) {
+	delivery.Attempts++
+	status, err := s.send(webhook, delivery, now, ctx)
+	delivery.ResponseStatus = status
+	if err == nil {
+		delivery.Status = domain.DeliveryDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= webhookMaxAttempts {
+		delivery.Status = domain.DeliveryDead
+		return
+	}
+	delivery.NextAttemptAt = now.Add(webhookBackoff(delivery.Attempts))
+}
+
+// send posts the delivery and returns the response status. Anything but a
+// 2xx answer is an error.
+func (s *WebhookService) send(webhook *domain.Webhook, delivery *domain.WebhookDelivery, now time.Time, ctx context.Context) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookIDHeader, delivery.ID)
+	req.Header.Set(WebhookEventHeader, string(delivery.EventType))
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+signWebhook(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, errors.New("webhook answered " + resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+func signWebhook(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is the wait after the given number of failed attempts.
+func webhookBackoff(attempts int) time.Duration {
+	wait := webhookRetryBase
+	for i := 1; i < attempts && wait < webhookRetryMax; i++ {
+		wait *= 2
+	}
+	return min(wait, webhookRetryMax)
+}
+
+// RetryDelivery queues a dead-lettered delivery again, with a fresh set of
+// attempts, once the receiver is fixed. Pending deliveries are left to their
+// backoff, as one may be being sent right now.
+func (s *WebhookService) RetryDelivery(id string, now time.Time, ctx context.Context) (*domain.WebhookDelivery, error) {
+	delivery, err := s.webhookRepository.FindDelivery(id, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status != domain.DeliveryDead {
+		return nil, errors.New("only dead webhook deliveries can be retried")
+	}
+	delivery.Status = domain.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	if err := s.webhookRepository.UpdateDelivery(delivery, ctx); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// FindDeliveries returns the delivery log of a webhook, newest first.
+func (s *WebhookService) FindDeliveries(webhookID string, status domain.WebhookDeliveryStatus, limit int, ctx context.Context) ([]domain.WebhookDelivery, error) {
+	if status != "" && !status.Valid() {
+		return nil, errors.New("status must be pending, delivered or dead")
+	}
+	if _, err := s.webhookRepository.FindByID(webhookID, ctx); err != nil {
+		return nil, err
+	}
+	return s.webhookRepository.FindDeliveries(webhookID, status, limit, ctx)
+}
+
+// RunDeliveries sends due deliveries every interval until ctx is cancelled.
+func (s *WebhookService) RunDeliveries(interval time.Duration, ctx context.Context) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// keep going while full batches come back
+			for {
+				count, err := s.DeliverDue(time.Now().UTC(), ctx)
+				if err != nil {
+					if ctx.Err() == nil {
+						slog.Error("Error delivering webhooks", "error", err)
+					}
+					break
+				}
+				if count < webhookBatchSize {
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/iamtbay/is-management/internal/domain"
+)
+
+type mockWebhookRepo struct {
+	fakeWebhooks      []domain.Webhook
+	savedDeliveries   []domain.WebhookDelivery
+	updatedDeliveries []domain.WebhookDelivery
+	// vanishing are claimed but deleted before they can be updated
+	vanishing  []domain.WebhookDelivery
+	leaseUntil time.Time
+}
+
+func (m *mockWebhookRepo) Save(webhook *domain.Webhook, ctx context.Context) error {
+	m.fakeWebhooks = append(m.fakeWebhooks, *webhook)
+	return nil
+}
+
+func (m *mockWebhookRepo) FindAll(ctx context.Context) ([]domain.Webhook, error) {
+	return m.fakeWebhooks, nil
+}
+
+func (m *mockWebhookRepo) FindByID(id string, ctx context.Context) (*domain.Webhook, error) {
+	for i := range m.fakeWebhooks {
+		if m.fakeWebhooks[i].ID == id {
+			return &m.fakeWebhooks[i], nil
+		}
+	}
+	return nil, domain.ErrWebhookNotFound
+}
+
+func (m *mockWebhookRepo) Delete(id string, ctx context.Context) error {
+	return nil
+}
+
+func (m *mockWebhookRepo) FindByEventType(eventType domain.EventType, ctx context.Context) ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+	for _, webhook := range m.fakeWebhooks {
+		if webhook.Subscribed(eventType) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (m *mockWebhookRepo) SaveDeliveries(deliveries []domain.WebhookDelivery, ctx context.Context) error {
+	m.savedDeliveries = append(m.savedDeliveries, deliveries...)
+	return nil
+}
+
+func (m *mockWebhookRepo) ClaimDue(now time.Time, leaseUntil time.Time, limit int, ctx context.Context) ([]domain.WebhookDelivery, error) {
+	m.leaseUntil = leaseUntil
+	var due []domain.WebhookDelivery
+	for _, delivery := range m.savedDeliveries {
+		if delivery.Status == domain.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	return append(due, m.vanishing...), nil
+}
+
+func (m *mockWebhookRepo) UpdateDelivery(delivery *domain.WebhookDelivery, ctx context.Context) error {
+	for i := range m.savedDeliveries {
+		if m.savedDeliveries[i].ID == delivery.ID {
+			m.updatedDeliveries = append(m.updatedDeliveries, *delivery)
+			m.savedDeliveries[i] = *delivery
+			return nil
+		}
+	}
+	return domain.ErrWebhookDeliveryNotFound
+}
+
+func (m *mockWebhookRepo) FindDelivery(id string, ctx context.Context) (*domain.WebhookDelivery, error) {
+	for i := range m.savedDeliveries {
+		if m.savedDeliveries[i].ID == id {
+			delivery := m.savedDeliveries[i]
+			return &delivery, nil
+		}
+	}
+	return nil, domain.ErrWebhookDeliveryNotFound
+}
+
+func (m *mockWebhookRepo) FindDeliveries(webhookID string, status domain.WebhookDeliveryStatus, limit int, ctx context.Context) ([]domain.WebhookDelivery, error) {
+	return m.savedDeliveries, nil
+}
+
+// receivedWebhook is a request seen by the test receiver.
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// newWebhookReceiver starts a receiver that answers with status and records
+// every request.
+func newWebhookReceiver(t *testing.T, status int) (*httptest.Server, *[]receivedWebhook) {
+	var received []receivedWebhook
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, receivedWebhook{header: r.Header.Clone(), body: body})
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
+func stockEvent() domain.Event {
+	return domain.Event{ID: 1, Type: domain.EventStockChanged, ProductID: "prod-1", Data: domain.StockChangedEvent{ProductID: "prod-1", Stock: 4}, CreatedAt: time.Now().UTC()}
+}
+
+// TESTS
+func TestCreateWebhook_GeneratesSecret(t *testing.T) {
+	svc := NewWebhookService(&mockWebhookRepo{}, http.DefaultClient)
+	webhook := &domain.Webhook{URL: "https://erp.example.com/hooks", EventTypes: []domain.EventType{domain.EventOrderCreated}}
+	if err := svc.CreateWebhook(webhook, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(webhook.Secret) != 64 || webhook.ID == "" {
+		t.Errorf("expected an ID and a generated secret, got %+v", webhook)
+	}
+
+	invalid := []*domain.Webhook{
+		{URL: "ftp://erp.example.com", EventTypes: []domain.EventType{domain.EventOrderCreated}},
+		{URL: "/hooks", EventTypes: []domain.EventType{domain.EventOrderCreated}},
+		{URL: "https://erp.example.com"},
+		{URL: "https://erp.example.com", EventTypes: []domain.EventType{"order.deleted"}},
+	}
+	for _, webhook := range invalid {
+		if err := svc.CreateWebhook(webhook, context.Background()); err == nil {
+			t.Errorf("expected error for %+v, got nil", webhook)
+		}
+	}
+}
+
+func TestEnqueue_OnlySubscribedWebhooks(t *testing.T) {
+	mockWRepo := &mockWebhookRepo{fakeWebhooks: []domain.Webhook{
+		{ID: "hook-1", EventTypes: []domain.EventType{domain.EventStockChanged}},
+		{ID: "hook-2", EventTypes: []domain.EventType{domain.EventOrderCreated}},
+	}}
+	svc := NewWebhookService(mockWRepo, http.DefaultClient)
+	if err := svc.Enqueue(stockEvent(), context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(mockWRepo.savedDeliveries) != 1 || mockWRepo.savedDeliveries[0].WebhookID != "hook-1" {
+		t.Fatalf("expected one delivery for hook-1, got %+v", mockWRepo.savedDeliveries)
+	}
+	if mockWRepo.savedDeliveries[0].Status != domain.DeliveryPending {
+		t.Errorf("expected a pending delivery, got %v", mockWRepo.savedDeliveries[0].Status)
+	}
+}
+
+func TestDeliverDue_SignsAndDelivers(t *testing.T) {
+	server, received := newWebhookReceiver(t, http.StatusNoContent)
+	mockWRepo := &mockWebhookRepo{fakeWebhooks: []domain.Webhook{
+		{ID: "hook-1", URL: server.URL, Secret: "s3cret", EventTypes: []domain.EventType{domain.EventStockChanged}},
+	}}
+	svc := NewWebhookService(mockWRepo, server.Client())
+	if err := svc.Enqueue(stockEvent(), context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	now := time.Now().UTC()
+	count, err := svc.DeliverDue(now, context.Background())
+	if err != nil || count != 1 {
+		t.Fatalf("expected one delivery, got %v (%v)", count, err)
+	}
+	if len(*received) != 1 {
+		t.Fatalf("expected the receiver to get one request, got %v", len(*received))
+	}
+	request := (*received)[0]
+	expected := "sha256=" + signWebhook("s3cret", request.header.Get(WebhookTimestampHeader), request.body)
+	if request.header.Get(WebhookSignatureHeader) != expected {
+		t.Errorf("expected signature %v, got %v", expected, request.header.Get(WebhookSignatureHeader))
+	}
+	if request.header.Get(WebhookEventHeader) != string(domain.EventStockChanged) {
+		t.Errorf("expected event header stock.changed, got %v", request.header.Get(WebhookEventHeader))
+	}
+	delivery := mockWRepo.savedDeliveries[0]
+	if delivery.Status != domain.DeliveryDelivered || delivery.Attempts != 1 || delivery.ResponseStatus != http.StatusNoContent {
+		t.Errorf("expected a delivered delivery after one attempt, got %+v", delivery)
+	}
+}
+
+func TestDeliverDue_RetriesWithBackoffAndDeadLetters(t *testing.T) {
+	server, received := newWebhookReceiver(t, http.StatusInternalServerError)
+	mockWRepo := &mockWebhookRepo{fakeWebhooks: []domain.Webhook{
+		{ID: "hook-1", URL: server.URL, Secret: "s3cret", EventTypes: []domain.EventType{domain.EventStockChanged}},
+	}}
+	svc := NewWebhookService(mockWRepo, server.Client())
+	if err := svc.Enqueue(stockEvent(), context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	now := time.Now().UTC()
+	if _, err := svc.DeliverDue(now, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	delivery := mockWRepo.savedDeliveries[0]
+	if delivery.Status != domain.DeliveryPending || delivery.ResponseStatus != http.StatusInternalServerError || delivery.LastError == "" {
+		t.Fatalf("expected a pending delivery with the failure recorded, got %+v", delivery)
+	}
+	if !delivery.NextAttemptAt.Equal(now.Add(webhookRetryBase)) {
+		t.Errorf("expected the next attempt after %v, got %v", webhookRetryBase, delivery.NextAttemptAt.Sub(now))
+	}
+
+	if count, _ := svc.DeliverDue(now.Add(time.Second), context.Background()); count != 0 {
+		t.Errorf("expected no delivery before the backoff ran out, got %v", count)
+	}
+
+	for delivery.Status == domain.DeliveryPending {
+		now = delivery.NextAttemptAt
+		if _, err := svc.DeliverDue(now, context.Background()); err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+		delivery = mockWRepo.savedDeliveries[0]
+	}
+	if delivery.Status != domain.DeliveryDead || delivery.Attempts != webhookMaxAttempts {
+		t.Errorf("expected a dead delivery after %v attempts, got %+v", webhookMaxAttempts, delivery)
+	}
+	if len(*received) != webhookMaxAttempts {
+		t.Errorf("expected %v requests, got %v", webhookMaxAttempts, len(*received))
+	}
+
+	retried, err := svc.RetryDelivery(delivery.ID, now, context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if retried.Status != domain.DeliveryPending || retried.Attempts != 0 {
+		t.Errorf("expected the delivery to be queued again, got %+v", retried)
+	}
+	if _, err := svc.RetryDelivery(delivery.ID, now, context.Background()); err == nil {
+		t.Errorf("expected error retrying a pending delivery, got nil")
+	}
+}
+
+func TestDeliverDue_LeaseCoversBatch(t *testing.T) {
+	mockWRepo := &mockWebhookRepo{}
+	svc := NewWebhookService(mockWRepo, &http.Client{Timeout: 10 * time.Second})
+	now := time.Now().UTC()
+	if _, err := svc.DeliverDue(now, context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if lease := mockWRepo.leaseUntil.Sub(now); lease < webhookBatchSize*10*time.Second {
+		t.Errorf("expected the lease to cover a batch of timed out requests, got %v", lease)
+	}
+}
+
+func TestDeliverDue_SkipsDeletedWebhooks(t *testing.T) {
+	server, received := newWebhookReceiver(t, http.StatusNoContent)
+	mockWRepo := &mockWebhookRepo{fakeWebhooks: []domain.Webhook{
+		{ID: "hook-deleted", URL: server.URL, EventTypes: []domain.EventType{domain.EventStockChanged}},
+		{ID: "hook-1", URL: server.URL, EventTypes: []domain.EventType{domain.EventStockChanged}},
+	}}
+	svc := NewWebhookService(mockWRepo, server.Client())
+	if err := svc.Enqueue(stockEvent(), context.Background()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	// hook-deleted goes between claiming and delivering, and a delivery of
+	// hook-1 is deleted before its attempt is stored
+	mockWRepo.fakeWebhooks = mockWRepo.fakeWebhooks[1:]
+	mockWRepo.vanishing = []domain.WebhookDelivery{{ID: "gone", WebhookID: "hook-1", EventType: domain.EventStockChanged, Status: domain.DeliveryPending}}
+
+	count, err := svc.DeliverDue(time.Now().UTC(), context.Background())
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 claimed deliveries, got %v", count)
+	}
+	if len(*received) != 2 {
+		t.Errorf("expected only the deliveries of hook-1 to be sent, got %v", len(*received))
+	}
+	if len(mockWRepo.updatedDeliveries) != 1 || mockWRepo.updatedDeliveries[0].WebhookID != "hook-1" {
+		t.Errorf("expected only the stored delivery of hook-1 to be updated, got %+v", mockWRepo.updatedDeliveries)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	cases := map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 4: 4 * time.Minute, 20: time.Hour}
+	for attempts, expected := range cases {
+		if got := webhookBackoff(attempts); got != expected {
+			t.Errorf("expected backoff %v after %v attempts, got %v", expected, attempts, got)
+		}
+	}
+}
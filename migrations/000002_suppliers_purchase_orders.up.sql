@@ -0,0 +1,44 @@
+CREATE TABLE IF NOT EXISTS suppliers (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	email TEXT NOT NULL DEFAULT '',
+	phone TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS supplier_products (
+	supplier_id TEXT NOT NULL REFERENCES suppliers(id),
+	product_id TEXT NOT NULL REFERENCES products(id),
+	unit_cost DECIMAL NOT NULL,
+	lead_time_days INT NOT NULL DEFAULT 0,
+	PRIMARY KEY (supplier_id, product_id)
+);
+
+CREATE TABLE IF NOT EXISTS purchase_orders (
+	id TEXT PRIMARY KEY,
+	supplier_id TEXT NOT NULL REFERENCES suppliers(id),
+	status TEXT NOT NULL DEFAULT 'draft',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS purchase_order_lines (
+	id TEXT PRIMARY KEY,
+	purchase_order_id TEXT NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+	product_id TEXT NOT NULL REFERENCES products(id),
+	quantity INT NOT NULL CHECK (quantity > 0),
+	received_quantity INT NOT NULL DEFAULT 0 CHECK (received_quantity <= quantity),
+	unit_cost DECIMAL NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS receipts (
+	id TEXT PRIMARY KEY,
+	purchase_order_id TEXT NOT NULL REFERENCES purchase_orders(id),
+	received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS receipt_lines (
+	id TEXT PRIMARY KEY,
+	receipt_id TEXT NOT NULL REFERENCES receipts(id) ON DELETE CASCADE,
+	purchase_order_line_id TEXT NOT NULL REFERENCES purchase_order_lines(id),
+	product_id TEXT NOT NULL REFERENCES products(id),
+	quantity INT NOT NULL CHECK (quantity > 0)
+);
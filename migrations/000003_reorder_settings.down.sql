@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_orders_created_at;
+ALTER TABLE products DROP COLUMN IF EXISTS reorder_quantity;
+ALTER TABLE products DROP COLUMN IF EXISTS reorder_point;
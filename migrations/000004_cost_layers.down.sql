@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS cost_consumptions;
+DROP TABLE IF EXISTS cost_layers;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS cost_layers (
+	id TEXT PRIMARY KEY,
+	product_id TEXT NOT NULL REFERENCES products(id),
+	receipt_line_id TEXT REFERENCES receipt_lines(id),
+	quantity INT NOT NULL CHECK (quantity > 0),
+	remaining INT NOT NULL CHECK (remaining >= 0),
+	unit_cost DECIMAL NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_layers_open ON cost_layers (product_id, created_at) WHERE remaining > 0;
+
+CREATE TABLE IF NOT EXISTS cost_consumptions (
+	id TEXT PRIMARY KEY,
+	layer_id TEXT NOT NULL REFERENCES cost_layers(id),
+	product_id TEXT NOT NULL REFERENCES products(id),
+	order_id TEXT NOT NULL REFERENCES orders(id),
+	quantity INT NOT NULL CHECK (quantity > 0),
+	unit_cost DECIMAL NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_consumptions_product ON cost_consumptions (product_id, created_at);
@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS stock_movements (
+	id TEXT PRIMARY KEY,
+	product_id TEXT NOT NULL REFERENCES products(id),
+	quantity INT NOT NULL,
+	reason TEXT NOT NULL,
+	reference_id TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product ON stock_movements (product_id, created_at);
+
+CREATE TABLE IF NOT EXISTS stock_snapshots (
+	product_id TEXT NOT NULL REFERENCES products(id),
+	stock INT NOT NULL,
+	taken_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (product_id, taken_at)
+);
+
+-- Rebuild the history we can from existing orders and receipts. Whatever
+-- they do not explain becomes an initial movement dated before the first of them.
+INSERT INTO stock_movements (id, product_id, quantity, reason, reference_id, created_at)
+SELECT gen_random_uuid()::TEXT, product_id, -quantity, 'order', id, created_at FROM orders;
+
+INSERT INTO stock_movements (id, product_id, quantity, reason, reference_id, created_at)
+SELECT gen_random_uuid()::TEXT, rl.product_id, rl.quantity, 'receipt', rl.id, r.received_at
+FROM receipt_lines rl JOIN receipts r ON r.id = rl.receipt_id;
+
+INSERT INTO stock_movements (id, product_id, quantity, reason, reference_id, created_at)
+SELECT gen_random_uuid()::TEXT, p.id, p.stock - COALESCE(SUM(m.quantity), 0), 'initial', '',
+	COALESCE((SELECT MIN(created_at) FROM stock_movements) - INTERVAL '1 second', CURRENT_TIMESTAMP)
+FROM products p LEFT JOIN stock_movements m ON m.product_id = p.id
+GROUP BY p.id, p.stock
+HAVING p.stock - COALESCE(SUM(m.quantity), 0) <> 0;
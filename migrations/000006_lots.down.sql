@@ -0,0 +1,4 @@
+ALTER TABLE receipt_lines DROP COLUMN IF EXISTS lot_number;
+DROP TABLE IF EXISTS order_lot_allocations;
+DROP TABLE IF EXISTS lots;
+ALTER TABLE products DROP COLUMN IF EXISTS lot_tracked;
@@ -0,0 +1,26 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS lot_tracked BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS lots (
+	id TEXT PRIMARY KEY,
+	product_id TEXT NOT NULL REFERENCES products(id),
+	lot_number TEXT NOT NULL,
+	manufactured_at TIMESTAMP,
+	expires_at TIMESTAMP,
+	quantity INT NOT NULL CHECK (quantity > 0),
+	remaining INT NOT NULL CHECK (remaining >= 0),
+	received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (product_id, lot_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lots_allocatable ON lots (product_id, expires_at) WHERE remaining > 0;
+
+CREATE TABLE IF NOT EXISTS order_lot_allocations (
+	order_id TEXT NOT NULL REFERENCES orders(id),
+	lot_id TEXT NOT NULL REFERENCES lots(id),
+	quantity INT NOT NULL CHECK (quantity > 0),
+	PRIMARY KEY (order_id, lot_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_lot_allocations_lot ON order_lot_allocations (lot_id);
+
+ALTER TABLE receipt_lines ADD COLUMN IF NOT EXISTS lot_number TEXT;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_lots_lot_number;
+ALTER TABLE lots DROP COLUMN IF EXISTS quarantined;
+ALTER TABLE orders DROP COLUMN IF EXISTS customer;
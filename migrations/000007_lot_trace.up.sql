@@ -0,0 +1,5 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS customer TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE lots ADD COLUMN IF NOT EXISTS quarantined BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_lots_lot_number ON lots (lot_number);
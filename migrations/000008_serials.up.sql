@@ -0,0 +1,22 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS serialized BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS serials (
+	serial_number TEXT PRIMARY KEY,
+	product_id TEXT NOT NULL REFERENCES products(id),
+	status TEXT NOT NULL,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_serials_product ON serials (product_id, status);
+
+CREATE TABLE IF NOT EXISTS serial_events (
+	id TEXT PRIMARY KEY,
+	serial_number TEXT NOT NULL REFERENCES serials(serial_number),
+	product_id TEXT NOT NULL REFERENCES products(id),
+	event TEXT NOT NULL,
+	reference_id TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_serial_events_serial ON serial_events (serial_number, created_at);
+CREATE INDEX IF NOT EXISTS idx_serial_events_reference ON serial_events (reference_id, event);
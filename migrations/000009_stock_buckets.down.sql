@@ -0,0 +1,4 @@
+ALTER TABLE products
+	DROP COLUMN IF EXISTS quarantined,
+	DROP COLUMN IF EXISTS damaged,
+	DROP COLUMN IF EXISTS reserved;
@@ -0,0 +1,4 @@
+ALTER TABLE products
+	ADD COLUMN IF NOT EXISTS reserved INT NOT NULL DEFAULT 0 CHECK (reserved >= 0),
+	ADD COLUMN IF NOT EXISTS damaged INT NOT NULL DEFAULT 0 CHECK (damaged >= 0),
+	ADD COLUMN IF NOT EXISTS quarantined INT NOT NULL DEFAULT 0 CHECK (quarantined >= 0);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS expected_receipts (
+	id TEXT PRIMARY KEY,
+	product_id TEXT NOT NULL REFERENCES products(id),
+	purchase_order_id TEXT REFERENCES purchase_orders(id),
+	quantity INT NOT NULL CHECK (quantity > 0),
+	received INT NOT NULL DEFAULT 0 CHECK (received >= 0 AND received <= quantity),
+	expected_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_expected_receipts_open ON expected_receipts (product_id, expected_at) WHERE received < quantity;
@@ -0,0 +1,9 @@
+ALTER TABLE receipt_lines
+	DROP COLUMN IF EXISTS unit_quantity,
+	DROP COLUMN IF EXISTS unit;
+
+ALTER TABLE orders
+	DROP COLUMN IF EXISTS unit_quantity,
+	DROP COLUMN IF EXISTS unit;
+
+DROP TABLE IF EXISTS product_units;
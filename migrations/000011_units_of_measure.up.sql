@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS product_units (
+	product_id TEXT NOT NULL REFERENCES products(id),
+	unit TEXT NOT NULL,
+	factor INT NOT NULL CHECK (factor > 0),
+	PRIMARY KEY (product_id, unit)
+);
+
+ALTER TABLE orders
+	ADD COLUMN IF NOT EXISTS unit TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS unit_quantity DECIMAL NOT NULL DEFAULT 0;
+
+ALTER TABLE receipt_lines
+	ADD COLUMN IF NOT EXISTS unit TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS unit_quantity DECIMAL NOT NULL DEFAULT 0;
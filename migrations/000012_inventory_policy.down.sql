@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_products_negative_stock;
+ALTER TABLE products DROP COLUMN IF EXISTS inventory_policy;
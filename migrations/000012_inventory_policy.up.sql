@@ -0,0 +1,3 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS inventory_policy TEXT NOT NULL DEFAULT 'tracked';
+
+CREATE INDEX IF NOT EXISTS idx_products_negative_stock ON products (stock) WHERE stock < 0;
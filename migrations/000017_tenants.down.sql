@@ -0,0 +1,28 @@
+DROP INDEX IF EXISTS idx_lots_lot_number;
+CREATE INDEX IF NOT EXISTS idx_lots_lot_number ON lots (lot_number);
+DROP INDEX IF EXISTS idx_purchase_orders_tenant_created;
+DROP INDEX IF EXISTS idx_suppliers_tenant;
+DROP INDEX IF EXISTS idx_webhooks_tenant;
+DROP INDEX IF EXISTS idx_orders_tenant_created;
+DROP INDEX IF EXISTS idx_products_tenant;
+
+ALTER TABLE serial_events DROP CONSTRAINT IF EXISTS serial_events_serial_number_fkey;
+ALTER TABLE serials DROP CONSTRAINT IF EXISTS serials_pkey;
+ALTER TABLE serials ADD PRIMARY KEY (serial_number);
+ALTER TABLE serial_events ADD CONSTRAINT serial_events_serial_number_fkey
+	FOREIGN KEY (serial_number) REFERENCES serials(serial_number);
+DROP INDEX IF EXISTS idx_serial_events_serial;
+CREATE INDEX IF NOT EXISTS idx_serial_events_serial ON serial_events (serial_number, created_at);
+
+ALTER TABLE serial_events DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE serials DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE lots DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE expected_receipts DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE purchase_orders DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE suppliers DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE products DROP COLUMN IF EXISTS tenant_id;
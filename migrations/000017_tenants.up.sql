@@ -0,0 +1,44 @@
+-- Existing data belongs to the default tenant. The defaults are dropped
+-- afterwards so every insert has to name its tenant.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE suppliers ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE purchase_orders ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE expected_receipts ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE lots ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE serials ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE serial_events ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+ALTER TABLE products ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE orders ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE api_keys ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE webhooks ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE webhook_deliveries ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE suppliers ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE purchase_orders ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE expected_receipts ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE lots ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE serials ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE serial_events ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- Serial numbers are unique per tenant, not globally.
+ALTER TABLE serial_events DROP CONSTRAINT IF EXISTS serial_events_serial_number_fkey;
+ALTER TABLE serials DROP CONSTRAINT IF EXISTS serials_pkey;
+ALTER TABLE serials ADD PRIMARY KEY (tenant_id, serial_number);
+ALTER TABLE serial_events ADD CONSTRAINT serial_events_serial_number_fkey
+	FOREIGN KEY (tenant_id, serial_number) REFERENCES serials(tenant_id, serial_number);
+DROP INDEX IF EXISTS idx_serial_events_serial;
+CREATE INDEX IF NOT EXISTS idx_serial_events_serial ON serial_events (tenant_id, serial_number, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_products_tenant ON products (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_orders_tenant_created ON orders (tenant_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_webhooks_tenant ON webhooks (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_suppliers_tenant ON suppliers (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_tenant_created ON purchase_orders (tenant_id, created_at);
+DROP INDEX IF EXISTS idx_lots_lot_number;
+CREATE INDEX IF NOT EXISTS idx_lots_lot_number ON lots (tenant_id, lot_number);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_expected_receipts_open;
+CREATE INDEX IF NOT EXISTS idx_expected_receipts_open ON expected_receipts (product_id, expected_at) WHERE received < quantity;
+
+ALTER TABLE expected_receipts DROP COLUMN IF EXISTS closed_at;
@@ -0,0 +1,6 @@
+-- Expected receipts that will not arrive, e.g. those without a purchase order
+-- whose receipts would close them, are closed by hand.
+ALTER TABLE expected_receipts ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP;
+
+DROP INDEX IF EXISTS idx_expected_receipts_open;
+CREATE INDEX IF NOT EXISTS idx_expected_receipts_open ON expected_receipts (product_id, expected_at) WHERE received < quantity AND closed_at IS NULL;
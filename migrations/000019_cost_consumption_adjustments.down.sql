@@ -0,0 +1,2 @@
+DELETE FROM cost_consumptions WHERE order_id IS NULL;
+ALTER TABLE cost_consumptions ALTER COLUMN order_id SET NOT NULL;
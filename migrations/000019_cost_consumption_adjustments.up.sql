@@ -0,0 +1,2 @@
+-- Stock adjustments and reconciliations consume cost layers without an order.
+ALTER TABLE cost_consumptions ALTER COLUMN order_id DROP NOT NULL;